@@ -5,6 +5,14 @@ use systems-management
 
 db.createCollection("user", { capped: false });
 db.createCollection("domain", { capped: false });
+db.createCollection("server", { capped: false });
+db.createCollection("package", { capped: false });
+db.createCollection("contact", { capped: false });
+db.createCollection("health_check", { capped: false });
+db.createCollection("record", { capped: false });
+db.createCollection("job", { capped: false });
+db.createCollection("customfield", { capped: false });
+db.createCollection("comment", { capped: false });
 db.user.createIndex({ email: 1 }, { unique: true });
 db.user.insert([
   {
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"systems-management-api/core/export"
+	"time"
+)
+
+// exportColumns the columns of the users export, the password hash is exported only on privileged request
+var exportColumns = []export.Column{
+	{Name: "id"},
+	{Name: "email"},
+	{Name: "role"},
+	{Name: "created"},
+	{Name: "password", Privileged: true},
+}
+
+// exportValue returns the value of a column of the users export
+func exportValue(user *User, column string) interface{} {
+	switch column {
+	case "id":
+		return user.ID.Hex()
+	case "email":
+		return user.Email
+	case "role":
+		return user.Role
+	case "created":
+		if user.Created == 0 {
+			return time.Time{}
+		}
+		return time.Unix(user.Created, 0)
+	case "password":
+		return user.Password
+	}
+	return nil
+}
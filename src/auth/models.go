@@ -2,17 +2,21 @@ package auth
 
 import (
 	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson/primitive" // for BSON ObjectID
 )
 
 // User the user model
 type User struct {
-	ID       primitive.ObjectID `bson:"_id,omitempty"`
-	Email    string             `json:"email"`
-	Password string             `json:"password"`
-	Created  int64              `json:"created"`
-	Role     string             `json:"role"`
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	Email         string             `json:"email"`
+	Password      string             `json:"password"`
+	Created       int64              `json:"created"`
+	Role          string             `json:"role"`
+	CalendarToken string             `json:"calendarToken"` // sha256 of the calendar feed token, the token itself is not stored
 }
 
 func (self *User) isAnonymous() bool {
@@ -24,6 +28,23 @@ func (self *User) SetPassword(password string) {
 	self.Password = fmt.Sprintf("%x", md5.Sum(md5Password))
 }
 
+// NewCalendarToken generates a new calendar feed token, replacing the previous one, and returns it
+// Only its hash is kept on the user, the user must be saved afterwards
+func (self *User) NewCalendarToken() (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(random)
+	self.CalendarToken = hashCalendarToken(token)
+	return token, nil
+}
+
+// hashCalendarToken returns the hash of a calendar feed token, as stored on the user
+func hashCalendarToken(token string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(token)))
+}
+
 func (self *User) Save() (bool, error) {
 	userService := new(UserService) // @TODO factory method
 	result, err := userService.Save(self)
@@ -12,4 +12,11 @@ func RoutesRegister(router *gin.RouterGroup) {
 	router.POST("/user", CreateUserView)
 	router.PUT("/user/:id", UpdateUserView)
 	router.DELETE("/user/:id", DeleteUserView)
+	router.POST("/calendar/token", CreateCalendarTokenView)
+	router.DELETE("/calendar/token", DeleteCalendarTokenView)
+}
+
+// ExportRoutesRegister attaches export routes (path + view) to the given gin router group (paths namespace)
+func ExportRoutesRegister(router *gin.RouterGroup) {
+	router.GET("/user", ExportUsersView)
 }
@@ -6,15 +6,30 @@ import (
 	"errors"
 	"fmt"
 	database "systems-management-api/core/database"
+	"systems-management-api/core/references"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 )
 
+// EnsureIndexes creates the indexes of the user collection
+func EnsureIndexes() {
+	db := database.DB()
+	err := db.EnsureIndexes("user", []mongo.IndexModel{
+		// calendar feeds look up their user by token on every poll
+		{Keys: bson.D{{Key: "calendartoken", Value: 1}}, Options: options.Index().SetName("calendartoken")},
+	})
+	if err != nil {
+		zap.S().Error("Error creating user indexes: ", err)
+	}
+}
+
 /* AUTHENTICATION */
 
 // AuthenticationService common interface for all authentication service providers
@@ -153,6 +168,28 @@ func (service *UserService) all() (*[]User, error) {
 	}
 }
 
+// Iterates over all users without loading them all in memory
+func (service *UserService) each(fn func(user *User) error) error {
+	db := database.DB()
+	collection := db.D.Collection("user")
+	cursor, err := collection.Find(context.TODO(), bson.D{{}}, options.Find().SetSort(bson.M{"email": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.TODO())
+
+	for cursor.Next(context.TODO()) {
+		var user User
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+		if err := fn(&user); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
 func (service *UserService) GetById(id string) (*User, error) {
 	db := database.DB()
 	user := User{}
@@ -164,6 +201,37 @@ func (service *UserService) GetById(id string) (*User, error) {
 	}
 }
 
+// Returns the number of documents referencing the user, grouped by collection
+func (service *UserService) References(user *User) (map[string]int64, error) {
+	return references.Count("user", user.ID)
+}
+
+// Returns the number of users of each role
+func (service *UserService) CountByRole() (map[string]int64, error) {
+	db := database.DB()
+	collection := db.D.Collection("user")
+	cursor, err := collection.Aggregate(context.TODO(), mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$role", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	res := map[string]int64{}
+	for cursor.Next(context.TODO()) {
+		var row struct {
+			Role  string `bson:"_id"`
+			Count int64  `bson:"count"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+		res[row.Role] = row.Count
+	}
+	return res, cursor.Err()
+}
+
 // Returns an user instance given an email
 func (service *UserService) GetByEmail(email string) (*User, error) {
 	var user User
@@ -181,6 +249,26 @@ func (service *UserService) GetByEmail(email string) (*User, error) {
 	}
 }
 
+// Returns the user owning the given calendar feed token
+func (service *UserService) GetByCalendarToken(token string) (*User, error) {
+	if token == "" {
+		return nil, errors.New("Empty calendar token")
+	}
+	var user User
+	db := database.DB()
+	collection := db.D.Collection("user")
+	err := collection.FindOne(
+		context.TODO(),
+		bson.M{"calendartoken": hashCalendarToken(token)},
+	).Decode(&user)
+
+	if err != nil {
+		return nil, err
+	} else {
+		return &user, nil
+	}
+}
+
 // Saves the user model to database
 // Returns boolean result and error
 func (service *UserService) Save(user *User) (bool, error) {
@@ -13,7 +13,7 @@ import (
 type UserValidatorData struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=8,max=255"`
-	Role     string `json:"role" binding:"required,oneof=superadmin admin"`
+	Role     string `json:"role" binding:"required,oneof=superadmin admin user"`
 }
 type UserValidator struct {
 	UserData UserValidatorData `json:"user"`
@@ -23,7 +23,7 @@ type UserValidator struct {
 type UserUpdateValidatorData struct {
 	Email    string `json:"email,omitempty" binding:"email"`
 	Password string `json:"password,omitempty" binding:"min=8,max=255"`
-	Role     string `json:"role,omitempty" binding:"oneof=superadmin admin"`
+	Role     string `json:"role,omitempty" binding:"oneof=superadmin admin user"`
 }
 type UserUpdateValidator struct {
 	UserUpdateData UserUpdateValidatorData `json:"user"`
@@ -61,6 +61,8 @@ func (self *UserUpdateValidator) BindUpdate(user *User, c *gin.Context) error {
 		self.user.SetPassword(self.UserUpdateData.Password)
 	}
 	self.user.Created = user.Created
+	// the calendar feed token is managed by its own endpoints
+	self.user.CalendarToken = user.CalendarToken
 
 	return nil
 }
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBindUpdateKeepsCalendarToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := User{ID: primitive.NewObjectID(), Email: "user@example.com", Role: "user", Created: 1000, CalendarToken: hashCalendarToken("secret")}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"email": "user@example.com", "password": "new password", "role": "admin"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	validator := NewUserUpdatelValidator(&user)
+	if err := validator.BindUpdate(&user, c); err != nil {
+		t.Fatal(err)
+	}
+	if validator.user.Role != "admin" || validator.user.ID != user.ID || validator.user.Created != user.Created {
+		t.Fatalf("unexpected user %+v", validator.user)
+	}
+	if validator.user.CalendarToken != user.CalendarToken {
+		t.Fatal("the calendar token must be kept on update")
+	}
+}
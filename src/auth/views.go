@@ -5,7 +5,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"net/http"
+	"strconv"
+	"systems-management-api/core/export"
 	"systems-management-api/core/utils"
+	"time"
 )
 
 // LoginCredentials data type for authentication payload
@@ -18,6 +21,11 @@ type LoginSuccessResponse struct {
 	Token string `json:"token"`
 }
 
+// CalendarTokenResponse the calendar feed token, returned only when generated
+type CalendarTokenResponse struct {
+	Token string `json:"token"`
+}
+
 // Allows users to authenticate providing email and password
 // @Summary Login user
 // @Description Generates and sends a jwt token given user credentials (email and password)
@@ -198,6 +206,7 @@ var UpdateUserView = RoleRequired([]string{"admin", "superadmin"}, updateUserVie
 // @Success 204
 // @Failure 403 {object} utils.ErrorResponse
 // @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /auth/user/{id} [delete]
 func deleteUserView(c *gin.Context) {
@@ -208,6 +217,17 @@ func deleteUserView(c *gin.Context) {
 		zap.S().Errorw("Error while getting user, Reason: ", "id", c.Param("id"), "error", err)
 		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "User not found"})
 	} else {
+		refs, err := userService.References(user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: err.Error()})
+			return
+		}
+		if len(refs) > 0 {
+			c.JSON(http.StatusConflict, utils.ErrorResponse{
+				Message: fmt.Sprintf("User %s is still referenced: %v", user.Email, refs),
+			})
+			return
+		}
 		if _, err := user.Delete(); err != nil {
 			c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: err.Error()})
 		}
@@ -216,3 +236,115 @@ func deleteUserView(c *gin.Context) {
 }
 
 var DeleteUserView = RoleRequired([]string{"admin", "superadmin"}, deleteUserView)
+
+// Generates the calendar feed token of the logged in user, replacing the previous one
+// @Summary Generate calendar token
+// @Description Generates a new token for the calendar feed of the logged in user (GET /calendar/domain.ics?token=...), the previous token stops working. The token is returned only once
+// @Security BearerAuth
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Success 201 {object} CalendarTokenResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /auth/calendar/token [post]
+func createCalendarTokenView(c *gin.Context) {
+	user := c.MustGet("user").(*User)
+	token, err := user.NewCalendarToken()
+	if err != nil {
+		zap.S().Error("Error while generating calendar token, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: "Cannot generate calendar token"})
+		return
+	}
+	if _, err := user.Save(); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, CalendarTokenResponse{token})
+}
+
+var CreateCalendarTokenView = RoleRequired([]string{"admin", "superadmin", "user"}, createCalendarTokenView)
+
+// Revokes the calendar feed token of the logged in user
+// @Summary Revoke calendar token
+// @Description Revokes the calendar feed token of the logged in user, the feed is no longer accessible until a new token is generated
+// @Security BearerAuth
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Success 204
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /auth/calendar/token [delete]
+func deleteCalendarTokenView(c *gin.Context) {
+	user := c.MustGet("user").(*User)
+	user.CalendarToken = ""
+	if _, err := user.Save(); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, gin.H{})
+}
+
+var DeleteCalendarTokenView = RoleRequired([]string{"admin", "superadmin", "user"}, deleteCalendarTokenView)
+
+// Exports the users as CSV, XLSX or NDJSON
+// @Summary Export users
+// @Description Streams all users as a CSV, XLSX or NDJSON file. The password hash column is exported only with the privileged flag, which requires the superadmin role.
+// @Security BearerAuth
+// @Tags auth
+// @Produce  plain
+// @Param format query string false "Export format: csv (default), xlsx or ndjson"
+// @Param columns query string false "Comma separated list of the exported columns, all by default"
+// @Param privileged query bool false "Export the privileged columns (password hash), superadmin role required"
+// @Success 200 {file} file
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /export/user [get]
+func exportUsersView(c *gin.Context) {
+	format := c.DefaultQuery("format", export.CSV)
+	if !utils.Contains(export.Formats, format) {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Unsupported format %s", format)})
+		return
+	}
+	privileged, _ := strconv.ParseBool(c.Query("privileged"))
+	if privileged && c.MustGet("user").(*User).Role != "superadmin" {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse{Message: "The privileged export requires the superadmin role"})
+		return
+	}
+	columns, err := export.SelectColumns(exportColumns, c.Query("columns"), privileged)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("users-%s.%s", time.Now().Format("20060102"), format)))
+	c.Status(http.StatusOK)
+	writer, err := export.NewWriter(format, c.Writer)
+	if err == nil {
+		err = writer.Header(columns)
+	}
+	if err != nil {
+		zap.S().Error("Error while exporting users, Reason: ", err)
+		return
+	}
+
+	userService := new(UserService)
+	err = userService.each(func(user *User) error {
+		values := make([]interface{}, len(columns))
+		for i, column := range columns {
+			values[i] = exportValue(user, column)
+		}
+		return writer.Row(values)
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		// the response is already being streamed, the status cannot be changed anymore
+		zap.S().Error("Error while exporting users, Reason: ", err)
+	}
+}
+
+var ExportUsersView = RoleRequired([]string{"admin", "superadmin"}, exportUsersView)
@@ -0,0 +1,46 @@
+package contacts
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive" // for BSON ObjectID
+)
+
+// Contact kinds
+const (
+	KindCompany = "company"
+	KindPerson  = "person"
+)
+
+// PostalAddress the contact postal address
+type PostalAddress struct {
+	Street   string `json:"street"`
+	City     string `json:"city"`
+	Zip      string `json:"zip"`
+	Province string `json:"province"`
+	Country  string `json:"country"`
+}
+
+// Contact the contact model, a customer (company or person) owning or registering domains
+type Contact struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty"`
+	Kind    string             `json:"kind"`
+	Name    string             `json:"name"`
+	Emails  []string           `json:"emails"`
+	Phones  []string           `json:"phones"`
+	Vat     string             `json:"vat"`
+	Address PostalAddress      `json:"address"`
+	Notes   string             `json:"notes"`
+	Created int64              `json:"created"`
+	Updated int64              `json:"updated"`
+}
+
+func (self *Contact) Save() (bool, error) {
+	contactService := new(ContactService) // @TODO factory method
+	result, err := contactService.Save(self)
+	return result, err
+}
+
+func (self *Contact) Delete() (bool, error) {
+	contactService := new(ContactService) // @TODO factory method
+	result, err := contactService.Delete(self)
+	return result, err
+}
@@ -0,0 +1,20 @@
+package contacts
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RoutesRegister attaches routes (path + view) to the given gin router group (paths namespace)
+func RoutesRegister(router *gin.RouterGroup) {
+	router.GET("", ContactListView)
+	router.GET("/:id", ContactDetailView)
+	router.POST("", CreateContactView)
+	router.PUT("/:id", UpdateContactView)
+	router.DELETE("/:id", DeleteContactView)
+	router.POST("/:id/merge", MergeContactsView)
+}
+
+// ReportRoutesRegister attaches reports routes (path + view) to the given gin router group (paths namespace)
+func ReportRoutesRegister(router *gin.RouterGroup) {
+	router.GET("/contact/duplicates", DuplicateContactsView)
+}
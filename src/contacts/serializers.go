@@ -0,0 +1,103 @@
+package contacts
+
+type contactSerializer struct{}
+
+type PostalAddressData struct {
+	Street   string `json:"street"`
+	City     string `json:"city"`
+	Zip      string `json:"zip"`
+	Province string `json:"province"`
+	Country  string `json:"country"`
+}
+
+type ContactData struct {
+	ID      string            `json:"id"`
+	Kind    string            `json:"kind"`
+	Name    string            `json:"name"`
+	Emails  []string          `json:"emails"`
+	Phones  []string          `json:"phones"`
+	Vat     string            `json:"vat"`
+	Address PostalAddressData `json:"address"`
+	Notes   string            `json:"notes"`
+	Created int64             `json:"created"`
+	Updated int64             `json:"updated"`
+}
+
+type DuplicatesData struct {
+	Reason   string        `json:"reason"`
+	Value    string        `json:"value"`
+	Contacts []ContactData `json:"contacts"`
+}
+
+type MergeResultData struct {
+	Contact    ContactData      `json:"contact"`
+	Merged     []string         `json:"merged"`
+	References map[string]int64 `json:"references"`
+}
+
+func NewContactSerializer() *contactSerializer {
+	return &contactSerializer{}
+}
+
+func (self *contactSerializer) Serialize(contact *Contact) ContactData {
+	contactData := ContactData{
+		ID:     contact.ID.Hex(),
+		Kind:   contact.Kind,
+		Name:   contact.Name,
+		Emails: nonNil(contact.Emails),
+		Phones: nonNil(contact.Phones),
+		Vat:    contact.Vat,
+		Address: PostalAddressData{
+			Street:   contact.Address.Street,
+			City:     contact.Address.City,
+			Zip:      contact.Address.Zip,
+			Province: contact.Address.Province,
+			Country:  contact.Address.Country,
+		},
+		Notes:   contact.Notes,
+		Created: contact.Created,
+		Updated: contact.Updated,
+	}
+	return contactData
+}
+
+func (self *contactSerializer) SerializeMany(contacts *[]Contact) []ContactData {
+	var res []ContactData
+	res = make([]ContactData, 0)
+	for _, contact := range *contacts {
+		res = append(res, self.Serialize(&contact))
+	}
+	return res
+}
+
+func (self *contactSerializer) SerializeDuplicates(duplicates *[]Duplicates) []DuplicatesData {
+	res := make([]DuplicatesData, 0)
+	for _, group := range *duplicates {
+		res = append(res, DuplicatesData{
+			Reason:   group.Reason,
+			Value:    group.Value,
+			Contacts: self.SerializeMany(&group.Contacts),
+		})
+	}
+	return res
+}
+
+func (self *contactSerializer) SerializeMergeResult(contact *Contact, merged *[]Contact, references map[string]int64) MergeResultData {
+	ids := make([]string, 0)
+	for _, c := range *merged {
+		ids = append(ids, c.ID.Hex())
+	}
+	return MergeResultData{
+		Contact:    self.Serialize(contact),
+		Merged:     ids,
+		References: references,
+	}
+}
+
+// nonNil returns an empty slice instead of nil, so that it's serialized as an empty array
+func nonNil(values []string) []string {
+	if values == nil {
+		return make([]string, 0)
+	}
+	return values
+}
@@ -0,0 +1,251 @@
+package contacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"sort"
+	"strings"
+	database "systems-management-api/core/database"
+	"systems-management-api/core/references"
+	"systems-management-api/core/utils"
+	"time"
+	"unicode"
+)
+
+// EnsureIndexes creates the contact collection indexes
+func EnsureIndexes() {
+	db := database.DB()
+	err := db.EnsureIndexes("contact", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetName("name")},
+		{Keys: bson.D{{Key: "emails", Value: 1}}, Options: options.Index().SetName("emails")},
+	})
+	if err != nil {
+		zap.S().Error("Error creating contact indexes: ", err)
+	}
+}
+
+// Duplicates a group of contacts which are likely to be the same customer
+type Duplicates struct {
+	Reason   string
+	Value    string
+	Contacts []Contact
+}
+
+// ContactService service which provides methods to access and modify database data
+type ContactService struct{}
+
+// Retrieves all contacts instances, sorted by name
+func (service *ContactService) all() (*[]Contact, error) {
+	db := database.DB()
+	collection := db.D.Collection("contact")
+	cursor, err := collection.Find(context.TODO(), bson.D{{}}, options.Find().SetSort(bson.M{"name": 1}))
+
+	if err != nil {
+		return nil, err
+	} else {
+		contacts := []Contact{}
+		for cursor.Next(context.TODO()) {
+			var contact Contact
+			cursor.Decode(&contact)
+			contacts = append(contacts, contact)
+		}
+		return &contacts, nil
+	}
+}
+
+// Retrieves a contact instance given its ID
+func (service *ContactService) GetById(id string) (*Contact, error) {
+	db := database.DB()
+	contact := Contact{}
+
+	if err := db.GetById("contact", id, &contact); err != nil {
+		return nil, err
+	} else {
+		return &contact, nil
+	}
+}
+
+// Retrieves the first contact instance with the given name
+func (service *ContactService) GetByName(name string) (*Contact, error) {
+	db := database.DB()
+	collection := db.D.Collection("contact")
+	contact := Contact{}
+
+	if err := collection.FindOne(context.TODO(), bson.M{"name": name}).Decode(&contact); err != nil {
+		return nil, err
+	} else {
+		return &contact, nil
+	}
+}
+
+// Returns the number of documents referencing the contact, grouped by collection
+func (service *ContactService) References(contact *Contact) (map[string]int64, error) {
+	return references.Count("contact", contact.ID)
+}
+
+// Finds the groups of contacts sharing the same normalized name, email or VAT number
+func (service *ContactService) Duplicates() (*[]Duplicates, error) {
+	contacts, err := service.all()
+	if err != nil {
+		return nil, err
+	}
+
+	res := []Duplicates{}
+	group := func(reason string, keys func(contact *Contact) []string) {
+		groups := map[string][]Contact{}
+		for _, contact := range *contacts {
+			for _, key := range keys(&contact) {
+				if key != "" {
+					groups[key] = append(groups[key], contact)
+				}
+			}
+		}
+		values := make([]string, 0)
+		for value, contacts := range groups {
+			if len(contacts) > 1 {
+				values = append(values, value)
+			}
+		}
+		sort.Strings(values)
+		for _, value := range values {
+			res = append(res, Duplicates{Reason: reason, Value: value, Contacts: groups[value]})
+		}
+	}
+	group("name", func(contact *Contact) []string { return []string{normalizeName(contact.Name)} })
+	group("email", func(contact *Contact) []string {
+		emails := []string{}
+		for _, email := range contact.Emails {
+			if !utils.Contains(emails, strings.ToLower(email)) {
+				emails = append(emails, strings.ToLower(email))
+			}
+		}
+		return emails
+	})
+	group("vat", func(contact *Contact) []string { return []string{normalizeVat(contact.Vat)} })
+
+	return &res, nil
+}
+
+// Merges the given contacts into the target one: emails and phones are joined, empty fields are filled,
+// references to the merged contacts are moved to the target and the merged contacts are deleted
+// Returns the number of updated references grouped by collection and error
+func (service *ContactService) Merge(target *Contact, others *[]Contact) (map[string]int64, error) {
+	ids := []primitive.ObjectID{}
+	for _, other := range *others {
+		if other.ID == target.ID {
+			return nil, errors.New("A contact cannot be merged into itself")
+		}
+		ids = append(ids, other.ID)
+		for _, email := range other.Emails {
+			if !utils.Contains(target.Emails, email) {
+				target.Emails = append(target.Emails, email)
+			}
+		}
+		for _, phone := range other.Phones {
+			if !utils.Contains(target.Phones, phone) {
+				target.Phones = append(target.Phones, phone)
+			}
+		}
+		if target.Vat == "" {
+			target.Vat = other.Vat
+		}
+		if target.Address == (PostalAddress{}) {
+			target.Address = other.Address
+		}
+		if other.Notes != "" {
+			target.Notes = strings.TrimSpace(target.Notes + "\n" + other.Notes)
+		}
+	}
+	target.Updated = time.Now().Unix()
+	if _, err := service.Save(target); err != nil {
+		return nil, err
+	}
+
+	refs, err := references.Replace("contact", ids, target.ID)
+	if err != nil {
+		zap.S().Error("Error replacing merged contacts references: ", err)
+		return refs, err
+	}
+
+	db := database.DB()
+	collection := db.D.Collection("contact")
+	if _, err := collection.DeleteMany(context.TODO(), bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+		zap.S().Error("Error deleting merged contacts: ", err)
+		return refs, err
+	}
+	zap.S().Info(fmt.Sprintf("Merged %d contacts into contact %s", len(ids), target.Name))
+
+	return refs, nil
+}
+
+// Saves the contact model to database
+// Returns boolean result and error
+func (service *ContactService) Save(contact *Contact) (bool, error) {
+	db := database.DB()
+	collection := db.D.Collection("contact")
+
+	if contact.ID.IsZero() {
+		// insert
+		res, err := collection.InsertOne(context.TODO(), contact)
+
+		if err != nil {
+			zap.S().Error("Error inserting contact: ", err)
+			return false, err
+		} else {
+			zap.S().Info(fmt.Sprintf("Contact %s inserted succesfully", contact.Name))
+			// update contact ID
+			contact.ID = res.InsertedID.(primitive.ObjectID)
+			return true, nil
+		}
+	} else {
+		// update
+		filter := bson.M{"_id": contact.ID}
+		_, err := collection.ReplaceOne(context.TODO(), filter, contact)
+
+		if err != nil {
+			zap.S().Error("Error updating contact: ", err)
+			return false, err
+		} else {
+			zap.S().Info(fmt.Sprintf("Contact %s updated succesfully", contact.Name))
+			return true, nil
+		}
+	}
+}
+
+// Deletes the contact model from databse
+// Returns boolean result and error
+func (service *ContactService) Delete(contact *Contact) (bool, error) {
+	db := database.DB()
+	collection := db.D.Collection("contact")
+
+	_, err := collection.DeleteOne(context.TODO(), bson.M{"_id": contact.ID})
+
+	if err != nil {
+		zap.S().Error("Error deleting contact: ", err)
+		return false, err
+	} else {
+		zap.S().Info(fmt.Sprintf("Contact %s deleted succesfully", contact.Name))
+		return true, nil
+	}
+}
+
+// normalizeName returns the name lowercased, keeping only letters and digits, used to spot duplicates
+func normalizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+// normalizeVat returns the VAT number uppercased and without spaces, used to spot duplicates
+func normalizeVat(vat string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(vat), ""))
+}
@@ -0,0 +1,137 @@
+package contacts
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+type PostalAddressValidatorData struct {
+	Street   string `json:"street"`
+	City     string `json:"city"`
+	Zip      string `json:"zip"`
+	Province string `json:"province"`
+	Country  string `json:"country" binding:"omitempty,iso3166_1_alpha2"`
+}
+type ContactValidatorData struct {
+	Kind    string                     `json:"kind" binding:"required,oneof=company person"`
+	Name    string                     `json:"name" binding:"required,max=255"`
+	Emails  []string                   `json:"emails" binding:"dive,email"`
+	Phones  []string                   `json:"phones" binding:"dive,min=3,max=50"`
+	Vat     string                     `json:"vat" binding:"max=50"`
+	Address PostalAddressValidatorData `json:"address"`
+	Notes   string                     `json:"notes"`
+}
+type ContactValidator struct {
+	ContactData ContactValidatorData `json:"contact"`
+	contact     Contact              `json:"-"`
+}
+
+func (self *ContactValidator) fillModelData() {
+	self.contact.Kind = self.ContactData.Kind
+	self.contact.Name = strings.TrimSpace(self.ContactData.Name)
+	self.contact.Emails = make([]string, 0)
+	for _, email := range self.ContactData.Emails {
+		self.contact.Emails = append(self.contact.Emails, strings.ToLower(email))
+	}
+	self.contact.Phones = make([]string, 0)
+	for _, phone := range self.ContactData.Phones {
+		self.contact.Phones = append(self.contact.Phones, strings.TrimSpace(phone))
+	}
+	self.contact.Vat = normalizeVat(self.ContactData.Vat)
+	self.contact.Address = PostalAddress{
+		Street:   self.ContactData.Address.Street,
+		City:     self.ContactData.Address.City,
+		Zip:      self.ContactData.Address.Zip,
+		Province: self.ContactData.Address.Province,
+		Country:  strings.ToUpper(self.ContactData.Address.Country),
+	}
+	self.contact.Notes = self.ContactData.Notes
+}
+
+func (self *ContactValidator) Bind(c *gin.Context) error {
+	err := c.ShouldBind(&self.ContactData)
+	if err != nil {
+		zap.S().Debug("Contact Validation Error: ", err)
+		return err
+	}
+	self.fillModelData()
+	self.contact.Created = time.Now().Unix()
+	self.contact.Updated = time.Now().Unix()
+
+	return nil
+}
+
+func (self *ContactValidator) BindUpdate(contact *Contact, c *gin.Context) error {
+	err := c.ShouldBind(&self.ContactData)
+	if err != nil {
+		zap.S().Debug("Contact Validation Error: ", err)
+		return err
+	}
+	self.contact.ID = contact.ID
+	self.fillModelData()
+	self.contact.Created = contact.Created
+	self.contact.Updated = time.Now().Unix()
+
+	return nil
+}
+
+// You can put the default value of a Validator here
+func NewContactValidator() ContactValidator {
+	contactValidator := ContactValidator{}
+	return contactValidator
+}
+
+type MergeValidatorData struct {
+	Ids []string `json:"ids" binding:"required,min=1,dive,len=24,hexadecimal"`
+}
+type MergeValidator struct {
+	MergeData MergeValidatorData `json:"merge"`
+	contacts  []Contact          `json:"-"`
+}
+
+// Bind reads the contacts to be merged into the target one, checking that they exist
+func (self *MergeValidator) Bind(target *Contact, c *gin.Context) error {
+	err := c.ShouldBind(&self.MergeData)
+	if err != nil {
+		zap.S().Debug("Contact Merge Validation Error: ", err)
+		return err
+	}
+	// ids are compared parsed, since hexadecimal ids are case insensitive
+	ids := []primitive.ObjectID{}
+	for _, hex := range self.MergeData.Ids {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			return errors.New("Invalid contact id " + hex)
+		}
+		if id == target.ID {
+			return errors.New("A contact cannot be merged into itself")
+		}
+		found := false
+		for _, other := range ids {
+			found = found || other == id
+		}
+		if !found {
+			ids = append(ids, id)
+		}
+	}
+	contactService := new(ContactService)
+	self.contacts = make([]Contact, 0)
+	for _, id := range ids {
+		contact, err := contactService.GetById(id.Hex())
+		if err != nil {
+			return errors.New("Contact " + id.Hex() + " not found")
+		}
+		self.contacts = append(self.contacts, *contact)
+	}
+
+	return nil
+}
+
+func NewMergeValidator() MergeValidator {
+	mergeValidator := MergeValidator{}
+	return mergeValidator
+}
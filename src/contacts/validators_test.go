@@ -0,0 +1,38 @@
+package contacts
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMergeIntoItselfMixedCase(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	target := Contact{ID: primitive.NewObjectID(), Name: "Acme"}
+	other := primitive.NewObjectID()
+
+	for _, id := range []string{target.ID.Hex(), strings.ToUpper(target.ID.Hex())} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		body := `{"ids": ["` + other.Hex() + `", "` + id + `"]}`
+		c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		validator := NewMergeValidator()
+		err := validator.Bind(&target, c)
+		if err == nil || !strings.Contains(err.Error(), "itself") {
+			t.Fatalf("expected the merge of %s into itself to be rejected, got %v", id, err)
+		}
+	}
+}
+
+func TestMergeServiceRejectsTarget(t *testing.T) {
+	target := Contact{ID: primitive.NewObjectID(), Name: "Acme"}
+	others := []Contact{{ID: target.ID, Name: "Acme"}}
+
+	if _, err := new(ContactService).Merge(&target, &others); err == nil {
+		t.Fatal("expected the merge of the target into itself to be rejected")
+	}
+}
@@ -0,0 +1,249 @@
+package contacts
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"systems-management-api/auth"
+	"systems-management-api/core/utils"
+)
+
+// Returns all contacts, admin or superadmin roles required
+// @Summary Contacts list
+// @Description Retrieves all contacts
+// @Security BearerAuth
+// @Tags contacts
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} ContactData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /contact/ [get]
+func contactListView(c *gin.Context) {
+	contactService := new(ContactService)
+	contacts, err := contactService.all()
+
+	if err != nil {
+		zap.S().Error("Error while getting all contacts, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{
+			Message: "Cannot fetch contacts",
+		})
+	} else {
+		serializer := NewContactSerializer()
+		c.JSON(http.StatusOK, serializer.SerializeMany(contacts))
+	}
+}
+
+var ContactListView = auth.RoleRequired([]string{"admin", "superadmin"}, contactListView)
+
+// Returns contact given its id
+// @Summary Contact detail
+// @Description Retrieves one contact given its id
+// @Security BearerAuth
+// @Tags contacts
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Contact ID"
+// @Success 200 {object} ContactData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /contact/{id} [get]
+func contactDetailView(c *gin.Context) {
+	contactService := new(ContactService)
+	contact, err := contactService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting contact, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Contact not found"})
+	} else {
+		serializer := NewContactSerializer()
+		c.JSON(http.StatusOK, serializer.Serialize(contact))
+	}
+}
+
+var ContactDetailView = auth.RoleRequired([]string{"admin", "superadmin"}, contactDetailView)
+
+// Creates a contact
+// @Summary Create contact
+// @Description Creates a contact
+// @Security BearerAuth
+// @Tags contacts
+// @Accept  json
+// @Produce  json
+// @Param contact body ContactValidatorData true "Contact data"
+// @Success 201 {object} ContactData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /contact/ [post]
+func createContactView(c *gin.Context) {
+	contactValidator := NewContactValidator()
+	if err := contactValidator.Bind(c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if _, err := contactValidator.contact.Save(); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Cannot insert contact: %v", err)})
+		return
+	}
+	serializer := NewContactSerializer()
+	c.JSON(http.StatusCreated, serializer.Serialize(&contactValidator.contact))
+}
+
+var CreateContactView = auth.RoleRequired([]string{"admin", "superadmin"}, createContactView)
+
+// Updates a contact
+// @Summary Update contact
+// @Description Updates a contact
+// @Security BearerAuth
+// @Tags contacts
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Contact ID"
+// @Param contact body ContactValidatorData true "Contact data"
+// @Success 200 {object} ContactData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /contact/{id} [put]
+func updateContactView(c *gin.Context) {
+	contactService := new(ContactService)
+	contact, err := contactService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting contact, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Contact not found"})
+		return
+	}
+
+	contactValidator := NewContactValidator()
+	if err := contactValidator.BindUpdate(contact, c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if _, err := contactValidator.contact.Save(); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Cannot update contact: %v", err)})
+		return
+	}
+	serializer := NewContactSerializer()
+	c.JSON(http.StatusOK, serializer.Serialize(&contactValidator.contact))
+}
+
+var UpdateContactView = auth.RoleRequired([]string{"admin", "superadmin"}, updateContactView)
+
+// Deletes a contact, contacts still referenced (i.e. by domains) cannot be deleted
+// @Summary Delete contact
+// @Description Deletes a contact which is not referenced by other resources
+// @Security BearerAuth
+// @Tags contacts
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Contact ID"
+// @Success 204
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /contact/{id} [delete]
+func deleteContactView(c *gin.Context) {
+	contactService := new(ContactService)
+	contact, err := contactService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting contact, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Contact not found"})
+		return
+	}
+
+	refs, err := contactService.References(contact)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+	if len(refs) > 0 {
+		c.JSON(http.StatusConflict, utils.ErrorResponse{
+			Message: fmt.Sprintf("Contact %s is still referenced: %v", contact.Name, refs),
+		})
+		return
+	}
+
+	if _, err := contact.Delete(); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, gin.H{})
+}
+
+var DeleteContactView = auth.RoleRequired([]string{"admin", "superadmin"}, deleteContactView)
+
+// Returns the groups of contacts which are likely duplicates, admin or superadmin roles required
+// @Summary Duplicate contacts
+// @Description Retrieves the groups of contacts sharing the same normalized name, email or VAT number
+// @Security BearerAuth
+// @Tags contacts
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} DuplicatesData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /report/contact/duplicates [get]
+func duplicateContactsView(c *gin.Context) {
+	contactService := new(ContactService)
+	duplicates, err := contactService.Duplicates()
+
+	if err != nil {
+		zap.S().Error("Error while searching duplicate contacts, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{
+			Message: "Cannot search duplicate contacts",
+		})
+	} else {
+		serializer := NewContactSerializer()
+		c.JSON(http.StatusOK, serializer.SerializeDuplicates(duplicates))
+	}
+}
+
+var DuplicateContactsView = auth.RoleRequired([]string{"admin", "superadmin"}, duplicateContactsView)
+
+// Merges duplicate contacts into a contact
+// @Summary Merge contacts
+// @Description Merges the given contacts into the contact: emails and phones are joined, references (i.e. domains) are moved and the merged contacts are deleted
+// @Security BearerAuth
+// @Tags contacts
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Contact ID"
+// @Param merge body MergeValidatorData true "Contacts to be merged"
+// @Success 200 {object} MergeResultData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /contact/{id}/merge [post]
+func mergeContactsView(c *gin.Context) {
+	contactService := new(ContactService)
+	contact, err := contactService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting contact, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Contact not found"})
+		return
+	}
+
+	mergeValidator := NewMergeValidator()
+	if err := mergeValidator.Bind(contact, c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	refs, err := contactService.Merge(contact, &mergeValidator.contacts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot merge contacts: %v", err)})
+		return
+	}
+	serializer := NewContactSerializer()
+	c.JSON(http.StatusOK, serializer.SerializeMergeResult(contact, &mergeValidator.contacts, refs))
+}
+
+var MergeContactsView = auth.RoleRequired([]string{"admin", "superadmin"}, mergeContactsView)
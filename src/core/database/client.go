@@ -69,3 +69,15 @@ func (self *DBDriver) GetById(collectionName string, id string, item interface{}
 
 	return err
 }
+
+// Creates the given indexes on the collection, existing indexes with the same specification are left untouched
+func (self *DBDriver) EnsureIndexes(collectionName string, indexes []mongo.IndexModel) error {
+	collection := self.D.Collection(collectionName)
+	names, err := collection.Indexes().CreateMany(context.TODO(), indexes)
+	if err != nil {
+		return err
+	}
+	zap.S().Debugw("Indexes ensured", "collection", collectionName, "indexes", names)
+
+	return nil
+}
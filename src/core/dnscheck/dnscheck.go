@@ -0,0 +1,141 @@
+package dnscheck
+
+import (
+	"context"
+	"github.com/spf13/viper"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MxRecord a mail exchanger record
+type MxRecord struct {
+	Host string
+	Pref uint16
+}
+
+// Records the DNS records of a domain name
+type Records struct {
+	A    []string
+	AAAA []string
+	Mx   []MxRecord
+	Ns   []string
+}
+
+// Checker resolves the DNS records of domain names
+type Checker struct {
+	Resolver *net.Resolver
+	Timeout  time.Duration
+}
+
+// NewChecker returns a checker configured from settings
+// If dns.resolver is set ("host:port") queries are sent to that server, otherwise the system resolver is used
+func NewChecker() *Checker {
+	timeout := time.Duration(viper.GetInt("dns.timeoutSeconds")) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return NewCheckerWithResolver(viper.GetString("dns.resolver"), timeout)
+}
+
+// NewCheckerWithResolver returns a checker which queries the given DNS server ("host:port"), or the system resolver if empty
+func NewCheckerWithResolver(address string, timeout time.Duration) *Checker {
+	resolver := net.DefaultResolver
+	if address != "" {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			address = net.JoinHostPort(address, "53")
+		}
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				dialer := net.Dialer{Timeout: timeout}
+				return dialer.DialContext(ctx, network, address)
+			},
+		}
+	}
+	return &Checker{Resolver: resolver, Timeout: timeout}
+}
+
+// isNotFound returns true if the error means that no record of the requested type exists
+func isNotFound(err error) bool {
+	if dnsErr, ok := err.(*net.DNSError); ok {
+		return dnsErr.IsNotFound
+	}
+	return false
+}
+
+// lookupContext returns the context of a single lookup, each lookup has its own deadline
+func (self *Checker) lookupContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), self.Timeout)
+}
+
+// Lookup resolves the A, AAAA, MX and NS records of the given domain name
+// Missing records are not errors, they result in empty lists
+func (self *Checker) Lookup(name string) (*Records, error) {
+	records := &Records{A: []string{}, AAAA: []string{}, Mx: []MxRecord{}, Ns: []string{}}
+
+	for _, network := range []string{"ip4", "ip6"} {
+		ctx, cancel := self.lookupContext()
+		ips, err := self.Resolver.LookupIP(ctx, network, name)
+		cancel()
+		if err != nil && !isNotFound(err) {
+			return nil, err
+		}
+		for _, ip := range ips {
+			if network == "ip4" {
+				records.A = append(records.A, ip.String())
+			} else {
+				records.AAAA = append(records.AAAA, ip.String())
+			}
+		}
+	}
+
+	ctx, cancel := self.lookupContext()
+	mxs, err := self.Resolver.LookupMX(ctx, name)
+	cancel()
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+	for _, mx := range mxs {
+		records.Mx = append(records.Mx, MxRecord{Host: normalizeHost(mx.Host), Pref: mx.Pref})
+	}
+
+	ctx, cancel = self.lookupContext()
+	nss, err := self.Resolver.LookupNS(ctx, name)
+	cancel()
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+	for _, ns := range nss {
+		records.Ns = append(records.Ns, normalizeHost(ns.Host))
+	}
+
+	sort.Strings(records.A)
+	sort.Strings(records.AAAA)
+	sort.Strings(records.Ns)
+	return records, nil
+}
+
+// LookupIPs resolves the A and AAAA records of a host name
+func (self *Checker) LookupIPs(host string) ([]string, error) {
+	ctx, cancel := self.lookupContext()
+	defer cancel()
+	addrs, err := self.Resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		if isNotFound(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	ips := []string{}
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP.String())
+	}
+	return ips, nil
+}
+
+// normalizeHost returns the host name lowercased and without the trailing dot
+func normalizeHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
@@ -0,0 +1,154 @@
+package dnscheck
+
+import (
+	"golang.org/x/net/dns/dnsmessage"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer a local DNS server answering from static records, each answer of the delayed types is sent after delay
+type fakeServer struct {
+	conn    net.PacketConn
+	a       map[string][]string
+	aaaa    map[string][]string
+	mx      map[string][]MxRecord
+	ns      map[string][]string
+	delay   time.Duration
+	delayed []dnsmessage.Type
+}
+
+// start listens on a local UDP port, the server is stopped at the end of the test
+func (self *fakeServer) start(t *testing.T) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	self.conn = conn
+	t.Cleanup(func() { conn.Close() })
+	go self.serve()
+	return conn.LocalAddr().String()
+}
+
+func (self *fakeServer) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := self.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		var request dnsmessage.Message
+		if err := request.Unpack(buf[:n]); err != nil || len(request.Questions) == 0 {
+			continue
+		}
+		go self.answer(request, addr)
+	}
+}
+
+func (self *fakeServer) answer(request dnsmessage.Message, addr net.Addr) {
+	question := request.Questions[0]
+	name := strings.TrimSuffix(question.Name.String(), ".")
+	header := dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 60}
+	response := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: request.ID, Response: true, Authoritative: true},
+		Questions: request.Questions,
+	}
+	switch question.Type {
+	case dnsmessage.TypeA:
+		for _, ip := range self.a[name] {
+			var a dnsmessage.AResource
+			copy(a.A[:], net.ParseIP(ip).To4())
+			response.Answers = append(response.Answers, dnsmessage.Resource{Header: header, Body: &a})
+		}
+	case dnsmessage.TypeAAAA:
+		for _, ip := range self.aaaa[name] {
+			var aaaa dnsmessage.AAAAResource
+			copy(aaaa.AAAA[:], net.ParseIP(ip).To16())
+			response.Answers = append(response.Answers, dnsmessage.Resource{Header: header, Body: &aaaa})
+		}
+	case dnsmessage.TypeMX:
+		for _, mx := range self.mx[name] {
+			response.Answers = append(response.Answers, dnsmessage.Resource{
+				Header: header,
+				Body:   &dnsmessage.MXResource{Pref: mx.Pref, MX: dnsmessage.MustNewName(mx.Host)},
+			})
+		}
+	case dnsmessage.TypeNS:
+		for _, ns := range self.ns[name] {
+			response.Answers = append(response.Answers, dnsmessage.Resource{Header: header, Body: &dnsmessage.NSResource{NS: dnsmessage.MustNewName(ns)}})
+		}
+	}
+	// the names without A records don't exist
+	if _, ok := self.a[name]; !ok {
+		response.RCode = dnsmessage.RCodeNameError
+	}
+	for _, t := range self.delayed {
+		if t == question.Type {
+			time.Sleep(self.delay)
+		}
+	}
+	packed, err := response.Pack()
+	if err == nil {
+		self.conn.WriteTo(packed, addr)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	server := &fakeServer{
+		a:    map[string][]string{"example.test": {"192.0.2.2", "192.0.2.1"}},
+		aaaa: map[string][]string{"example.test": {"2001:db8::1"}},
+		mx:   map[string][]MxRecord{"example.test": {{Host: "Mail.Example.test.", Pref: 10}}},
+		ns:   map[string][]string{"example.test": {"ns2.example.test.", "NS1.example.test."}},
+	}
+	checker := NewCheckerWithResolver(server.start(t), 2*time.Second)
+
+	records, err := checker.Lookup("example.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(records.A, ",") != "192.0.2.1,192.0.2.2" || strings.Join(records.AAAA, ",") != "2001:db8::1" {
+		t.Fatalf("unexpected addresses %v %v", records.A, records.AAAA)
+	}
+	if len(records.Mx) != 1 || records.Mx[0].Host != "mail.example.test" || records.Mx[0].Pref != 10 {
+		t.Fatalf("unexpected MX records %v", records.Mx)
+	}
+	if strings.Join(records.Ns, ",") != "ns1.example.test,ns2.example.test" {
+		t.Fatalf("unexpected NS records %v", records.Ns)
+	}
+}
+
+func TestLookupNotFound(t *testing.T) {
+	server := &fakeServer{}
+	checker := NewCheckerWithResolver(server.start(t), 2*time.Second)
+
+	records, err := checker.Lookup("missing.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records.A) != 0 || len(records.AAAA) != 0 || len(records.Mx) != 0 || len(records.Ns) != 0 {
+		t.Fatalf("expected no records, got %+v", records)
+	}
+	ips, err := checker.LookupIPs("missing.test")
+	if err != nil || len(ips) != 0 {
+		t.Fatalf("expected no ips, got %v %v", ips, err)
+	}
+}
+
+func TestLookupTimeoutPerQuery(t *testing.T) {
+	// each of the address queries takes most of the timeout, but none exceeds it
+	server := &fakeServer{
+		a:       map[string][]string{"slow.test": {"192.0.2.1"}},
+		delay:   600 * time.Millisecond,
+		delayed: []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA},
+	}
+	checker := NewCheckerWithResolver(server.start(t), time.Second)
+
+	records, err := checker.Lookup("slow.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(records.A, ",") != "192.0.2.1" {
+		t.Fatalf("unexpected addresses %v", records.A)
+	}
+}
@@ -0,0 +1,190 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats
+const (
+	CSV    = "csv"
+	XLSX   = "xlsx"
+	NDJSON = "ndjson"
+)
+
+// Formats the supported export formats
+var Formats = []string{CSV, XLSX, NDJSON}
+
+// Column an exportable column, privileged columns hold sensitive data (i.e. credentials)
+// and are exported only when explicitly requested by a privileged user
+type Column struct {
+	Name       string
+	Privileged bool
+}
+
+// Writer writes the exported rows in a given format
+// Values are strings, integers, floats, booleans or time.Time
+type Writer interface {
+	Header(columns []string) error
+	Row(values []interface{}) error
+	Close() error
+}
+
+// NewWriter returns a writer of the given format writing on w
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case CSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case XLSX:
+		return newXlsxWriter(w)
+	case NDJSON:
+		return &ndjsonWriter{w: w}, nil
+	}
+	return nil, fmt.Errorf("Unsupported format %s, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+// ContentType returns the mime type of the given format
+func ContentType(format string) string {
+	switch format {
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case NDJSON:
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// SelectColumns returns the columns to export given the comma separated list of requested columns
+// All the columns are exported by default, privileged columns only if privileged is set
+func SelectColumns(available []Column, requested string, privileged bool) ([]string, error) {
+	columns := []string{}
+	if strings.TrimSpace(requested) == "" {
+		for _, column := range available {
+			if !column.Privileged || privileged {
+				columns = append(columns, column.Name)
+			}
+		}
+		return columns, nil
+	}
+
+	for _, name := range strings.Split(requested, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, column := range available {
+			if column.Name != name {
+				continue
+			}
+			if column.Privileged && !privileged {
+				return nil, fmt.Errorf("Column %s can be exported only with the privileged flag", name)
+			}
+			found = true
+		}
+		if !found {
+			return nil, fmt.Errorf("Unknown column %s", name)
+		}
+		columns = append(columns, name)
+	}
+	return columns, nil
+}
+
+// formatValue returns the textual representation of a value, dates are formatted as RFC 3339
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// formulaPrefixes the first characters which make spreadsheets evaluate a cell as a formula
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeFormula prefixes the text with a quote if spreadsheets would evaluate it as a formula
+func escapeFormula(text string) string {
+	if text != "" && strings.ContainsRune(formulaPrefixes, rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (self *csvWriter) Header(columns []string) error {
+	record := make([]string, len(columns))
+	for i, column := range columns {
+		record[i] = escapeFormula(column)
+	}
+	return self.w.Write(record)
+}
+
+func (self *csvWriter) Row(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = formatValue(value)
+		// user entered text is written as is, only numbers may start with a sign
+		if _, ok := value.(string); ok {
+			record[i] = escapeFormula(record[i])
+		}
+	}
+	return self.w.Write(record)
+}
+
+func (self *csvWriter) Close() error {
+	self.w.Flush()
+	return self.w.Error()
+}
+
+// ndjsonWriter writes a json object per row, keeping the columns order
+type ndjsonWriter struct {
+	w       io.Writer
+	columns []string
+}
+
+func (self *ndjsonWriter) Header(columns []string) error {
+	self.columns = columns
+	return nil
+}
+
+func (self *ndjsonWriter) Row(values []interface{}) error {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(self.columns[i])
+		if t, ok := value.(time.Time); ok {
+			value = formatValue(t)
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(encoded)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(self.w, b.String())
+	return err
+}
+
+func (self *ndjsonWriter) Close() error {
+	return nil
+}
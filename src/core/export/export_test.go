@@ -0,0 +1,62 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestCSVFormulaInjection(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(CSV, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Header([]string{"name", "tags", "notes", "cost"})
+	w.Row([]interface{}{"=HYPERLINK(\"http://evil\")", "+1", "@SUM(A1)", -12.5})
+	w.Row([]interface{}{"-2+3", "\tcmd", "\rcmd", int64(-3)})
+	w.Row([]interface{}{"example.com", "a=b", "", nil})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "name,tags,notes,cost\n" +
+		"\"'=HYPERLINK(\"\"http://evil\"\")\",'+1,'@SUM(A1),-12.5\n" +
+		"'-2+3,'\tcmd,\"'\rcmd\",-3\n" +
+		"example.com,a=b,,\n"
+	if buf.String() != expected {
+		t.Fatalf("expected\n%q\ngot\n%q", expected, buf.String())
+	}
+}
+
+func TestXLSXFormulaInjection(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(XLSX, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Header([]string{"name"})
+	w.Row([]interface{}{"=1+1"})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range archive.File {
+		if file.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		f, _ := file.Open()
+		sheet, _ := ioutil.ReadAll(f)
+		if !strings.Contains(string(sheet), "<t xml:space=\"preserve\">&#39;=1+1</t>") {
+			t.Fatalf("expected the formula to be escaped, got %s", sheet)
+		}
+		return
+	}
+	t.Fatal("worksheet not found")
+}
@@ -0,0 +1,71 @@
+package healthcheck
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxBodySize the maximum number of bytes of the response body searched for the expected content
+const maxBodySize = 1 << 20
+
+// Probe a HTTP(S) health check definition
+type Probe struct {
+	Url             string
+	ExpectedStatus  int // 0 means any 2xx or 3xx status
+	ExpectedContent string
+	Timeout         time.Duration
+}
+
+// Result the outcome of a probe
+type Result struct {
+	Up        bool
+	Status    int
+	LatencyMs int64
+	Error     string
+}
+
+// Run performs the HTTP request and checks status and content of the response
+// Redirects are not followed, so that a 3xx status can be expected
+func Run(probe Probe) Result {
+	client := &http.Client{
+		Timeout: probe.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	start := time.Now()
+	res, err := client.Get(probe.Url)
+	if err != nil {
+		return Result{Up: false, LatencyMs: time.Since(start).Milliseconds(), Error: err.Error()}
+	}
+	defer res.Body.Close()
+	var body []byte
+	if probe.ExpectedContent != "" {
+		body, err = ioutil.ReadAll(io.LimitReader(res.Body, maxBodySize))
+	}
+	result := Result{Status: res.StatusCode, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if probe.ExpectedStatus != 0 && res.StatusCode != probe.ExpectedStatus {
+		result.Error = fmt.Sprintf("Unexpected status %d, expected %d", res.StatusCode, probe.ExpectedStatus)
+		return result
+	}
+	if probe.ExpectedStatus == 0 && res.StatusCode >= 400 {
+		result.Error = fmt.Sprintf("Unexpected status %d", res.StatusCode)
+		return result
+	}
+	if probe.ExpectedContent != "" && !strings.Contains(string(body), probe.ExpectedContent) {
+		result.Error = "Expected content not found"
+		return result
+	}
+	result.Up = true
+	return result
+}
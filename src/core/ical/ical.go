@@ -0,0 +1,100 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ContentType the mime type of the iCalendar documents
+const ContentType = "text/calendar; charset=utf-8"
+
+// maxLineLength the maximum length of a content line in octets, longer lines are folded (RFC 5545, 3.1)
+const maxLineLength = 75
+
+// Event an all day calendar event
+type Event struct {
+	Uid         string
+	Date        time.Time
+	Summary     string
+	Description string
+	Categories  []string
+	Url         string
+}
+
+// Calendar an iCalendar document, written by Write
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// Write writes the calendar as an iCalendar (RFC 5545) document on w
+func (self *Calendar) Write(w io.Writer) error {
+	buffer := bufio.NewWriter(w)
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Otto//Systems Management API//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:" + escape(self.Name),
+	}
+	for _, event := range self.Events {
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+event.Uid,
+			"DTSTAMP:"+stamp,
+			"DTSTART;VALUE=DATE:"+event.Date.Format("20060102"),
+			"DTEND;VALUE=DATE:"+event.Date.AddDate(0, 0, 1).Format("20060102"),
+			"SUMMARY:"+escape(event.Summary),
+			"TRANSP:TRANSPARENT",
+		)
+		if event.Description != "" {
+			lines = append(lines, "DESCRIPTION:"+escape(event.Description))
+		}
+		if len(event.Categories) > 0 {
+			categories := make([]string, len(event.Categories))
+			for i, category := range event.Categories {
+				categories[i] = escape(category)
+			}
+			lines = append(lines, "CATEGORIES:"+strings.Join(categories, ","))
+		}
+		if event.Url != "" {
+			lines = append(lines, "URL:"+event.Url)
+		}
+		lines = append(lines, "END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		if _, err := fmt.Fprint(buffer, fold(line), "\r\n"); err != nil {
+			return err
+		}
+	}
+	return buffer.Flush()
+}
+
+// escape escapes the special characters of a text value
+func escape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// fold splits the lines longer than 75 octets, continuation lines start with a space
+// Lines are split between runes so that multi byte characters are not broken
+func fold(line string) string {
+	var res strings.Builder
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > maxLineLength {
+			res.WriteString("\r\n ")
+			length = 1
+		}
+		res.WriteRune(r)
+		length += size
+	}
+	return res.String()
+}
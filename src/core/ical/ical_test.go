@@ -0,0 +1,84 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscape(t *testing.T) {
+	tests := map[string]string{
+		"plain":            "plain",
+		`back\slash`:       `back\\slash`,
+		"semi;colon":       `semi\;colon`,
+		"com,ma":           `com\,ma`,
+		"two\nlines":       `two\nlines`,
+		"windows\r\nlines": `windows\nlines`,
+		`all\;,` + "\n":    `all\\\;\,\n`,
+	}
+	for text, expected := range tests {
+		if res := escape(text); res != expected {
+			t.Fatalf("escape(%q): expected %q, got %q", text, expected, res)
+		}
+	}
+}
+
+func TestFold(t *testing.T) {
+	for _, line := range []string{
+		"SUMMARY:" + strings.Repeat("a", 200),
+		"SUMMARY:" + strings.Repeat("é", 100),
+		"SUMMARY:" + strings.Repeat("日本", 50),
+		"SUMMARY:" + strings.Repeat("a", 66),
+	} {
+		folded := fold(line)
+		parts := strings.Split(folded, "\r\n")
+		for i, part := range parts {
+			if len(part) > maxLineLength {
+				t.Fatalf("expected lines of at most %d octets, got %d: %q", maxLineLength, len(part), part)
+			}
+			if !utf8.ValidString(part) {
+				t.Fatalf("expected the lines not to break multi byte characters, got %q", part)
+			}
+			if i > 0 && !strings.HasPrefix(part, " ") {
+				t.Fatalf("expected the continuation lines to start with a space, got %q", part)
+			}
+		}
+		if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != line {
+			t.Fatalf("expected the unfolded line to be the original one, got %q", unfolded)
+		}
+	}
+	if line := "SUMMARY:" + strings.Repeat("a", 67); fold(line) != line {
+		t.Fatal("expected a line of 75 octets not to be folded")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	calendar := Calendar{Name: "Domains", Events: []Event{{
+		Uid:         "1@test",
+		Date:        time.Date(2030, 1, 31, 0, 0, 0, 0, time.UTC),
+		Summary:     "Domain example.com expires",
+		Description: "Registrar: Example, Inc.\nAuto renew: true",
+		Categories:  []string{"expiry", "a,b"},
+	}}}
+	var b bytes.Buffer
+	if err := calendar.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	document := b.String()
+	for _, line := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"DTSTART;VALUE=DATE:20300131\r\n",
+		"DTEND;VALUE=DATE:20300201\r\n",
+		`DESCRIPTION:Registrar: Example\, Inc.\nAuto renew: true` + "\r\n",
+		`CATEGORIES:expiry,a\,b` + "\r\n",
+	} {
+		if !strings.Contains(document, line) {
+			t.Fatalf("expected the document to contain %q, got %q", line, document)
+		}
+	}
+	if !strings.HasSuffix(document, "END:VCALENDAR\r\n") {
+		t.Fatalf("expected the document to end the calendar, got %q", document)
+	}
+}
@@ -0,0 +1,97 @@
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// logNotifier writes notifications to the application log
+type logNotifier struct{}
+
+func (self *logNotifier) Notify(notification Notification) error {
+	zap.S().Infow("Notification", "event", notification.Event, "subject", notification.Subject, "body", notification.Body)
+	return nil
+}
+
+// webhookNotifier posts notifications as json to an url
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func (self *webhookNotifier) Notify(notification Notification) error {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	res, err := self.client.Post(self.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("Webhook answered with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// emailNotifier sends notifications by email through a smtp server
+type emailNotifier struct {
+	address string
+	auth    smtp.Auth
+	from    string
+	to      []string
+}
+
+func (self *emailNotifier) Notify(notification Notification) error {
+	message := fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		self.from,
+		strings.Join(self.to, ", "),
+		notification.Subject,
+		notification.Body,
+	)
+	return smtp.SendMail(self.address, self.auth, self.from, self.to, []byte(message))
+}
+
+func init() {
+	Register("log", func() (Notifier, error) {
+		return &logNotifier{}, nil
+	})
+	Register("webhook", func() (Notifier, error) {
+		url := viper.GetString("notifications.webhook.url")
+		if url == "" {
+			return nil, errors.New("Missing notifications.webhook.url setting")
+		}
+		return &webhookNotifier{url: url, client: &http.Client{Timeout: 10 * time.Second}}, nil
+	})
+	Register("email", func() (Notifier, error) {
+		host := viper.GetString("notifications.email.host")
+		to := viper.GetStringSlice("notifications.email.to")
+		if host == "" || len(to) == 0 {
+			return nil, errors.New("Missing notifications.email.host or notifications.email.to setting")
+		}
+		port := viper.GetInt("notifications.email.port")
+		if port == 0 {
+			port = 25
+		}
+		var auth smtp.Auth
+		if username := viper.GetString("notifications.email.username"); username != "" {
+			auth = smtp.PlainAuth("", username, viper.GetString("notifications.email.password"), host)
+		}
+		return &emailNotifier{
+			address: fmt.Sprintf("%s:%d", host, port),
+			auth:    auth,
+			from:    viper.GetString("notifications.email.from"),
+			to:      to,
+		}, nil
+	})
+}
@@ -0,0 +1,85 @@
+package notifications
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"sync"
+)
+
+// Notification a message sent to the team, i.e. a domain expiry reminder
+type Notification struct {
+	Event   string                 `json:"event"`
+	Subject string                 `json:"subject"`
+	Body    string                 `json:"body"`
+	Data    map[string]interface{} `json:"data"`
+}
+
+// Notifier common interface for all the notification backends
+type Notifier interface {
+	Notify(notification Notification) error
+}
+
+// Factory creates a notifier reading its configuration from settings
+type Factory func() (Notifier, error)
+
+var mutex sync.RWMutex
+var factories = map[string]Factory{}
+
+// Register makes a notification backend available with the given name
+func Register(name string, factory Factory) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	factories[name] = factory
+}
+
+// multiNotifier sends notifications through many backends
+type multiNotifier struct {
+	notifiers map[string]Notifier
+}
+
+// Notify sends the notification through every backend, returning the last error if any fails
+func (self *multiNotifier) Notify(notification Notification) error {
+	var lastErr error
+	for name, notifier := range self.notifiers {
+		if err := notifier.Notify(notification); err != nil {
+			zap.S().Errorw("Error sending notification", "backend", name, "event", notification.Event, "error", err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// New returns a notifier which sends notifications through the backends listed in the notifications.backends setting
+// The log backend is used if no backend is configured
+func New() (Notifier, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	names := viper.GetStringSlice("notifications.backends")
+	if len(names) == 0 {
+		names = []string{"log"}
+	}
+	notifier := &multiNotifier{notifiers: map[string]Notifier{}}
+	for _, name := range names {
+		factory, ok := factories[name]
+		if !ok {
+			return nil, fmt.Errorf("Unknown notification backend %s", name)
+		}
+		backend, err := factory()
+		if err != nil {
+			return nil, fmt.Errorf("Cannot create notification backend %s: %v", name, err)
+		}
+		notifier.notifiers[name] = backend
+	}
+	return notifier, nil
+}
+
+// Send sends a notification through the configured backends
+func Send(notification Notification) error {
+	notifier, err := New()
+	if err != nil {
+		zap.S().Error("Error creating notifier: ", err)
+		return err
+	}
+	return notifier.Notify(notification)
+}
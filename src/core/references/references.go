@@ -0,0 +1,74 @@
+package references
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sync"
+)
+
+// Counter counts the documents of a collection which reference the given ID
+type Counter func(id primitive.ObjectID) (int64, error)
+
+var mutex sync.RWMutex
+var counters = map[string]map[string]Counter{}
+
+// Register registers a counter of the documents of the referencing collection which reference
+// the documents of the referenced collection. Packages register their counters in their init function,
+// so that the referenced package does not need to import the referencing one.
+func Register(referenced string, referencing string, counter Counter) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if counters[referenced] == nil {
+		counters[referenced] = map[string]Counter{}
+	}
+	counters[referenced][referencing] = counter
+}
+
+// Count returns the number of documents referencing the given ID, grouped by referencing collection
+// Collections with no references are not included
+func Count(referenced string, id primitive.ObjectID) (map[string]int64, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	res := map[string]int64{}
+	for referencing, counter := range counters[referenced] {
+		count, err := counter(id)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			res[referencing] = count
+		}
+	}
+	return res, nil
+}
+
+// Replacer replaces the references to any of the from IDs with the to ID, returning the number of updated documents
+type Replacer func(from []primitive.ObjectID, to primitive.ObjectID) (int64, error)
+
+var replacers = map[string]map[string]Replacer{}
+
+// RegisterReplacer registers a replacer of the references from the documents of the referencing collection
+// to the documents of the referenced collection, used when merging referenced documents
+func RegisterReplacer(referenced string, referencing string, replacer Replacer) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if replacers[referenced] == nil {
+		replacers[referenced] = map[string]Replacer{}
+	}
+	replacers[referenced][referencing] = replacer
+}
+
+// Replace replaces the references to any of the from IDs with the to ID in all the referencing collections
+// Returns the number of updated documents grouped by referencing collection
+func Replace(referenced string, from []primitive.ObjectID, to primitive.ObjectID) (map[string]int64, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	res := map[string]int64{}
+	for referencing, replacer := range replacers[referenced] {
+		count, err := replacer(from, to)
+		if err != nil {
+			return res, err
+		}
+		res[referencing] = count
+	}
+	return res, nil
+}
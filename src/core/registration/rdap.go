@@ -0,0 +1,154 @@
+package registration
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rdapDomain the subset of the RDAP domain object (RFC 9083) used here
+type rdapDomain struct {
+	LdhName string   `json:"ldhName"`
+	Status  []string `json:"status"`
+	Events  []struct {
+		Action string `json:"eventAction"`
+		Date   string `json:"eventDate"`
+	} `json:"events"`
+	Entities []struct {
+		Roles      []string        `json:"roles"`
+		VcardArray json.RawMessage `json:"vcardArray"`
+	} `json:"entities"`
+	Nameservers []struct {
+		LdhName string `json:"ldhName"`
+	} `json:"nameservers"`
+}
+
+// rdapBootstrap the IANA RDAP bootstrap registry (RFC 7484)
+type rdapBootstrap struct {
+	Services [][][]string `json:"services"`
+}
+
+// maxRDAPResponse the maximum size of an RDAP response
+const maxRDAPResponse = 1 << 20
+
+var bootstrapMutex sync.Mutex
+var bootstrapCache = map[string]map[string]string{}
+
+// baseURL returns the RDAP base url of the registry serving the given domain name
+func (self *Client) baseURL(name string) (string, error) {
+	if self.RDAPBaseURL != "" {
+		return self.RDAPBaseURL, nil
+	}
+
+	bootstrapMutex.Lock()
+	defer bootstrapMutex.Unlock()
+	tlds, ok := bootstrapCache[self.BootstrapURL]
+	if !ok {
+		var bootstrap rdapBootstrap
+		if err := self.getJSON(self.BootstrapURL, &bootstrap); err != nil {
+			return "", err
+		}
+		tlds = map[string]string{}
+		for _, service := range bootstrap.Services {
+			if len(service) < 2 || len(service[1]) == 0 {
+				continue
+			}
+			for _, tld := range service[0] {
+				tlds[strings.ToLower(tld)] = service[1][0]
+			}
+		}
+		bootstrapCache[self.BootstrapURL] = tlds
+	}
+
+	labels := strings.Split(name, ".")
+	if url, ok := tlds[labels[len(labels)-1]]; ok {
+		return url, nil
+	}
+	return "", fmt.Errorf("No RDAP server known for %s", name)
+}
+
+// getJSON performs a GET request decoding the json response
+func (self *Client) getJSON(url string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/rdap+json, application/json")
+	res, err := self.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("RDAP server answered with status %d", res.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(res.Body, maxRDAPResponse)).Decode(v)
+}
+
+// lookupRDAP fetches the registration data from the RDAP server of the registry
+func (self *Client) lookupRDAP(name string) (*Registration, error) {
+	base, err := self.baseURL(name)
+	if err != nil {
+		return nil, err
+	}
+	var domain rdapDomain
+	if err := self.getJSON(strings.TrimSuffix(base, "/")+"/domain/"+name, &domain); err != nil {
+		return nil, err
+	}
+
+	registration := &Registration{Source: "rdap", Statuses: []string{}, Nameservers: []string{}}
+	registration.Statuses = append(registration.Statuses, domain.Status...)
+	for _, nameserver := range domain.Nameservers {
+		registration.Nameservers = append(registration.Nameservers, strings.ToLower(strings.TrimSuffix(nameserver.LdhName, ".")))
+	}
+	for _, event := range domain.Events {
+		date, err := time.Parse(time.RFC3339, event.Date)
+		if err != nil {
+			continue
+		}
+		switch event.Action {
+		case "registration":
+			registration.RegisteredAt = date.Unix()
+		case "expiration":
+			registration.ExpiresAt = date.Unix()
+		}
+	}
+	for _, entity := range domain.Entities {
+		for _, role := range entity.Roles {
+			if role == "registrar" {
+				registration.Registrar = vcardName(entity.VcardArray)
+			}
+		}
+	}
+
+	return registration, nil
+}
+
+// vcardName extracts the formatted name (fn) from a jCard (RFC 7095)
+func vcardName(raw json.RawMessage) string {
+	var vcard []interface{}
+	if err := json.Unmarshal(raw, &vcard); err != nil || len(vcard) < 2 {
+		return ""
+	}
+	properties, ok := vcard[1].([]interface{})
+	if !ok {
+		return ""
+	}
+	for _, p := range properties {
+		property, ok := p.([]interface{})
+		if !ok || len(property) < 4 || property[0] != "fn" {
+			continue
+		}
+		if name, ok := property[3].(string); ok {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package registration
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// Registration the registration data of a domain as published by its registry
+type Registration struct {
+	Registrar    string
+	Statuses     []string
+	Nameservers  []string
+	RegisteredAt int64
+	ExpiresAt    int64
+	Source       string // rdap or whois
+}
+
+// Client looks up domains registration data through RDAP, falling back to WHOIS
+type Client struct {
+	// RDAPBaseURL if set, is used for all the domains instead of the IANA bootstrap registry
+	RDAPBaseURL string
+	// BootstrapURL the IANA RDAP bootstrap registry for DNS
+	BootstrapURL string
+	// WhoisServer if set, is queried for all the domains instead of following the IANA referral
+	WhoisServer string
+	// WhoisDisabled disables the WHOIS fallback
+	WhoisDisabled bool
+	Timeout       time.Duration
+	HTTPClient    *http.Client
+}
+
+// NewClient returns a client configured from settings
+func NewClient() *Client {
+	timeout := time.Duration(viper.GetInt("registration.timeoutSeconds")) * time.Second
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	bootstrapURL := viper.GetString("registration.rdap.bootstrapUrl")
+	if bootstrapURL == "" {
+		bootstrapURL = "https://data.iana.org/rdap/dns.json"
+	}
+	return &Client{
+		RDAPBaseURL:   viper.GetString("registration.rdap.baseUrl"),
+		BootstrapURL:  bootstrapURL,
+		WhoisServer:   viper.GetString("registration.whois.server"),
+		WhoisDisabled: viper.GetBool("registration.whois.disabled"),
+		Timeout:       timeout,
+		HTTPClient:    &http.Client{Timeout: timeout},
+	}
+}
+
+// Lookup fetches the registration data of the given domain name (punycode)
+func (self *Client) Lookup(name string) (*Registration, error) {
+	registration, rdapErr := self.lookupRDAP(name)
+	if rdapErr == nil {
+		return registration, nil
+	}
+	zap.S().Debugw("RDAP lookup failed", "domain", name, "error", rdapErr)
+	if self.WhoisDisabled {
+		return nil, rdapErr
+	}
+
+	registration, whoisErr := self.lookupWhois(name)
+	if whoisErr != nil {
+		zap.S().Debugw("WHOIS lookup failed", "domain", name, "error", whoisErr)
+		return nil, fmt.Errorf("rdap: %v, whois: %v", rdapErr, whoisErr)
+	}
+	return registration, nil
+}
+
+// ErrNotFound the domain is not registered
+var ErrNotFound = errors.New("Domain not found in registry")
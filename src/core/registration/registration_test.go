@@ -0,0 +1,149 @@
+package registration
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const rdapResponse = `{
+	"objectClassName": "domain",
+	"ldhName": "example.com",
+	"status": ["client transfer prohibited", "active"],
+	"events": [
+		{"eventAction": "registration", "eventDate": "1995-08-14T04:00:00Z"},
+		{"eventAction": "expiration", "eventDate": "2030-08-13T04:00:00Z"},
+		{"eventAction": "last changed", "eventDate": "2024-08-14T07:01:34Z"}
+	],
+	"entities": [
+		{"roles": ["registrar"], "vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "Example Registrar, Inc."]]]},
+		{"roles": ["abuse"], "vcardArray": ["vcard", [["fn", {}, "text", "Abuse desk"]]]}
+	],
+	"nameservers": [{"ldhName": "A.IANA-SERVERS.NET."}, {"ldhName": "b.iana-servers.net"}]
+}`
+
+// testClient returns a client using the given RDAP server, with the WHOIS fallback disabled
+func testClient(rdap *httptest.Server) *Client {
+	return &Client{RDAPBaseURL: rdap.URL, WhoisDisabled: true, Timeout: 5 * time.Second, HTTPClient: rdap.Client()}
+}
+
+func TestLookupRDAP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/domain/example.com" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rdap+json")
+		fmt.Fprint(w, rdapResponse)
+	}))
+	defer server.Close()
+
+	registration, err := testClient(server).Lookup("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registration.Source != "rdap" || registration.Registrar != "Example Registrar, Inc." {
+		t.Fatalf("unexpected registration %+v", registration)
+	}
+	if registration.RegisteredAt != time.Date(1995, 8, 14, 4, 0, 0, 0, time.UTC).Unix() ||
+		registration.ExpiresAt != time.Date(2030, 8, 13, 4, 0, 0, 0, time.UTC).Unix() {
+		t.Fatalf("unexpected dates %d - %d", registration.RegisteredAt, registration.ExpiresAt)
+	}
+	if strings.Join(registration.Nameservers, ",") != "a.iana-servers.net,b.iana-servers.net" {
+		t.Fatalf("unexpected nameservers %v", registration.Nameservers)
+	}
+	if len(registration.Statuses) != 2 {
+		t.Fatalf("unexpected statuses %v", registration.Statuses)
+	}
+}
+
+func TestLookupRDAPNotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := testClient(server).Lookup("unregistered.example")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// whoisServer starts a local WHOIS server answering the given response, and returns its address
+func whoisServer(t *testing.T, response string) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	queries := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		query, _ := bufio.NewReader(conn).ReadString('\n')
+		queries <- strings.TrimSpace(query)
+		fmt.Fprint(conn, response)
+	}()
+	return listener.Addr().String(), queries
+}
+
+func TestLookupWhoisFallback(t *testing.T) {
+	rdap := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer rdap.Close()
+	address, queries := whoisServer(t, strings.Join([]string{
+		"% test server",
+		"Domain Name: EXAMPLE.COM",
+		"Registrar: Example Registrar, Inc.",
+		"Creation Date: 1995-08-14T04:00:00Z",
+		"Registry Expiry Date: 2030-08-13T04:00:00Z",
+		"Domain Status: clientTransferProhibited https://icann.org/epp#clientTransferProhibited",
+		"Name Server: A.IANA-SERVERS.NET",
+		"Name Server: B.IANA-SERVERS.NET",
+		"",
+	}, "\r\n"))
+
+	client := testClient(rdap)
+	client.WhoisDisabled = false
+	client.WhoisServer = address
+	registration, err := client.Lookup("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query := <-queries; query != "example.com" {
+		t.Fatalf("unexpected query %q", query)
+	}
+	if registration.Source != "whois" || registration.Registrar != "Example Registrar, Inc." {
+		t.Fatalf("unexpected registration %+v", registration)
+	}
+	if registration.ExpiresAt != time.Date(2030, 8, 13, 4, 0, 0, 0, time.UTC).Unix() {
+		t.Fatalf("unexpected expiry %d", registration.ExpiresAt)
+	}
+	if strings.Join(registration.Statuses, ",") != "clientTransferProhibited" ||
+		strings.Join(registration.Nameservers, ",") != "a.iana-servers.net,b.iana-servers.net" {
+		t.Fatalf("unexpected statuses %v or nameservers %v", registration.Statuses, registration.Nameservers)
+	}
+}
+
+func TestLookupWhoisNotFound(t *testing.T) {
+	rdap := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer rdap.Close()
+	address, _ := whoisServer(t, "No match for \"UNREGISTERED.EXAMPLE\".\r\n")
+
+	client := testClient(rdap)
+	client.WhoisDisabled = false
+	client.WhoisServer = address
+	if _, err := client.Lookup("unregistered.example"); err == nil || !strings.Contains(err.Error(), ErrNotFound.Error()) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package registration
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+)
+
+const ianaWhoisServer = "whois.iana.org"
+
+// maxWhoisResponse the maximum size of a WHOIS response, the rest is ignored
+const maxWhoisResponse = 1 << 20
+
+// whoisDateLayouts date formats used by the most common WHOIS servers
+var whoisDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z",
+	"2006-01-02T15:04:05.0Z",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"02-Jan-2006",
+}
+
+// queryWhois sends a query to a WHOIS server (RFC 3912) and returns the raw response
+func (self *Client) queryWhois(server string, query string) (string, error) {
+	address := server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		address = net.JoinHostPort(server, "43")
+	}
+	conn, err := net.DialTimeout("tcp", address, self.Timeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(self.Timeout))
+	if _, err := fmt.Fprintf(conn, "%s\r\n", query); err != nil {
+		return "", err
+	}
+	response, err := ioutil.ReadAll(io.LimitReader(conn, maxWhoisResponse))
+	if err != nil {
+		return "", err
+	}
+	return string(response), nil
+}
+
+// whoisServer returns the WHOIS server of the registry serving the given domain name
+func (self *Client) whoisServer(name string) (string, error) {
+	if self.WhoisServer != "" {
+		return self.WhoisServer, nil
+	}
+	labels := strings.Split(name, ".")
+	response, err := self.queryWhois(ianaWhoisServer, labels[len(labels)-1])
+	if err != nil {
+		return "", err
+	}
+	fields := whoisFields(response)
+	for _, key := range []string{"refer", "whois"} {
+		if values, ok := fields[key]; ok {
+			return values[0], nil
+		}
+	}
+	return "", fmt.Errorf("No WHOIS server known for %s", name)
+}
+
+// whoisFields parses the "key: value" lines of a WHOIS response, keys are lowercased
+func whoisFields(response string) map[string][]string {
+	fields := map[string][]string{}
+	scanner := bufio.NewScanner(strings.NewReader(response))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "%") || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ">>>") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(parts[0]))
+		fields[key] = append(fields[key], strings.TrimSpace(parts[1]))
+	}
+	return fields
+}
+
+// whoisDate parses a WHOIS date, returning 0 if the format is not known
+func whoisDate(value string) int64 {
+	for _, layout := range whoisDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date.Unix()
+		}
+	}
+	return 0
+}
+
+// lookupWhois fetches the registration data from the WHOIS server of the registry
+func (self *Client) lookupWhois(name string) (*Registration, error) {
+	server, err := self.whoisServer(name)
+	if err != nil {
+		return nil, err
+	}
+	response, err := self.queryWhois(server, name)
+	if err != nil {
+		return nil, err
+	}
+	lower := strings.ToLower(response)
+	if strings.Contains(lower, "no match for") || strings.Contains(lower, "not found") || strings.Contains(lower, "status: free") {
+		return nil, ErrNotFound
+	}
+
+	fields := whoisFields(response)
+	registration := &Registration{Source: "whois", Statuses: []string{}, Nameservers: []string{}}
+	first := func(keys ...string) string {
+		for _, key := range keys {
+			if values, ok := fields[key]; ok {
+				return values[0]
+			}
+		}
+		return ""
+	}
+	registration.Registrar = first("registrar", "registrar name", "sponsoring registrar")
+	registration.RegisteredAt = whoisDate(first("creation date", "created", "registered on", "registration time"))
+	registration.ExpiresAt = whoisDate(first("registry expiry date", "registrar registration expiration date", "expiration date", "expire date", "expiry date", "paid-till"))
+	for _, status := range append(fields["domain status"], fields["status"]...) {
+		// "clientTransferProhibited https://icann.org/epp#clientTransferProhibited"
+		registration.Statuses = append(registration.Statuses, strings.Fields(status)[0])
+	}
+	for _, nameserver := range append(fields["name server"], fields["nserver"]...) {
+		registration.Nameservers = append(registration.Nameservers, strings.ToLower(strings.TrimSuffix(strings.Fields(nameserver)[0], ".")))
+	}
+
+	return registration, nil
+}
@@ -0,0 +1,35 @@
+package scheduler
+
+import (
+	"go.uber.org/zap"
+	"time"
+)
+
+// Every runs the given job in a background goroutine, once at startup and then at every interval
+// The name is only used for logging purposes
+func Every(name string, interval time.Duration, job func()) {
+	if interval <= 0 {
+		zap.S().Warnw("Scheduler, job not started because of invalid interval", "job", name, "interval", interval)
+		return
+	}
+	zap.S().Infow("Scheduler, starting job", "job", name, "interval", interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			run(name, job)
+			<-ticker.C
+		}
+	}()
+}
+
+// run executes the job recovering from panics, so that a failing job does not bring the server down
+func run(name string, job func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			zap.S().Errorw("Scheduler, job panicked", "job", name, "error", r)
+		}
+	}()
+	zap.S().Debugw("Scheduler, running job", "job", name)
+	job()
+}
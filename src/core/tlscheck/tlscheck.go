@@ -0,0 +1,112 @@
+package tlscheck
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"github.com/spf13/viper"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Certificate the leaf certificate presented by a server and the result of its verification
+type Certificate struct {
+	Subject    string
+	Issuer     string
+	Sans       []string
+	NotBefore  int64
+	NotAfter   int64
+	ChainError string
+}
+
+// Target where and how to connect: the address is dialed, the server name is sent as SNI and verified
+type Target struct {
+	ServerName string
+	Ip         string
+	Port       int
+}
+
+// Checker connects to TLS servers and inspects their certificates
+type Checker struct {
+	Timeout time.Duration
+	// Roots the CA pool used to verify the chains, system pool if nil
+	Roots *x509.CertPool
+}
+
+// NewChecker returns a checker configured from settings
+// tls.rootCAFile may point to a PEM bundle used instead of the system pool (i.e. to trust local test servers)
+func NewChecker() (*Checker, error) {
+	timeout := time.Duration(viper.GetInt("tls.timeoutSeconds")) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	checker := &Checker{Timeout: timeout}
+	if file := viper.GetString("tls.rootCAFile"); file != "" {
+		pem, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		checker.Roots = x509.NewCertPool()
+		if !checker.Roots.AppendCertsFromPEM(pem) {
+			return nil, errors.New("No certificate found in " + file)
+		}
+	}
+	return checker, nil
+}
+
+// Check connects to the target and returns its certificate
+// Chain and hostname verification errors don't fail the check, they are reported in the certificate ChainError
+func (self *Checker) Check(target Target) (*Certificate, error) {
+	host := target.Ip
+	if host == "" {
+		host = target.ServerName
+	}
+	port := target.Port
+	if port == 0 {
+		port = 443
+	}
+
+	dialer := &net.Dialer{Timeout: self.Timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(host, strconv.Itoa(port)), &tls.Config{
+		ServerName: target.ServerName,
+		// verification is done below, so that an invalid certificate can still be inspected
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, errors.New("No certificate presented")
+	}
+	leaf := certs[0]
+	certificate := &Certificate{
+		Subject:   leaf.Subject.String(),
+		Issuer:    leaf.Issuer.String(),
+		Sans:      leaf.DNSNames,
+		NotBefore: leaf.NotBefore.Unix(),
+		NotAfter:  leaf.NotAfter.Unix(),
+	}
+	for _, ip := range leaf.IPAddresses {
+		certificate.Sans = append(certificate.Sans, ip.String())
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err = leaf.Verify(x509.VerifyOptions{
+		DNSName:       target.ServerName,
+		Roots:         self.Roots,
+		Intermediates: intermediates,
+	})
+	if err != nil {
+		certificate.ChainError = err.Error()
+	}
+
+	return certificate, nil
+}
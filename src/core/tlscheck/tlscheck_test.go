@@ -0,0 +1,87 @@
+package tlscheck
+
+import (
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// testTarget returns the target of the local test server, presented with the given server name
+func testTarget(t *testing.T, server *httptest.Server, serverName string) Target {
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := strconv.Atoi(port)
+	return Target{ServerName: serverName, Ip: host, Port: p}
+}
+
+func TestCheckTrustedCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	checker := &Checker{Timeout: 5 * time.Second, Roots: roots}
+
+	cert, err := checker.Check(testTarget(t, server, "example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.ChainError != "" {
+		t.Fatalf("unexpected chain error %s", cert.ChainError)
+	}
+	if cert.NotAfter != server.Certificate().NotAfter.Unix() || cert.NotBefore != server.Certificate().NotBefore.Unix() {
+		t.Fatalf("unexpected validity %d - %d", cert.NotBefore, cert.NotAfter)
+	}
+	found := false
+	for _, san := range cert.Sans {
+		found = found || san == "example.com"
+	}
+	if !found {
+		t.Fatalf("expected example.com in the SANs, got %v", cert.Sans)
+	}
+	if cert.Issuer == "" || cert.Subject == "" {
+		t.Fatal("expected the subject and issuer")
+	}
+}
+
+func TestCheckChainErrors(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// the test certificate is not trusted by the system pool
+	untrusted := &Checker{Timeout: 5 * time.Second, Roots: x509.NewCertPool()}
+	cert, err := untrusted.Check(testTarget(t, server, "example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.ChainError == "" {
+		t.Fatal("expected a chain error for an untrusted certificate")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	trusted := &Checker{Timeout: 5 * time.Second, Roots: roots}
+	cert, err = trusted.Check(testTarget(t, server, "other.example"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.ChainError == "" {
+		t.Fatal("expected a chain error for a name not in the certificate")
+	}
+}
+
+func TestCheckConnectionError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	target := testTarget(t, server, "example.com")
+	server.Close()
+
+	checker := &Checker{Timeout: time.Second}
+	if _, err := checker.Check(target); err == nil {
+		t.Fatal("expected an error when the server is down")
+	}
+}
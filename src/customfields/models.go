@@ -0,0 +1,103 @@
+package customfields
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive" // for BSON ObjectID
+	"strconv"
+	"strings"
+	"systems-management-api/core/utils"
+	"time"
+)
+
+// Custom field types
+const (
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeDate    = "date"
+	TypeEnum    = "enum"
+)
+
+// Field the custom field definition model, defining an additional attribute of the domains
+// Values are stored by name, dates as YYYY-MM-DD strings
+type Field struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Name        string             `json:"name"`
+	Label       string             `json:"label"`
+	Description string             `json:"description"`
+	Type        string             `json:"type"`
+	Required    bool               `json:"required"`
+	Values      []string           `json:"values"`
+	Created     int64              `json:"created"`
+	Updated     int64              `json:"updated"`
+}
+
+// Validate checks that the value suits the field type, returning the value as stored
+func (self *Field) Validate(value interface{}) (interface{}, error) {
+	switch self.Type {
+	case TypeString:
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+	case TypeNumber:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int:
+			return float64(v), nil
+		case int32:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		}
+	case TypeBoolean:
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+	case TypeDate:
+		if v, ok := value.(string); ok {
+			if _, err := time.Parse("2006-01-02", v); err == nil {
+				return v, nil
+			}
+		}
+		return nil, fmt.Errorf("Custom field %s must be a date (YYYY-MM-DD)", self.Name)
+	case TypeEnum:
+		if v, ok := value.(string); ok && utils.Contains(self.Values, v) {
+			return v, nil
+		}
+		return nil, fmt.Errorf("Custom field %s must be one of %s", self.Name, strings.Join(self.Values, ", "))
+	}
+	return nil, fmt.Errorf("Custom field %s must be a %s", self.Name, self.Type)
+}
+
+// Parse converts a textual value (i.e. a CSV cell or a query parameter) to the field type, then validates it
+func (self *Field) Parse(text string) (interface{}, error) {
+	var value interface{} = text
+	switch self.Type {
+	case TypeNumber:
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Custom field %s must be a number", self.Name)
+		}
+		value = number
+	case TypeBoolean:
+		flag, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("Custom field %s must be a boolean", self.Name)
+		}
+		value = flag
+	}
+	return self.Validate(value)
+}
+
+func (self *Field) Save() (bool, error) {
+	fieldService := new(FieldService) // @TODO factory method
+	result, err := fieldService.Save(self)
+	return result, err
+}
+
+func (self *Field) Delete() (bool, error) {
+	fieldService := new(FieldService) // @TODO factory method
+	result, err := fieldService.Delete(self)
+	return result, err
+}
@@ -0,0 +1,14 @@
+package customfields
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RoutesRegister attaches routes (path + view) to the given gin router group (paths namespace)
+func RoutesRegister(router *gin.RouterGroup) {
+	router.GET("", FieldListView)
+	router.GET("/:id", FieldDetailView)
+	router.POST("", CreateFieldView)
+	router.PUT("/:id", UpdateFieldView)
+	router.DELETE("/:id", DeleteFieldView)
+}
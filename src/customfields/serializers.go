@@ -0,0 +1,47 @@
+package customfields
+
+type fieldSerializer struct{}
+
+type FieldData struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Label       string   `json:"label"`
+	Description string   `json:"description"`
+	Type        string   `json:"type"`
+	Required    bool     `json:"required"`
+	Values      []string `json:"values"`
+	Created     int64    `json:"created"`
+	Updated     int64    `json:"updated"`
+}
+
+func NewFieldSerializer() *fieldSerializer {
+	return &fieldSerializer{}
+}
+
+func (self *fieldSerializer) Serialize(field *Field) FieldData {
+	values := field.Values
+	if values == nil {
+		values = make([]string, 0)
+	}
+	fieldData := FieldData{
+		ID:          field.ID.Hex(),
+		Name:        field.Name,
+		Label:       field.Label,
+		Description: field.Description,
+		Type:        field.Type,
+		Required:    field.Required,
+		Values:      values,
+		Created:     field.Created,
+		Updated:     field.Updated,
+	}
+	return fieldData
+}
+
+func (self *fieldSerializer) SerializeMany(fields *[]Field) []FieldData {
+	var res []FieldData
+	res = make([]FieldData, 0)
+	for _, field := range *fields {
+		res = append(res, self.Serialize(&field))
+	}
+	return res
+}
@@ -0,0 +1,127 @@
+package customfields
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	database "systems-management-api/core/database"
+	"systems-management-api/core/references"
+)
+
+// EnsureIndexes creates the custom field collection indexes
+func EnsureIndexes() {
+	db := database.DB()
+	err := db.EnsureIndexes("customfield", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetUnique(true).SetName("unique_name")},
+	})
+	if err != nil {
+		zap.S().Error("Error creating custom field indexes: ", err)
+	}
+}
+
+// FieldService service which provides methods to access and modify database data
+type FieldService struct{}
+
+// Retrieves all custom fields instances, sorted by name
+func (service *FieldService) All() (*[]Field, error) {
+	db := database.DB()
+	collection := db.D.Collection("customfield")
+	cursor, err := collection.Find(context.TODO(), bson.D{{}}, options.Find().SetSort(bson.M{"name": 1}))
+
+	if err != nil {
+		return nil, err
+	} else {
+		fields := []Field{}
+		for cursor.Next(context.TODO()) {
+			var field Field
+			cursor.Decode(&field)
+			fields = append(fields, field)
+		}
+		return &fields, nil
+	}
+}
+
+// Retrieves a custom field instance given its ID
+func (service *FieldService) GetById(id string) (*Field, error) {
+	db := database.DB()
+	field := Field{}
+
+	if err := db.GetById("customfield", id, &field); err != nil {
+		return nil, err
+	} else {
+		return &field, nil
+	}
+}
+
+// Retrieves a custom field instance given its name
+func (service *FieldService) GetByName(name string) (*Field, error) {
+	db := database.DB()
+	collection := db.D.Collection("customfield")
+	field := Field{}
+
+	if err := collection.FindOne(context.TODO(), bson.M{"name": name}).Decode(&field); err != nil {
+		return nil, err
+	} else {
+		return &field, nil
+	}
+}
+
+// Returns the number of documents referencing the custom field, grouped by collection
+func (service *FieldService) References(field *Field) (map[string]int64, error) {
+	return references.Count("customfield", field.ID)
+}
+
+// Saves the custom field model to database
+// Returns boolean result and error
+func (service *FieldService) Save(field *Field) (bool, error) {
+	db := database.DB()
+	collection := db.D.Collection("customfield")
+
+	if field.ID.IsZero() {
+		// insert
+		res, err := collection.InsertOne(context.TODO(), field)
+
+		if err != nil {
+			zap.S().Error("Error inserting custom field: ", err)
+			return false, err
+		} else {
+			zap.S().Info(fmt.Sprintf("Field %s inserted succesfully", field.Name))
+			// update custom field ID
+			field.ID = res.InsertedID.(primitive.ObjectID)
+			return true, nil
+		}
+	} else {
+		// update
+		filter := bson.M{"_id": field.ID}
+		_, err := collection.ReplaceOne(context.TODO(), filter, field)
+
+		if err != nil {
+			zap.S().Error("Error updating custom field: ", err)
+			return false, err
+		} else {
+			zap.S().Info(fmt.Sprintf("Field %s updated succesfully", field.Name))
+			return true, nil
+		}
+	}
+}
+
+// Deletes the custom field model from databse
+// Returns boolean result and error
+func (service *FieldService) Delete(field *Field) (bool, error) {
+	db := database.DB()
+	collection := db.D.Collection("customfield")
+
+	_, err := collection.DeleteOne(context.TODO(), bson.M{"_id": field.ID})
+
+	if err != nil {
+		zap.S().Error("Error deleting custom field: ", err)
+		return false, err
+	} else {
+		zap.S().Info(fmt.Sprintf("Field %s deleted succesfully", field.Name))
+		return true, nil
+	}
+}
@@ -0,0 +1,98 @@
+package customfields
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// fieldName custom field names are used as keys of the stored values and as query parameters
+var fieldName = regexp.MustCompile(`^[a-z][a-zA-Z0-9_]*$`)
+
+type FieldValidatorData struct {
+	Name        string   `json:"name" binding:"required,max=64"`
+	Label       string   `json:"label" binding:"max=255"`
+	Description string   `json:"description"`
+	Type        string   `json:"type" binding:"required,oneof=string number boolean date enum"`
+	Required    bool     `json:"required"`
+	Values      []string `json:"values" binding:"dive,required,max=255"`
+}
+type FieldValidator struct {
+	FieldData FieldValidatorData `json:"field"`
+	field     Field              `json:"-"`
+}
+
+func (self *FieldValidator) fillModelData() error {
+	self.field.Name = strings.TrimSpace(self.FieldData.Name)
+	if !fieldName.MatchString(self.field.Name) {
+		return fmt.Errorf("Invalid custom field name %s, letters, digits and underscores are allowed, starting with a lowercase letter", self.field.Name)
+	}
+	self.field.Label = strings.TrimSpace(self.FieldData.Label)
+	if self.field.Label == "" {
+		self.field.Label = self.field.Name
+	}
+	self.field.Description = self.FieldData.Description
+	self.field.Type = self.FieldData.Type
+	self.field.Required = self.FieldData.Required
+	self.field.Values = []string{}
+	if self.field.Type == TypeEnum {
+		seen := make(map[string]bool)
+		for _, value := range self.FieldData.Values {
+			if !seen[value] {
+				self.field.Values = append(self.field.Values, value)
+				seen[value] = true
+			}
+		}
+		if len(self.field.Values) == 0 {
+			return errors.New("Enum custom fields require at least one value")
+		}
+	}
+	return nil
+}
+
+func (self *FieldValidator) Bind(c *gin.Context) error {
+	err := c.ShouldBind(&self.FieldData)
+	if err != nil {
+		zap.S().Debug("Field Validation Error: ", err)
+		return err
+	}
+	if err := self.fillModelData(); err != nil {
+		zap.S().Debug("Field Validation Error: ", err)
+		return err
+	}
+	self.field.Created = time.Now().Unix()
+	self.field.Updated = time.Now().Unix()
+
+	return nil
+}
+
+// BindUpdate validates the field update, the name cannot be changed since values are stored by name
+func (self *FieldValidator) BindUpdate(field *Field, c *gin.Context) error {
+	err := c.ShouldBind(&self.FieldData)
+	if err != nil {
+		zap.S().Debug("Field Validation Error: ", err)
+		return err
+	}
+	self.field.ID = field.ID
+	if err := self.fillModelData(); err != nil {
+		zap.S().Debug("Field Validation Error: ", err)
+		return err
+	}
+	if self.field.Name != field.Name {
+		return errors.New("The name of a custom field cannot be changed")
+	}
+	self.field.Created = field.Created
+	self.field.Updated = time.Now().Unix()
+
+	return nil
+}
+
+// You can put the default value of a Validator here
+func NewFieldValidator() FieldValidator {
+	fieldValidator := FieldValidator{}
+	return fieldValidator
+}
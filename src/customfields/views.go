@@ -0,0 +1,195 @@
+package customfields
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"net/http"
+	"systems-management-api/auth"
+	"systems-management-api/core/utils"
+)
+
+// duplicateNameResponse error returned when saving a custom field whose name is already taken
+func duplicateNameResponse(field *Field) utils.ErrorResponse {
+	return utils.ErrorResponse{Message: fmt.Sprintf("A custom field named %s already exists", field.Name)}
+}
+
+// Returns all custom fields, admin or superadmin roles required
+// @Summary Custom fields list
+// @Description Retrieves all customfields
+// @Security BearerAuth
+// @Tags customfields
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} FieldData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /customfield/ [get]
+func fieldListView(c *gin.Context) {
+	fieldService := new(FieldService)
+	fields, err := fieldService.All()
+
+	if err != nil {
+		zap.S().Error("Error while getting all custom fields, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{
+			Message: "Cannot fetch custom fields",
+		})
+	} else {
+		serializer := NewFieldSerializer()
+		c.JSON(http.StatusOK, serializer.SerializeMany(fields))
+	}
+}
+
+var FieldListView = auth.RoleRequired([]string{"admin", "superadmin"}, fieldListView)
+
+// Returns custom field given its id
+// @Summary Custom field detail
+// @Description Retrieves one custom field given its id
+// @Security BearerAuth
+// @Tags customfields
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Custom field ID"
+// @Success 200 {object} FieldData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /customfield/{id} [get]
+func fieldDetailView(c *gin.Context) {
+	fieldService := new(FieldService)
+	field, err := fieldService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting custom field, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Custom field not found"})
+	} else {
+		serializer := NewFieldSerializer()
+		c.JSON(http.StatusOK, serializer.Serialize(field))
+	}
+}
+
+var FieldDetailView = auth.RoleRequired([]string{"admin", "superadmin"}, fieldDetailView)
+
+// Creates a customfield
+// @Summary Create custom field
+// @Description Creates a customfield
+// @Security BearerAuth
+// @Tags customfields
+// @Accept  json
+// @Produce  json
+// @Param field body FieldValidatorData true "Custom field data"
+// @Success 201 {object} FieldData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /customfield/ [post]
+func createFieldView(c *gin.Context) {
+	fieldValidator := NewFieldValidator()
+	if err := fieldValidator.Bind(c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if _, err := fieldValidator.field.Save(); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, duplicateNameResponse(&fieldValidator.field))
+			return
+		}
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Cannot insert custom field: %v", err)})
+		return
+	}
+	serializer := NewFieldSerializer()
+	c.JSON(http.StatusCreated, serializer.Serialize(&fieldValidator.field))
+}
+
+var CreateFieldView = auth.RoleRequired([]string{"admin", "superadmin"}, createFieldView)
+
+// Updates a customfield
+// @Summary Update custom field
+// @Description Updates a customfield
+// @Security BearerAuth
+// @Tags customfields
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Custom field ID"
+// @Param field body FieldValidatorData true "Custom field data"
+// @Success 200 {object} FieldData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /customfield/{id} [put]
+func updateFieldView(c *gin.Context) {
+	fieldService := new(FieldService)
+	field, err := fieldService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting custom field, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Custom field not found"})
+		return
+	}
+
+	fieldValidator := NewFieldValidator()
+	if err := fieldValidator.BindUpdate(field, c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if _, err := fieldValidator.field.Save(); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, duplicateNameResponse(&fieldValidator.field))
+			return
+		}
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Cannot update custom field: %v", err)})
+		return
+	}
+	serializer := NewFieldSerializer()
+	c.JSON(http.StatusOK, serializer.Serialize(&fieldValidator.field))
+}
+
+var UpdateFieldView = auth.RoleRequired([]string{"admin", "superadmin"}, updateFieldView)
+
+// Deletes a custom field, custom fields still referenced (i.e. by domains) cannot be deleted
+// @Summary Delete custom field
+// @Description Deletes a custom field which is not referenced by other resources
+// @Security BearerAuth
+// @Tags customfields
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Custom field ID"
+// @Success 204
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /customfield/{id} [delete]
+func deleteFieldView(c *gin.Context) {
+	fieldService := new(FieldService)
+	field, err := fieldService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting custom field, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Custom field not found"})
+		return
+	}
+
+	refs, err := fieldService.References(field)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+	if len(refs) > 0 {
+		c.JSON(http.StatusConflict, utils.ErrorResponse{
+			Message: fmt.Sprintf("Custom field %s is still referenced: %v", field.Name, refs),
+		})
+		return
+	}
+
+	if _, err := field.Delete(); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, gin.H{})
+}
+
+var DeleteFieldView = auth.RoleRequired([]string{"admin", "superadmin"}, deleteFieldView)
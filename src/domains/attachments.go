@@ -0,0 +1,237 @@
+package domains
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"io"
+	"mime"
+	"net/http"
+	database "systems-management-api/core/database"
+	"systems-management-api/core/utils"
+	"time"
+)
+
+// attachmentBucket the GridFS bucket holding the attachments, stored in the attachment.files and attachment.chunks collections
+const attachmentBucket = "attachment"
+
+// defaultAttachmentTypes the MIME types accepted when domains.attachments.allowedTypes is not set
+var defaultAttachmentTypes = []string{
+	"application/pdf",
+	"application/zip",
+	"application/x-gzip",
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"text/plain",
+}
+
+// Attachment upload errors
+var (
+	ErrAttachmentEmpty    = errors.New("The file is empty")
+	ErrAttachmentTooLarge = errors.New("The file is too large")
+	ErrAttachmentType     = errors.New("The file type is not allowed")
+)
+
+// Attachment a file attached to a domain, as stored by GridFS (the files collection document)
+// The file content type is detected from its content, the checksum is computed while uploading
+type Attachment struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Filename   string             `bson:"filename"`
+	Length     int64              `bson:"length"`
+	UploadDate time.Time          `bson:"uploadDate"`
+	Metadata   AttachmentMeta     `bson:"metadata"`
+}
+
+// AttachmentMeta the metadata stored along an attachment
+type AttachmentMeta struct {
+	DomainId    primitive.ObjectID `json:"domainId"`
+	ContentType string             `json:"contentType"`
+	Sha256      string             `json:"sha256"`
+	UploadedBy  string             `json:"uploadedBy"`
+}
+
+// maxAttachmentSize returns the maximum size of an attachment, in bytes
+func maxAttachmentSize() int64 {
+	size := viper.GetInt64("domains.attachments.maxSizeMB")
+	if size <= 0 {
+		size = 20
+	}
+	return size << 20
+}
+
+// attachmentTypeAllowed tells whether files of the given MIME type can be attached
+func attachmentTypeAllowed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	allowed := viper.GetStringSlice("domains.attachments.allowedTypes")
+	if len(allowed) == 0 {
+		allowed = defaultAttachmentTypes
+	}
+	return utils.Contains(allowed, mediaType)
+}
+
+// ensureAttachmentIndexes creates the attachments files collection indexes, GridFS creates its own on first upload
+func ensureAttachmentIndexes() {
+	db := database.DB()
+	err := db.EnsureIndexes(attachmentBucket+".files", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "metadata.domainid", Value: 1}, {Key: "uploadDate", Value: 1}}, Options: options.Index().SetName("domain_uploaddate")},
+	})
+	if err != nil {
+		zap.S().Error("Error creating attachment indexes: ", err)
+	}
+}
+
+// AttachmentService service which provides methods to store and retrieve the domains attachments
+type AttachmentService struct{}
+
+// bucket returns the GridFS bucket of the attachments
+func (service *AttachmentService) bucket() (*gridfs.Bucket, error) {
+	db := database.DB()
+	return gridfs.NewBucket(db.D, options.GridFSBucket().SetName(attachmentBucket))
+}
+
+// Retrieves the attachments of the domain, sorted by upload date
+func (service *AttachmentService) all(domain *Domain) (*[]Attachment, error) {
+	return service.find(bson.M{"metadata.domainid": domain.ID})
+}
+
+// Retrieves the attachments matching the given filter, sorted by upload date
+func (service *AttachmentService) find(filter interface{}) (*[]Attachment, error) {
+	bucket, err := service.bucket()
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := bucket.Find(filter, options.GridFSFind().SetSort(bson.D{{Key: "uploadDate", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	attachments := []Attachment{}
+	if err := cursor.All(context.TODO(), &attachments); err != nil {
+		return nil, err
+	}
+	return &attachments, nil
+}
+
+// Retrieves an attachment of the domain given its ID
+func (service *AttachmentService) GetById(domain *Domain, id string) (*Attachment, error) {
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := service.find(bson.M{"_id": objId, "metadata.domainid": domain.ID})
+	if err != nil {
+		return nil, err
+	}
+	if len(*attachments) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &(*attachments)[0], nil
+}
+
+// Upload streams the file to GridFS, checking its type and size and computing its checksum
+// The upload is aborted, and its chunks removed, as soon as the file exceeds the maximum size
+func (service *AttachmentService) Upload(domain *Domain, filename string, source io.Reader, uploadedBy string) (*Attachment, error) {
+	reader := bufio.NewReaderSize(source, 512)
+	head, err := reader.Peek(512)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(head) == 0 {
+		return nil, ErrAttachmentEmpty
+	}
+	contentType := http.DetectContentType(head)
+	if !attachmentTypeAllowed(contentType) {
+		return nil, fmt.Errorf("%w: %s", ErrAttachmentType, contentType)
+	}
+
+	bucket, err := service.bucket()
+	if err != nil {
+		return nil, err
+	}
+	meta := AttachmentMeta{DomainId: domain.ID, ContentType: contentType, UploadedBy: uploadedBy}
+	stream, err := bucket.OpenUploadStream(filename, options.GridFSUpload().SetMetadata(meta))
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	limit := maxAttachmentSize()
+	written, err := io.Copy(io.MultiWriter(stream, hash), io.LimitReader(reader, limit+1))
+	if err == nil && written > limit {
+		err = ErrAttachmentTooLarge
+	}
+	if err != nil {
+		if abortErr := stream.Abort(); abortErr != nil {
+			zap.S().Error("Error aborting attachment upload: ", abortErr)
+		}
+		return nil, err
+	}
+	if err := stream.Close(); err != nil {
+		zap.S().Error("Error storing attachment: ", err)
+		return nil, err
+	}
+
+	id := stream.FileID.(primitive.ObjectID)
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	_, err = bucket.GetFilesCollection().UpdateOne(context.TODO(), bson.M{"_id": id}, bson.M{"$set": bson.M{"metadata.sha256": checksum}})
+	if err != nil {
+		zap.S().Error("Error storing attachment checksum: ", err)
+		return nil, err
+	}
+	zap.S().Info(fmt.Sprintf("Attachment %s of domain %s uploaded succesfully", filename, domain.Name))
+	return service.GetById(domain, id.Hex())
+}
+
+// Open returns a stream reading the attachment content
+func (service *AttachmentService) Open(attachment *Attachment) (*gridfs.DownloadStream, error) {
+	bucket, err := service.bucket()
+	if err != nil {
+		return nil, err
+	}
+	return bucket.OpenDownloadStream(attachment.ID)
+}
+
+// Deletes the attachment and its content
+// Returns boolean result and error
+func (service *AttachmentService) Delete(attachment *Attachment) (bool, error) {
+	bucket, err := service.bucket()
+	if err != nil {
+		return false, err
+	}
+	if err := bucket.Delete(attachment.ID); err != nil {
+		zap.S().Error("Error deleting attachment: ", err)
+		return false, err
+	}
+	zap.S().Info(fmt.Sprintf("Attachment %s of domain %s deleted succesfully", attachment.Filename, attachment.Metadata.DomainId.Hex()))
+	return true, nil
+}
+
+// Deletes all the attachments of the given domains
+// Returns the number of deleted attachments and error
+func (service *AttachmentService) DeleteByDomains(ids []primitive.ObjectID) (int64, error) {
+	attachments, err := service.find(bson.M{"metadata.domainid": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	for _, attachment := range *attachments {
+		if _, err := service.Delete(&attachment); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
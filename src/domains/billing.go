@@ -0,0 +1,247 @@
+package domains
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"strings"
+	"systems-management-api/packages"
+	"time"
+)
+
+// Line item kinds
+const (
+	LineRegistration = "registration"
+	LineHosting      = "hosting"
+	LineSsl          = "ssl"
+	LineOther        = "other"
+)
+
+// Billing periods of the line items, once items are charged a single time
+const (
+	PeriodOnce      = "once"
+	PeriodMonthly   = "monthly"
+	PeriodQuarterly = "quarterly"
+	PeriodYearly    = "yearly"
+)
+
+// periodMonths the number of months between two charges of each billing period
+var periodMonths = map[string]int{
+	PeriodMonthly:   1,
+	PeriodQuarterly: 3,
+	PeriodYearly:    12,
+}
+
+// maxBillingRange the longest period a billing report can cover
+const maxBillingRange = 5 * 366 * 24 * time.Hour
+
+// LineItem a recurring (or one time) cost and price of the domain
+// Cost is what the domain costs us, Price what we charge the owner, both in Currency and per Period.
+// The first charge happens at Start (the domain creation when unset), the last one before End (when set)
+type LineItem struct {
+	Kind        string  `json:"kind"`
+	Description string  `json:"description"`
+	Cost        float64 `json:"cost"`
+	Price       float64 `json:"price"`
+	Currency    string  `json:"currency"`
+	Period      string  `json:"period"`
+	Start       int64   `json:"start"`
+	End         int64   `json:"end"`
+}
+
+// billingCurrency returns the currency of the line items derived from the domain data
+func billingCurrency() string {
+	currency := strings.ToUpper(viper.GetString("billing.currency"))
+	if currency == "" {
+		currency = "EUR"
+	}
+	return currency
+}
+
+// charges returns the number of times the line item is charged in the [from, to) period
+func (self *LineItem) charges(anchor time.Time, from time.Time, to time.Time) int {
+	if self.Start > 0 {
+		anchor = time.Unix(self.Start, 0)
+	}
+	end := to
+	if self.End > 0 && time.Unix(self.End, 0).Before(end) {
+		end = time.Unix(self.End, 0)
+	}
+	if self.Period == PeriodOnce {
+		if !anchor.Before(from) && anchor.Before(end) {
+			return 1
+		}
+		return 0
+	}
+	months := periodMonths[self.Period]
+	if months == 0 {
+		return 0
+	}
+	count := 0
+	// skips the charges before the period, starting a few periods early since months differ in length
+	k := 0
+	if elapsed := int(from.Sub(anchor).Hours() / 24 / 31); elapsed > months {
+		k = elapsed/months - 1
+	}
+	for ; ; k++ {
+		charge := anchor.AddDate(0, k*months, 0)
+		if !charge.Before(end) {
+			break
+		}
+		if !charge.Before(from) {
+			count++
+		}
+	}
+	return count
+}
+
+// billingItems returns the line items of the domain, adding the items derived from its data when no item of the same kind is set:
+// the hosting package price (monthly) and the registration renewal cost (yearly, charged at the expiry date)
+// Charges of cancelled and expired domains stop when they entered that status
+func billingItems(domain *Domain, pkgs map[primitive.ObjectID]packages.Package) []LineItem {
+	items := append([]LineItem{}, domain.Billing...)
+	kinds := map[string]bool{}
+	for _, item := range items {
+		kinds[item.Kind] = true
+	}
+	if pkg, ok := pkgs[domain.PackageId]; ok && !kinds[LineHosting] && (pkg.MonthlyPrice > 0 || pkg.YearlyPrice > 0) {
+		item := LineItem{Kind: LineHosting, Description: pkg.Name, Price: pkg.MonthlyPrice, Currency: billingCurrency(), Period: PeriodMonthly}
+		if pkg.MonthlyPrice == 0 {
+			item.Price, item.Period = pkg.YearlyPrice, PeriodYearly
+		}
+		items = append(items, item)
+	}
+	if domain.RenewalCost > 0 && !kinds[LineRegistration] {
+		item := LineItem{Kind: LineRegistration, Description: "Renewal", Cost: domain.RenewalCost, Currency: billingCurrency(), Period: PeriodYearly}
+		if domain.ExpiresAt > 0 {
+			item.Start = domain.ExpiresAt
+		}
+		items = append(items, item)
+	}
+	if status := lifecycleStatus(domain.Lifecycle.Status); status == LifecycleCancelled || status == LifecycleExpired {
+		if domain.Lifecycle.ChangedAt == 0 {
+			return []LineItem{}
+		}
+		for i := range items {
+			if items[i].End == 0 || items[i].End > domain.Lifecycle.ChangedAt {
+				items[i].End = domain.Lifecycle.ChangedAt
+			}
+		}
+	}
+	return items
+}
+
+// BillingLine a line item of the projected invoice
+type BillingLine struct {
+	DomainId primitive.ObjectID
+	Domain   string
+	Item     LineItem
+	Charges  int
+	Cost     float64
+	Price    float64
+}
+
+// BillingTotal the totals of a projected invoice in a currency
+type BillingTotal struct {
+	Currency string
+	Cost     float64
+	Price    float64
+}
+
+// BillingInvoice the projected invoice of an owner for a period
+type BillingInvoice struct {
+	OwnerId primitive.ObjectID
+	Owner   string
+	From    time.Time
+	To      time.Time
+	Lines   []BillingLine
+	Totals  []BillingTotal
+}
+
+// parseBillingPeriod reads the [from, to) period of a billing report, YYYY-MM-DD dates, the current month by default
+func parseBillingPeriod(fromText string, toText string) (time.Time, time.Time, error) {
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	var err error
+	if fromText != "" {
+		if from, err = time.Parse("2006-01-02", fromText); err != nil {
+			return from, to, fmt.Errorf("Invalid from date %s, expected YYYY-MM-DD", fromText)
+		}
+		if toText == "" {
+			to = from.AddDate(0, 1, 0)
+		}
+	}
+	if toText != "" {
+		if to, err = time.Parse("2006-01-02", toText); err != nil {
+			return from, to, fmt.Errorf("Invalid to date %s, expected YYYY-MM-DD", toText)
+		}
+	}
+	if !to.After(from) {
+		return from, to, fmt.Errorf("The to date must follow the from date")
+	}
+	if to.Sub(from) > maxBillingRange {
+		return from, to, fmt.Errorf("The billing period cannot exceed 5 years")
+	}
+	return from, to, nil
+}
+
+// projectInvoices computes the projected invoices of the owners of the given domains for the [from, to) period
+// Invoices are sorted by owner name, their lines by domain, amounts are totalled per currency (no conversion)
+func projectInvoices(domains *[]Domain, from time.Time, to time.Time) ([]BillingInvoice, error) {
+	pkgs := map[primitive.ObjectID]packages.Package{}
+	all, err := new(packages.PackageService).All()
+	if err != nil {
+		return nil, err
+	}
+	for _, pkg := range *all {
+		pkgs[pkg.ID] = pkg
+	}
+
+	names := newExportNames()
+	invoices := map[primitive.ObjectID]*BillingInvoice{}
+	for _, domain := range *domains {
+		for _, item := range billingItems(&domain, pkgs) {
+			charges := item.charges(time.Unix(domain.Created, 0), from, to)
+			if charges == 0 {
+				continue
+			}
+			invoice, ok := invoices[domain.OwnerId]
+			if !ok {
+				invoice = &BillingInvoice{OwnerId: domain.OwnerId, Owner: names.contact(domain.OwnerId), From: from, To: to, Lines: []BillingLine{}}
+				invoices[domain.OwnerId] = invoice
+			}
+			invoice.Lines = append(invoice.Lines, BillingLine{
+				DomainId: domain.ID,
+				Domain:   domain.Name,
+				Item:     item,
+				Charges:  charges,
+				Cost:     float64(charges) * item.Cost,
+				Price:    float64(charges) * item.Price,
+			})
+		}
+	}
+
+	res := []BillingInvoice{}
+	for _, invoice := range invoices {
+		totals := map[string]*BillingTotal{}
+		for _, line := range invoice.Lines {
+			total, ok := totals[line.Item.Currency]
+			if !ok {
+				total = &BillingTotal{Currency: line.Item.Currency}
+				totals[line.Item.Currency] = total
+			}
+			total.Cost += line.Cost
+			total.Price += line.Price
+		}
+		for _, total := range totals {
+			invoice.Totals = append(invoice.Totals, *total)
+		}
+		sort.Slice(invoice.Totals, func(i, j int) bool { return invoice.Totals[i].Currency < invoice.Totals[j].Currency })
+		sort.SliceStable(invoice.Lines, func(i, j int) bool { return invoice.Lines[i].Domain < invoice.Lines[j].Domain })
+		res = append(res, *invoice)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Owner < res[j].Owner })
+	return res, nil
+}
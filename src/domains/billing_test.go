@@ -0,0 +1,41 @@
+package domains
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"systems-management-api/packages"
+	"testing"
+	"time"
+)
+
+func TestBillingItemsCancelled(t *testing.T) {
+	created := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	cancelled := time.Date(2024, 6, 20, 0, 0, 0, 0, time.UTC)
+	domain := Domain{
+		Name:    "example.com",
+		Created: created.Unix(),
+		Billing: []LineItem{{Kind: LineHosting, Price: 10, Currency: "EUR", Period: PeriodMonthly}},
+	}
+	pkgs := map[primitive.ObjectID]packages.Package{}
+
+	items := billingItems(&domain, pkgs)
+	if charges := items[0].charges(created, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)); charges != 12 {
+		t.Fatalf("expected 12 charges for an active domain, got %d", charges)
+	}
+
+	domain.Lifecycle = LifecycleInfo{Status: LifecycleCancelled, ChangedAt: cancelled.Unix()}
+	items = billingItems(&domain, pkgs)
+	if charges := items[0].charges(created, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)); charges != 6 {
+		t.Fatalf("expected the charges to stop at the cancellation, got %d", charges)
+	}
+	if charges := items[0].charges(created, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)); charges != 0 {
+		t.Fatalf("expected no charge after the cancellation, got %d", charges)
+	}
+	if domain.Billing[0].End != 0 {
+		t.Fatal("the domain line items must not be changed")
+	}
+
+	domain.Lifecycle = LifecycleInfo{Status: LifecycleExpired}
+	if items := billingItems(&domain, pkgs); len(items) != 0 {
+		t.Fatalf("expected no item for an expired domain without transition date, got %v", items)
+	}
+}
@@ -0,0 +1,235 @@
+package domains
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"reflect"
+	"systems-management-api/core/utils"
+	"time"
+)
+
+// Bulk item statuses, in dry run mode they tell what would happen
+const (
+	BulkUpdated   = "updated"
+	BulkDeleted   = "deleted"
+	BulkUnchanged = "unchanged"
+	BulkNotFound  = "not-found"
+	BulkFailed    = "failed"
+)
+
+// bulkField a domain field which can be set by a bulk operation, named as in the json documents
+type bulkField struct {
+	name string
+	key  string // bson key
+	get  func(domain *Domain) interface{}
+}
+
+// bulkFields the domain fields which can be set by a bulk operation
+var bulkFields = []bulkField{
+	{"ownerId", "ownerid", func(domain *Domain) interface{} { return domain.OwnerId }},
+	{"registrantId", "registrantid", func(domain *Domain) interface{} { return domain.RegistrantId }},
+	{"packageId", "packageid", func(domain *Domain) interface{} { return domain.PackageId }},
+	{"serverId", "serverid", func(domain *Domain) interface{} { return domain.ServerId }},
+	{"mx", "mx", func(domain *Domain) interface{} { return domain.Mx }},
+	{"registrar", "registrar", func(domain *Domain) interface{} { return domain.Registrar }},
+	{"autoRenew", "autorenew", func(domain *Domain) interface{} { return domain.AutoRenew }},
+	{"renewalCost", "renewalcost", func(domain *Domain) interface{} { return domain.RenewalCost }},
+}
+
+// BulkOperation the changes applied to a selection of domains: the domains matching the filter, restricted to Ids when set
+// Set holds the new values of the fields by name (a nil ID unsets the reference), tags are added, then removed
+// Delete moves the domains to the trash and excludes the other changes
+type BulkOperation struct {
+	Ids        []primitive.ObjectID
+	Filter     DomainFilter
+	Set        map[string]interface{}
+	AddTags    []string
+	RemoveTags []string
+	Delete     bool
+}
+
+// BulkItemResult the outcome of a bulk operation on a domain
+type BulkItemResult struct {
+	DomainId primitive.ObjectID
+	Name     string
+	Status   string
+	Changes  []string // names of the changed fields
+	Error    string
+}
+
+// BulkReport the outcome of a bulk operation, with the number of domains per status
+type BulkReport struct {
+	DryRun    bool
+	Matched   int
+	Updated   int
+	Deleted   int
+	Unchanged int
+	NotFound  int
+	Failed    int
+	Items     []BulkItemResult
+}
+
+// BulkTooLargeError error returned when the selection of a bulk operation exceeds domains.bulk.maxItems
+type BulkTooLargeError struct {
+	Count int
+	Max   int
+}
+
+func (self *BulkTooLargeError) Error() string {
+	return fmt.Sprintf("The selection matches %d domains, more than the %d allowed in a bulk operation", self.Count, self.Max)
+}
+
+// maxBulkItems returns the maximum number of domains changed by a bulk operation
+func maxBulkItems() int {
+	max := viper.GetInt("domains.bulk.maxItems")
+	if max <= 0 {
+		max = 5000
+	}
+	return max
+}
+
+// changes returns the names of the fields the operation changes on the domain and the corresponding update
+// A nil update means the domain is not changed
+func (self *BulkOperation) changes(domain *Domain, now int64) ([]string, bson.M) {
+	if self.Delete {
+		return []string{"deletedAt"}, bson.M{"$set": bson.M{"deletedat": now}}
+	}
+	changes := []string{}
+	set := bson.M{}
+	unset := bson.M{}
+	for _, field := range bulkFields {
+		value, ok := self.Set[field.name]
+		if !ok || reflect.DeepEqual(field.get(domain), value) {
+			continue
+		}
+		changes = append(changes, field.name)
+		if id, ok := value.(primitive.ObjectID); ok && id.IsZero() {
+			unset[field.key] = ""
+		} else {
+			set[field.key] = value
+		}
+	}
+	if len(self.AddTags) > 0 || len(self.RemoveTags) > 0 {
+		tags := []string{}
+		for _, tag := range normalizeTags(append(append([]string{}, domain.Tags...), self.AddTags...)) {
+			if !utils.Contains(self.RemoveTags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		if !reflect.DeepEqual(nonNilStrings(domain.Tags), tags) {
+			changes = append(changes, "tags")
+			set["tags"] = tags
+		}
+	}
+	if len(changes) == 0 {
+		return changes, nil
+	}
+	set["updated"] = now
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return changes, update
+}
+
+// RunBulk applies the operation to the selected domains with a single unordered bulk write, trashed domains excluded
+// Selected IDs which are not found (or do not match the filter) are reported, failed writes do not stop the others
+// With dryRun nothing is written and the report tells what would be changed
+func RunBulk(operation *BulkOperation, dryRun bool) (*BulkReport, error) {
+	query := operation.Filter.query()
+	if len(operation.Ids) > 0 {
+		query = bson.M{"$and": []bson.M{query, {"_id": bson.M{"$in": operation.Ids}}}}
+	}
+	domainService := new(DomainService)
+	domains, err := domainService.find(query)
+	if err != nil {
+		return nil, err
+	}
+	report, models, items, err := planBulk(operation, domains, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	if !dryRun && len(models) > 0 {
+		_, err := domainService.bulkWrite(models)
+		var bulkErr mongo.BulkWriteException
+		if errors.As(err, &bulkErr) {
+			for _, writeErr := range bulkErr.WriteErrors {
+				report.Items[items[writeErr.Index]].Status = BulkFailed
+				report.Items[items[writeErr.Index]].Error = writeErr.Message
+			}
+		} else if err != nil {
+			return nil, err
+		}
+		zap.S().Infow("Bulk operation applied", "matched", report.Matched, "writes", len(models))
+	}
+	report.count()
+	return report, nil
+}
+
+// planBulk computes the report and the write models of the operation on the candidate domains, read with the filter query
+// Candidates not matching the in-memory filter conditions (i.e. cidr) are not selected
+// Returns the report, the write models and the index of the report item of each write model
+func planBulk(operation *BulkOperation, candidates *[]Domain, dryRun bool) (*BulkReport, []mongo.WriteModel, []int, error) {
+	domains := []Domain{}
+	for _, domain := range *candidates {
+		if operation.Filter.match(&domain) {
+			domains = append(domains, domain)
+		}
+	}
+	if len(domains) > maxBulkItems() {
+		return nil, nil, nil, &BulkTooLargeError{Count: len(domains), Max: maxBulkItems()}
+	}
+
+	report := &BulkReport{DryRun: dryRun, Matched: len(domains), Items: []BulkItemResult{}}
+	now := time.Now().Unix()
+	models := []mongo.WriteModel{}
+	items := []int{} // index of the report item of each write model
+	found := map[primitive.ObjectID]bool{}
+	for _, domain := range domains {
+		found[domain.ID] = true
+		changes, update := operation.changes(&domain, now)
+		item := BulkItemResult{DomainId: domain.ID, Name: domain.Name, Status: BulkUnchanged, Changes: changes}
+		if update != nil {
+			item.Status = BulkUpdated
+			if operation.Delete {
+				item.Status = BulkDeleted
+			}
+			models = append(models, mongo.NewUpdateOneModel().SetFilter(bson.M{"$and": []bson.M{notTrashed, {"_id": domain.ID}}}).SetUpdate(update))
+			items = append(items, len(report.Items))
+		}
+		report.Items = append(report.Items, item)
+	}
+	for _, id := range operation.Ids {
+		if !found[id] {
+			found[id] = true
+			report.Items = append(report.Items, BulkItemResult{DomainId: id, Status: BulkNotFound, Changes: []string{}})
+		}
+	}
+	report.count()
+	return report, models, items, nil
+}
+
+// count sets the number of domains per status from the items
+func (self *BulkReport) count() {
+	self.Updated, self.Deleted, self.Unchanged, self.NotFound, self.Failed = 0, 0, 0, 0, 0
+	for _, item := range self.Items {
+		switch item.Status {
+		case BulkUpdated:
+			self.Updated++
+		case BulkDeleted:
+			self.Deleted++
+		case BulkUnchanged:
+			self.Unchanged++
+		case BulkNotFound:
+			self.NotFound++
+		case BulkFailed:
+			self.Failed++
+		}
+	}
+}
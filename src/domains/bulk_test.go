@@ -0,0 +1,56 @@
+package domains
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+)
+
+func TestPlanBulkCidrDryRun(t *testing.T) {
+	inside := Domain{ID: primitive.NewObjectID(), Name: "inside.example", Addresses: []Address{{Ip: "10.1.2.3"}}}
+	outside := Domain{ID: primitive.NewObjectID(), Name: "outside.example", Addresses: []Address{{Ip: "192.168.1.1"}}}
+	none := Domain{ID: primitive.NewObjectID(), Name: "none.example"}
+	candidates := []Domain{inside, outside, none}
+
+	operation := BulkOperation{Filter: DomainFilter{Cidr: "10.0.0.0/8"}, Delete: true}
+	if operation.Filter.empty() {
+		t.Fatal("a cidr filter must not be considered empty")
+	}
+	report, models, _, err := planBulk(&operation, &candidates, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Matched != 1 || report.Deleted != 1 || len(models) != 1 {
+		t.Fatalf("expected only the domain in the network to be deleted, got matched %d, deleted %d, writes %d", report.Matched, report.Deleted, len(models))
+	}
+	if len(report.Items) != 1 || report.Items[0].DomainId != inside.ID || report.Items[0].Status != BulkDeleted {
+		t.Fatalf("unexpected items %+v", report.Items)
+	}
+	if !report.DryRun {
+		t.Fatal("expected a dry run report")
+	}
+}
+
+func TestPlanBulkIdsNotFound(t *testing.T) {
+	domain := Domain{ID: primitive.NewObjectID(), Name: "a.example", Tags: []string{"old"}}
+	missing := primitive.NewObjectID()
+	candidates := []Domain{domain}
+
+	operation := BulkOperation{Ids: []primitive.ObjectID{domain.ID, missing}, AddTags: []string{"new"}, RemoveTags: []string{"old"}}
+	report, models, items, err := planBulk(&operation, &candidates, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Updated != 1 || report.NotFound != 1 || len(models) != 1 || items[0] != 0 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if changes := report.Items[0].Changes; len(changes) != 1 || changes[0] != "tags" {
+		t.Fatalf("unexpected changes %v", changes)
+	}
+}
+
+func TestFilterEmpty(t *testing.T) {
+	filter := NewDomainFilter()
+	if !filter.empty() {
+		t.Fatal("a new filter must be empty")
+	}
+}
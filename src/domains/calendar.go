@@ -0,0 +1,86 @@
+package domains
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"sort"
+	"systems-management-api/core/ical"
+	"time"
+)
+
+// Calendar event kinds
+const (
+	CalendarExpiry      = "expiry"
+	CalendarRenewal     = "renewal"
+	CalendarCertificate = "certificate"
+)
+
+// CalendarEvents all the calendar event kinds
+var CalendarEvents = []string{CalendarExpiry, CalendarRenewal, CalendarCertificate}
+
+// calendarDays returns how many days ahead the calendar feed covers by default
+func calendarDays() int {
+	days := viper.GetInt("calendar.days")
+	if days <= 0 {
+		days = 365
+	}
+	return days
+}
+
+// calendarRenewalDays returns how many days before the expiry date the renewal deadline falls
+func calendarRenewalDays() int {
+	days := viper.GetInt("calendar.renewalDays")
+	if days <= 0 {
+		days = 30
+	}
+	return days
+}
+
+// calendarEvents returns the events of the given kinds of the domains falling in the [from, to) period, sorted by date
+// Cancelled domains have no events, the fields hidden by the policy are not used
+func calendarEvents(domains *[]Domain, kinds []string, policy FieldPolicy, from time.Time, to time.Time) []ical.Event {
+	wanted := map[string]bool{}
+	for _, kind := range kinds {
+		wanted[kind] = true
+	}
+	events := []ical.Event{}
+	add := func(domain *Domain, kind string, date time.Time, summary string, description string) {
+		if !wanted[kind] || date.Before(from) || !date.Before(to) {
+			return
+		}
+		events = append(events, ical.Event{
+			Uid:         fmt.Sprintf("%s-%s@systems-management-api", domain.ID.Hex(), kind),
+			Date:        date,
+			Summary:     summary,
+			Description: description,
+			Categories:  append([]string{kind}, domain.Tags...),
+		})
+	}
+
+	for i := range *domains {
+		domain := &(*domains)[i]
+		if lifecycleStatus(domain.Lifecycle.Status) == LifecycleCancelled {
+			continue
+		}
+		if domain.ExpiresAt > 0 && policy.CanRead("expiresAt") {
+			expiry := calendarDate(domain.ExpiresAt)
+			details := fmt.Sprintf("Registrar: %s\nAuto renew: %t", domain.Registrar, domain.AutoRenew)
+			add(domain, CalendarExpiry, expiry, fmt.Sprintf("Domain %s expires", domain.UnicodeName), details)
+			add(domain, CalendarRenewal, expiry.AddDate(0, 0, -calendarRenewalDays()),
+				fmt.Sprintf("Renew domain %s (expires on %s)", domain.UnicodeName, expiry.Format("2006-01-02")), details)
+		}
+		if domain.Certificate.NotAfter > 0 && policy.CanRead("certificate") {
+			add(domain, CalendarCertificate, calendarDate(domain.Certificate.NotAfter),
+				fmt.Sprintf("Certificate of %s expires", domain.UnicodeName),
+				fmt.Sprintf("Subject: %s\nIssuer: %s", domain.Certificate.Subject, domain.Certificate.Issuer))
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Date.Before(events[j].Date) })
+	return events
+}
+
+// calendarDate returns the day (UTC) of a unix timestamp
+func calendarDate(timestamp int64) time.Time {
+	date := time.Unix(timestamp, 0).UTC()
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,68 @@
+package domains
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendarEvents(t *testing.T) {
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC)
+	domain := func(name string, expires time.Time, status string) Domain {
+		return Domain{
+			ID:          primitive.NewObjectID(),
+			Name:        name,
+			UnicodeName: name,
+			ExpiresAt:   expires.Unix(),
+			Certificate: CertificateInfo{NotAfter: expires.AddDate(0, 0, -10).Unix()},
+			Lifecycle:   LifecycleInfo{Status: status},
+		}
+	}
+	domains := []Domain{
+		domain("inside.com", time.Date(2030, 6, 15, 12, 0, 0, 0, time.UTC), ""),
+		domain("first-day.com", from, LifecycleExpired),
+		domain("last-day.com", to, LifecycleActive),
+		domain("before.com", time.Date(2029, 12, 31, 23, 0, 0, 0, time.UTC), LifecycleActive),
+		domain("cancelled.com", time.Date(2030, 6, 15, 0, 0, 0, 0, time.UTC), LifecycleCancelled),
+	}
+
+	events := calendarEvents(&domains, []string{CalendarExpiry}, FieldPolicy{}, from, to)
+	names := []string{}
+	for _, event := range events {
+		names = append(names, strings.TrimSuffix(strings.TrimPrefix(event.Summary, "Domain "), " expires"))
+	}
+	if strings.Join(names, " ") != "first-day.com inside.com" {
+		t.Fatalf("expected the expiries in [from, to) of the domains not cancelled, got %v", names)
+	}
+	if !events[1].Date.Equal(time.Date(2030, 6, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the events to fall on the expiry day, got %v", events[1].Date)
+	}
+
+	events = calendarEvents(&domains, CalendarEvents, FieldPolicy{}, from, to)
+	kinds := map[string]int{}
+	for i, event := range events {
+		kinds[event.Categories[0]]++
+		if i > 0 && event.Date.Before(events[i-1].Date) {
+			t.Fatal("expected the events to be sorted by date")
+		}
+	}
+	// the renewal and certificate of last-day.com fall in the period, unlike the ones of first-day.com
+	if kinds[CalendarExpiry] != 2 || kinds[CalendarRenewal] != 2 || kinds[CalendarCertificate] != 2 {
+		t.Fatalf("unexpected events: %v", kinds)
+	}
+
+	hidden := FieldPolicy{Hidden: []string{"expiresAt"}}
+	for _, event := range calendarEvents(&domains, CalendarEvents, hidden, from, to) {
+		if event.Categories[0] != CalendarCertificate {
+			t.Fatalf("expected no event using the hidden expiry date, got %v", event)
+		}
+	}
+	hidden = FieldPolicy{Hidden: []string{"certificate"}}
+	for _, event := range calendarEvents(&domains, CalendarEvents, hidden, from, to) {
+		if event.Categories[0] == CalendarCertificate {
+			t.Fatalf("expected no event using the hidden certificate, got %v", event)
+		}
+	}
+}
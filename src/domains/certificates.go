@@ -0,0 +1,152 @@
+package domains
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"math"
+	"sort"
+	"systems-management-api/core/notifications"
+	"systems-management-api/core/tlscheck"
+	"time"
+)
+
+// certificateReminderDays returns the thresholds (days before expiry) at which certificate reminders are sent, sorted descending
+func certificateReminderDays() []int {
+	days := viper.GetIntSlice("tls.reminderDays")
+	if len(days) == 0 {
+		days = []int{30, 14, 7, 1}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(days)))
+	return days
+}
+
+// certificateCheckInterval returns how often the certificates of all the domains are checked
+func certificateCheckInterval() time.Duration {
+	hours := viper.GetInt("tls.check.intervalHours")
+	if hours <= 0 {
+		hours = 12
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// CheckCertificate connects to the domain HTTPS endpoint, stores the presented certificate and
+// sends a reminder if the certificate is going to expire
+func CheckCertificate(domain *Domain, checker *tlscheck.Checker) error {
+	serverName := domain.Tls.ServerName
+	if serverName == "" {
+		serverName = domain.Name
+	}
+	cert, err := checker.Check(tlscheck.Target{ServerName: serverName, Ip: domain.Tls.Ip, Port: domain.Tls.Port})
+	if err != nil {
+		zap.S().Infow("TLS check failed", "domain", domain.Name, "error", err)
+	}
+	info := certificateInfo(domain, cert, err, time.Now().Unix())
+	if err == nil {
+		info.Reminders = sendCertificateReminder(domain, &info)
+	}
+
+	domainService := new(DomainService)
+	return domainService.SetCertificate(domain, info)
+}
+
+// certificateInfo returns the certificate info of the domain after a check
+// A failed check keeps the last known certificate and its reminders, so that a transient error doesn't reset them
+func certificateInfo(domain *Domain, cert *tlscheck.Certificate, err error, now int64) CertificateInfo {
+	if err != nil {
+		info := domain.Certificate
+		info.Error = err.Error()
+		info.CheckedAt = now
+		if info.Sans == nil {
+			info.Sans = []string{}
+		}
+		if info.Reminders == nil {
+			info.Reminders = []int{}
+		}
+		return info
+	}
+
+	info := CertificateInfo{
+		Subject:    cert.Subject,
+		Issuer:     cert.Issuer,
+		Sans:       cert.Sans,
+		NotBefore:  cert.NotBefore,
+		NotAfter:   cert.NotAfter,
+		ChainError: cert.ChainError,
+		CheckedAt:  now,
+		Reminders:  []int{},
+	}
+	if info.Sans == nil {
+		info.Sans = []string{}
+	}
+	// reminders are sent again when the certificate is renewed
+	if cert.NotAfter == domain.Certificate.NotAfter && domain.Certificate.Reminders != nil {
+		info.Reminders = domain.Certificate.Reminders
+	}
+	return info
+}
+
+// sendCertificateReminder notifies the certificate expiry if a reminder is due
+// Returns the updated list of notified thresholds
+func sendCertificateReminder(domain *Domain, info *CertificateInfo) []int {
+	daysLeft := int(math.Ceil(float64(info.NotAfter-time.Now().Unix()) / 86400))
+	thresholds := certificateReminderDays()
+	threshold, due := dueExpiryReminder(daysLeft, thresholds, info.Reminders)
+	if !due {
+		return info.Reminders
+	}
+
+	subject := fmt.Sprintf("Certificate of %s expires in %d days", domain.UnicodeName, daysLeft)
+	if daysLeft <= 0 {
+		subject = fmt.Sprintf("Certificate of %s is expired", domain.UnicodeName)
+	}
+	err := notifications.Send(notifications.Notification{
+		Event:   "certificate.expiry",
+		Subject: subject,
+		Body: fmt.Sprintf(
+			"The certificate presented by %s (issuer: %s) expires on %s.",
+			domain.UnicodeName,
+			info.Issuer,
+			time.Unix(info.NotAfter, 0).Format("2006-01-02"),
+		),
+		Data: map[string]interface{}{
+			"id":        domain.ID.Hex(),
+			"name":      domain.Name,
+			"notAfter":  info.NotAfter,
+			"daysLeft":  daysLeft,
+			"threshold": threshold,
+		},
+	})
+	if err != nil {
+		return info.Reminders
+	}
+
+	reminders := []int{}
+	for _, t := range thresholds {
+		if t >= threshold {
+			reminders = append(reminders, t)
+		}
+	}
+	return reminders
+}
+
+// checkAllCertificates checks the certificates of all the domains
+func checkAllCertificates() {
+	domainService := new(DomainService)
+	domains, err := domainService.all()
+	if err != nil {
+		zap.S().Error("Error while getting all domains, Reason: ", err)
+		return
+	}
+
+	checker, err := tlscheck.NewChecker()
+	if err != nil {
+		zap.S().Error("Error while creating TLS checker, Reason: ", err)
+		return
+	}
+	for i := range *domains {
+		if err := CheckCertificate(&(*domains)[i], checker); err != nil {
+			zap.S().Errorw("Error while checking domain certificate", "domain", (*domains)[i].Name, "error", err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package domains
+
+import (
+	"errors"
+	"systems-management-api/core/tlscheck"
+	"testing"
+)
+
+func TestCertificateInfoKeptOnError(t *testing.T) {
+	domain := Domain{Name: "example.com", Certificate: CertificateInfo{
+		Subject:   "CN=example.com",
+		Issuer:    "CN=Test CA",
+		Sans:      []string{"example.com"},
+		NotBefore: 1000,
+		NotAfter:  2000,
+		CheckedAt: 1500,
+		Reminders: []int{30, 14},
+	}}
+
+	info := certificateInfo(&domain, nil, errors.New("connection refused"), 1600)
+	if info.Error != "connection refused" || info.CheckedAt != 1600 {
+		t.Fatalf("unexpected error and check time %q %d", info.Error, info.CheckedAt)
+	}
+	if info.Subject != "CN=example.com" || info.Issuer != "CN=Test CA" || info.NotAfter != 2000 || len(info.Sans) != 1 {
+		t.Fatalf("the last known certificate must be kept, got %+v", info)
+	}
+	if len(info.Reminders) != 2 {
+		t.Fatalf("the reminders must be kept, got %v", info.Reminders)
+	}
+
+	// the next successful check of the same certificate doesn't send the reminders again
+	domain.Certificate = info
+	info = certificateInfo(&domain, &tlscheck.Certificate{Subject: "CN=example.com", NotBefore: 1000, NotAfter: 2000}, nil, 1700)
+	if info.Error != "" || len(info.Reminders) != 2 {
+		t.Fatalf("unexpected info after recovery %+v", info)
+	}
+}
+
+func TestCertificateInfoRenewed(t *testing.T) {
+	domain := Domain{Name: "example.com", Certificate: CertificateInfo{NotAfter: 2000, Reminders: []int{30, 14}, Error: "timeout"}}
+
+	info := certificateInfo(&domain, &tlscheck.Certificate{Subject: "CN=example.com", NotBefore: 1900, NotAfter: 5000}, nil, 1950)
+	if len(info.Reminders) != 0 {
+		t.Fatalf("the reminders must be reset when the certificate is renewed, got %v", info.Reminders)
+	}
+	if info.Error != "" || info.NotAfter != 5000 || info.Sans == nil {
+		t.Fatalf("unexpected info %+v", info)
+	}
+}
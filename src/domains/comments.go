@@ -0,0 +1,158 @@
+package domains
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	database "systems-management-api/core/database"
+	"time"
+)
+
+// legacyNotesAuthor the author of the comments created from the legacy domain notes
+const legacyNotesAuthor = "notes"
+
+// Comment a comment of the domain timeline, whose markdown body can be edited only by its author
+// Legacy is set on the comment holding the notes of the domain, as they were before comments
+type Comment struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty"`
+	DomainId primitive.ObjectID `json:"domainId"`
+	AuthorId primitive.ObjectID `json:"authorId"`
+	Author   string             `json:"author"`
+	Body     string             `json:"body"`
+	Pinned   bool               `json:"pinned"`
+	Legacy   bool               `json:"legacy"`
+	History  []CommentRevision  `json:"history"`
+	Created  int64              `json:"created"`
+	Updated  int64              `json:"updated"`
+}
+
+// CommentRevision a previous body of an edited comment
+type CommentRevision struct {
+	Body     string `json:"body"`
+	EditedAt int64  `json:"editedAt"`
+}
+
+// IsAuthor tells whether the comment was written by the given user
+func (self *Comment) IsAuthor(userId primitive.ObjectID) bool {
+	return !self.AuthorId.IsZero() && self.AuthorId == userId
+}
+
+// Edit replaces the comment body, keeping the previous one in the history
+func (self *Comment) Edit(body string) {
+	if body == self.Body {
+		return
+	}
+	self.History = append(self.History, CommentRevision{Body: self.Body, EditedAt: self.Updated})
+	self.Body = body
+	self.Updated = time.Now().Unix()
+}
+
+// ensureCommentIndexes creates the comments collection indexes
+func ensureCommentIndexes() {
+	db := database.DB()
+	err := db.EnsureIndexes("comment", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "domainid", Value: 1}, {Key: "created", Value: 1}}, Options: options.Index().SetName("domain_created")},
+	})
+	if err != nil {
+		zap.S().Error("Error creating comment indexes: ", err)
+	}
+}
+
+// CommentService service which provides methods to access and modify the domains comments
+type CommentService struct{}
+
+// Retrieves the comments of the domain, pinned comments first then by creation time
+func (service *CommentService) all(domain *Domain) (*[]Comment, error) {
+	db := database.DB()
+	collection := db.D.Collection("comment")
+	opts := options.Find().SetSort(bson.D{{Key: "pinned", Value: -1}, {Key: "created", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := collection.Find(context.TODO(), bson.M{"domainid": domain.ID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	comments := []Comment{}
+	if err := cursor.All(context.TODO(), &comments); err != nil {
+		return nil, err
+	}
+	return &comments, nil
+}
+
+// Retrieves a comment of the domain given its ID
+func (service *CommentService) GetById(domain *Domain, id string) (*Comment, error) {
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	db := database.DB()
+	collection := db.D.Collection("comment")
+	comment := Comment{}
+
+	if err := collection.FindOne(context.TODO(), bson.M{"_id": objId, "domainid": domain.ID}).Decode(&comment); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// Saves the comment to database
+// Returns boolean result and error
+func (service *CommentService) Save(comment *Comment) (bool, error) {
+	db := database.DB()
+	collection := db.D.Collection("comment")
+
+	if comment.ID.IsZero() {
+		res, err := collection.InsertOne(context.TODO(), comment)
+		if err != nil {
+			zap.S().Error("Error inserting comment: ", err)
+			return false, err
+		}
+		comment.ID = res.InsertedID.(primitive.ObjectID)
+	} else {
+		if _, err := collection.ReplaceOne(context.TODO(), bson.M{"_id": comment.ID}, comment); err != nil {
+			zap.S().Error("Error updating comment: ", err)
+			return false, err
+		}
+	}
+	zap.S().Info(fmt.Sprintf("Comment %s of domain %s saved succesfully", comment.ID.Hex(), comment.DomainId.Hex()))
+	return true, nil
+}
+
+// Deletes the comment from database
+// Returns boolean result and error
+func (service *CommentService) Delete(comment *Comment) (bool, error) {
+	db := database.DB()
+	collection := db.D.Collection("comment")
+
+	if _, err := collection.DeleteOne(context.TODO(), bson.M{"_id": comment.ID}); err != nil {
+		zap.S().Error("Error deleting comment: ", err)
+		return false, err
+	}
+	zap.S().Info(fmt.Sprintf("Comment %s of domain %s deleted succesfully", comment.ID.Hex(), comment.DomainId.Hex()))
+	return true, nil
+}
+
+// Deletes all the comments of the given domains
+// Returns the number of deleted comments and error
+func (service *CommentService) DeleteByDomains(ids []primitive.ObjectID) (int64, error) {
+	db := database.DB()
+	collection := db.D.Collection("comment")
+
+	res, err := collection.DeleteMany(context.TODO(), bson.M{"domainid": bson.M{"$in": ids}})
+	if err != nil {
+		zap.S().Error("Error deleting comments: ", err)
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// hasLegacy tells whether the legacy notes of the domain were already copied to a comment
+func (service *CommentService) hasLegacy(domainId primitive.ObjectID) (bool, error) {
+	db := database.DB()
+	collection := db.D.Collection("comment")
+
+	count, err := collection.CountDocuments(context.TODO(), bson.M{"domainid": domainId, "legacy": true})
+	return count > 0, err
+}
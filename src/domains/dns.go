@@ -0,0 +1,127 @@
+package domains
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"sort"
+	"strings"
+	"systems-management-api/core/dnscheck"
+	"systems-management-api/core/utils"
+	"time"
+)
+
+// dnsMismatches compares the resolved records with the stored domain configuration:
+// - A/AAAA records with the web addresses (addresses with web or no purpose)
+// - MX records, when mx is enabled, with the mail addresses (if any)
+// - NS records with the nameservers published by the registry (if known)
+func dnsMismatches(domain *Domain, records *dnscheck.Records, mxIps map[string][]string) []string {
+	mismatches := []string{}
+
+	expected := []string{}
+	mail := []string{}
+	for _, address := range domain.Addresses {
+		switch address.Purpose {
+		case "", "web":
+			expected = append(expected, address.Ip)
+		case "mail":
+			mail = append(mail, address.Ip)
+		}
+	}
+	resolved := append(append([]string{}, records.A...), records.AAAA...)
+	for _, ip := range expected {
+		if !utils.Contains(resolved, ip) {
+			mismatches = append(mismatches, fmt.Sprintf("address %s is not resolved by DNS", ip))
+		}
+	}
+	if len(expected) > 0 {
+		for _, ip := range resolved {
+			if !utils.Contains(expected, ip) {
+				mismatches = append(mismatches, fmt.Sprintf("DNS resolves to unexpected address %s", ip))
+			}
+		}
+	}
+
+	if domain.Mx {
+		if len(records.Mx) == 0 {
+			mismatches = append(mismatches, "mx is enabled but no MX record is published")
+		} else if len(mail) > 0 {
+			found := false
+			for _, mx := range records.Mx {
+				for _, ip := range mxIps[mx.Host] {
+					if utils.Contains(mail, ip) {
+						found = true
+					}
+				}
+			}
+			if !found {
+				mismatches = append(mismatches, "no MX record points to the domain mail addresses")
+			}
+		}
+	}
+
+	if len(domain.Registry.Nameservers) > 0 {
+		stored := append([]string{}, domain.Registry.Nameservers...)
+		sort.Strings(stored)
+		if strings.Join(stored, ",") != strings.Join(records.Ns, ",") {
+			mismatches = append(mismatches, fmt.Sprintf(
+				"NS records (%s) differ from the registry nameservers (%s)",
+				strings.Join(records.Ns, ", "),
+				strings.Join(stored, ", "),
+			))
+		}
+	}
+
+	return mismatches
+}
+
+// CheckDns resolves the domain records, compares them with the stored configuration and stores the result
+func CheckDns(domain *Domain, checker *dnscheck.Checker) error {
+	info := DnsInfo{CheckedAt: time.Now().Unix(), A: []string{}, AAAA: []string{}, Mx: []MxInfo{}, Ns: []string{}, Mismatches: []string{}}
+	records, err := checker.Lookup(domain.Name)
+	if err != nil {
+		zap.S().Infow("DNS lookup failed", "domain", domain.Name, "error", err)
+		info.Error = err.Error()
+	} else {
+		mxIps := map[string][]string{}
+		for _, mx := range records.Mx {
+			if ips, err := checker.LookupIPs(mx.Host); err == nil {
+				mxIps[mx.Host] = ips
+			}
+			info.Mx = append(info.Mx, MxInfo{Host: mx.Host, Pref: int(mx.Pref)})
+		}
+		info.A = records.A
+		info.AAAA = records.AAAA
+		info.Ns = records.Ns
+		info.Mismatches = dnsMismatches(domain, records, mxIps)
+	}
+
+	domainService := new(DomainService)
+	return domainService.SetDns(domain, info)
+}
+
+// dnsCheckInterval returns how often the DNS records of all the domains are checked
+func dnsCheckInterval() time.Duration {
+	hours := viper.GetInt("dns.check.intervalHours")
+	if hours <= 0 {
+		hours = 6
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// checkAllDns checks the DNS records of all the domains
+func checkAllDns() {
+	domainService := new(DomainService)
+	domains, err := domainService.all()
+	if err != nil {
+		zap.S().Error("Error while getting all domains, Reason: ", err)
+		return
+	}
+
+	checker := dnscheck.NewChecker()
+	for i := range *domains {
+		if err := CheckDns(&(*domains)[i], checker); err != nil {
+			zap.S().Errorw("Error while checking domain DNS records", "domain", (*domains)[i].Name, "error", err)
+		}
+	}
+}
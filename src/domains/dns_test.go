@@ -0,0 +1,68 @@
+package domains
+
+import (
+	"systems-management-api/core/dnscheck"
+	"testing"
+)
+
+func TestDnsMismatchesNone(t *testing.T) {
+	domain := Domain{
+		Name:      "example.com",
+		Mx:        true,
+		Addresses: []Address{{Ip: "192.0.2.1"}, {Ip: "2001:db8::1", Purpose: "web"}, {Ip: "192.0.2.25", Purpose: "mail"}},
+		Registry:  RegistryInfo{Nameservers: []string{"ns2.example.com", "ns1.example.com"}},
+	}
+	records := dnscheck.Records{
+		A:    []string{"192.0.2.1"},
+		AAAA: []string{"2001:db8::1"},
+		Mx:   []dnscheck.MxRecord{{Host: "mail.example.com", Pref: 10}},
+		Ns:   []string{"ns1.example.com", "ns2.example.com"},
+	}
+	mxIps := map[string][]string{"mail.example.com": {"192.0.2.25"}}
+
+	if mismatches := dnsMismatches(&domain, &records, mxIps); len(mismatches) != 0 {
+		t.Fatalf("expected no mismatch, got %v", mismatches)
+	}
+}
+
+func TestDnsMismatches(t *testing.T) {
+	domain := Domain{
+		Name:      "example.com",
+		Mx:        true,
+		Addresses: []Address{{Ip: "192.0.2.1"}, {Ip: "192.0.2.25", Purpose: "mail"}},
+		Registry:  RegistryInfo{Nameservers: []string{"ns1.example.com"}},
+	}
+	records := dnscheck.Records{
+		A:  []string{"198.51.100.1"},
+		Mx: []dnscheck.MxRecord{{Host: "mx.provider.net", Pref: 10}},
+		Ns: []string{"ns1.other.net"},
+	}
+	mxIps := map[string][]string{"mx.provider.net": {"198.51.100.25"}}
+
+	expected := []string{
+		"address 192.0.2.1 is not resolved by DNS",
+		"DNS resolves to unexpected address 198.51.100.1",
+		"no MX record points to the domain mail addresses",
+		"NS records (ns1.other.net) differ from the registry nameservers (ns1.example.com)",
+	}
+	mismatches := dnsMismatches(&domain, &records, mxIps)
+	if len(mismatches) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, mismatches)
+	}
+	for i := range expected {
+		if mismatches[i] != expected[i] {
+			t.Fatalf("expected %q, got %q", expected[i], mismatches[i])
+		}
+	}
+}
+
+func TestDnsMismatchesMissingMx(t *testing.T) {
+	// without addresses nor registry nameservers only the MX records are compared
+	domain := Domain{Name: "example.com", Mx: true}
+	records := dnscheck.Records{A: []string{"192.0.2.1"}, Ns: []string{"ns1.example.com"}}
+
+	mismatches := dnsMismatches(&domain, &records, map[string][]string{})
+	if len(mismatches) != 1 || mismatches[0] != "mx is enabled but no MX record is published" {
+		t.Fatalf("unexpected mismatches %v", mismatches)
+	}
+}
@@ -0,0 +1,216 @@
+package domains
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"systems-management-api/contacts"
+	"systems-management-api/core/export"
+	"systems-management-api/customfields"
+	"systems-management-api/packages"
+	"systems-management-api/servers"
+	"time"
+)
+
+// exportColumns the columns of the domains export, the login info is exported only on privileged request
+// The custom fields columns (field.<name>) are added by domainExportColumns
+var exportColumns = []export.Column{
+	{Name: "id"},
+	{Name: "name"},
+	{Name: "unicodeName"},
+	{Name: "ownerId"},
+	{Name: "owner"},
+	{Name: "registrantId"},
+	{Name: "registrant"},
+	{Name: "packageId"},
+	{Name: "package"},
+	{Name: "mx"},
+	{Name: "addresses"},
+	{Name: "serverId"},
+	{Name: "server"},
+	{Name: "registrar"},
+	{Name: "registeredAt"},
+	{Name: "expiresAt"},
+	{Name: "autoRenew"},
+	{Name: "renewalCost"},
+	{Name: "health"},
+	{Name: "lifecycle"},
+	{Name: "tags"},
+	{Name: "notes"},
+	{Name: "created"},
+	{Name: "updated"},
+	{Name: "loginInfo", Privileged: true},
+}
+
+// exportColumnFields the domain fields of the columns which are not named after one, such as the referenced names
+var exportColumnFields = map[string]string{
+	"owner":      "ownerId",
+	"registrant": "registrantId",
+	"package":    "packageId",
+	"server":     "serverId",
+}
+
+// domainExportColumns returns the columns of the domains export, including a column per custom field
+// Columns hidden by the field policy are not available
+func domainExportColumns(policy FieldPolicy) ([]export.Column, error) {
+	columns := visibleExportColumns(policy)
+	if !policy.CanRead("customFields") {
+		return columns, nil
+	}
+	fieldService := new(customfields.FieldService)
+	fields, err := fieldService.All()
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range *fields {
+		columns = append(columns, export.Column{Name: "field." + field.Name})
+	}
+	return columns, nil
+}
+
+// visibleExportColumns returns the export columns whose field is not hidden by the field policy
+func visibleExportColumns(policy FieldPolicy) []export.Column {
+	columns := []export.Column{}
+	for _, column := range exportColumns {
+		field, ok := exportColumnFields[column.Name]
+		if !ok {
+			field = column.Name
+		}
+		if policy.CanRead(field) {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// exportNames resolves the names of the referenced contacts, packages and servers, caching them during an export
+type exportNames struct {
+	names map[primitive.ObjectID]string
+}
+
+func newExportNames() *exportNames {
+	return &exportNames{names: make(map[primitive.ObjectID]string)}
+}
+
+// get returns the cached name of the referenced item, looking it up the first time
+func (self *exportNames) get(id primitive.ObjectID, lookup func(id string) (string, error)) string {
+	if id.IsZero() {
+		return ""
+	}
+	if name, ok := self.names[id]; ok {
+		return name
+	}
+	name, err := lookup(id.Hex())
+	if err != nil {
+		name = ""
+	}
+	self.names[id] = name
+	return name
+}
+
+func (self *exportNames) contact(id primitive.ObjectID) string {
+	return self.get(id, func(id string) (string, error) {
+		contact, err := new(contacts.ContactService).GetById(id)
+		if err != nil {
+			return "", err
+		}
+		return contact.Name, nil
+	})
+}
+
+func (self *exportNames) pkg(id primitive.ObjectID) string {
+	return self.get(id, func(id string) (string, error) {
+		pkg, err := new(packages.PackageService).GetById(id)
+		if err != nil {
+			return "", err
+		}
+		return pkg.Name, nil
+	})
+}
+
+func (self *exportNames) server(id primitive.ObjectID) string {
+	return self.get(id, func(id string) (string, error) {
+		server, err := new(servers.ServerService).GetById(id)
+		if err != nil {
+			return "", err
+		}
+		return server.Hostname, nil
+	})
+}
+
+// exportTime returns the time of a unix timestamp, zero timestamps meaning no date
+func exportTime(timestamp int64) time.Time {
+	if timestamp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(timestamp, 0)
+}
+
+// exportValue returns the value of a column of the domains export
+// Addresses are formatted as in the import (ip=purpose, semicolon separated)
+func exportValue(domain *Domain, column string, names *exportNames) interface{} {
+	switch column {
+	case "id":
+		return domain.ID.Hex()
+	case "name":
+		return domain.Name
+	case "unicodeName":
+		return domain.UnicodeName
+	case "ownerId":
+		return hexOrEmpty(domain.OwnerId)
+	case "owner":
+		return names.contact(domain.OwnerId)
+	case "registrantId":
+		return hexOrEmpty(domain.RegistrantId)
+	case "registrant":
+		return names.contact(domain.RegistrantId)
+	case "packageId":
+		return hexOrEmpty(domain.PackageId)
+	case "package":
+		return names.pkg(domain.PackageId)
+	case "mx":
+		return domain.Mx
+	case "addresses":
+		addresses := []string{}
+		for _, address := range domain.Addresses {
+			if address.Purpose != "" {
+				addresses = append(addresses, fmt.Sprintf("%s=%s", address.Ip, address.Purpose))
+			} else {
+				addresses = append(addresses, address.Ip)
+			}
+		}
+		return strings.Join(addresses, ";")
+	case "serverId":
+		return hexOrEmpty(domain.ServerId)
+	case "server":
+		return names.server(domain.ServerId)
+	case "registrar":
+		return domain.Registrar
+	case "registeredAt":
+		return exportTime(domain.RegisteredAt)
+	case "expiresAt":
+		return exportTime(domain.ExpiresAt)
+	case "autoRenew":
+		return domain.AutoRenew
+	case "renewalCost":
+		return domain.RenewalCost
+	case "lifecycle":
+		return lifecycleStatus(domain.Lifecycle.Status)
+	case "health":
+		return healthStatus(domain.Health.Status)
+	case "notes":
+		return domain.Notes
+	case "created":
+		return exportTime(domain.Created)
+	case "updated":
+		return exportTime(domain.Updated)
+	case "tags":
+		return strings.Join(domain.Tags, ";")
+	case "loginInfo":
+		return domain.LoginInfo
+	}
+	if strings.HasPrefix(column, "field.") {
+		return domain.CustomFields[strings.TrimPrefix(column, "field.")]
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package domains
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"net"
+	"strings"
+	"systems-management-api/customfields"
+	"time"
+)
+
+// DomainFilter list query parameters used to filter domains
+type DomainFilter struct {
+	Ip             string                 `form:"ip" binding:"omitempty,ip"`
+	Cidr           string                 `form:"cidr" binding:"omitempty,cidr"`
+	Server         string                 `form:"server" binding:"omitempty,len=24,hexadecimal"`
+	Owner          string                 `form:"owner" binding:"omitempty,len=24,hexadecimal"`
+	Registrant     string                 `form:"registrant" binding:"omitempty,len=24,hexadecimal"`
+	Contact        string                 `form:"contact" binding:"omitempty,len=24,hexadecimal"`
+	ExpiringWithin int                    `form:"expiringWithin" binding:"omitempty,min=1"` // days
+	DnsDrift       bool                   `form:"dnsDrift"`
+	Lifecycle      string                 `form:"lifecycle" binding:"omitempty,oneof=pending active transfer-in transfer-out expired cancelled"`
+	Tag            string                 `form:"tag"` // comma separated, all the tags are required
+	Fields         map[string]interface{} `form:"-"`   // custom fields values, from the field.<name> parameters
+}
+
+// NewDomainFilter returns an empty filter, which matches all the domains
+func NewDomainFilter() DomainFilter {
+	return DomainFilter{}
+}
+
+// Bind reads the filter from the request query string
+func (self *DomainFilter) Bind(c *gin.Context) error {
+	if err := c.ShouldBindQuery(self); err != nil {
+		zap.S().Debug("Domain Filter Validation Error: ", err)
+		return err
+	}
+	self.Fields = make(map[string]interface{})
+	for key, values := range c.Request.URL.Query() {
+		if !strings.HasPrefix(key, "field.") {
+			continue
+		}
+		fieldService := new(customfields.FieldService)
+		field, err := fieldService.GetByName(strings.TrimPrefix(key, "field."))
+		if err != nil {
+			return fmt.Errorf("Unknown custom field %s", strings.TrimPrefix(key, "field."))
+		}
+		if self.Fields[field.Name], err = field.Parse(values[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// empty tells whether the filter matches all the domains
+func (self *DomainFilter) empty() bool {
+	return self.Ip == "" && self.Cidr == "" && self.Server == "" && self.Owner == "" && self.Registrant == "" &&
+		self.Contact == "" && self.ExpiringWithin == 0 && !self.DnsDrift && self.Lifecycle == "" && self.Tag == "" &&
+		len(self.Fields) == 0
+}
+
+// query returns the mongo query matching the filter, trashed domains excluded
+func (self *DomainFilter) query() bson.M {
+	conditions := []bson.M{notTrashed}
+	if self.Ip != "" {
+		conditions = append(conditions, bson.M{"addresses.ip": net.ParseIP(self.Ip).String()})
+	}
+	if self.Server != "" {
+		serverId, _ := primitive.ObjectIDFromHex(self.Server)
+		conditions = append(conditions, serverQuery(serverId))
+	}
+	if self.Owner != "" {
+		ownerId, _ := primitive.ObjectIDFromHex(self.Owner)
+		conditions = append(conditions, bson.M{"ownerid": ownerId})
+	}
+	if self.Registrant != "" {
+		registrantId, _ := primitive.ObjectIDFromHex(self.Registrant)
+		conditions = append(conditions, bson.M{"registrantid": registrantId})
+	}
+	if self.Contact != "" {
+		contactId, _ := primitive.ObjectIDFromHex(self.Contact)
+		conditions = append(conditions, contactQuery(contactId))
+	}
+	if self.ExpiringWithin > 0 {
+		now := time.Now()
+		conditions = append(conditions, bson.M{"expiresat": bson.M{
+			"$gte": now.Unix(),
+			"$lte": now.AddDate(0, 0, self.ExpiringWithin).Unix(),
+		}})
+	}
+	if self.DnsDrift {
+		conditions = append(conditions, bson.M{"$or": []bson.M{
+			{"dns.mismatches.0": bson.M{"$exists": true}},
+			{"dns.error": bson.M{"$gt": ""}},
+		}})
+	}
+	if self.Lifecycle == LifecycleActive {
+		conditions = append(conditions, bson.M{"lifecycle.status": bson.M{"$in": []interface{}{nil, "", LifecycleActive}}})
+	} else if self.Lifecycle != "" {
+		conditions = append(conditions, bson.M{"lifecycle.status": self.Lifecycle})
+	}
+	if tags := normalizeTags(strings.Split(self.Tag, ",")); len(tags) > 0 {
+		conditions = append(conditions, bson.M{"tags": bson.M{"$all": tags}})
+	}
+	for name, value := range self.Fields {
+		conditions = append(conditions, bson.M{"customfields." + name: value})
+	}
+	return bson.M{"$and": conditions}
+}
+
+// match applies the conditions which cannot be expressed as a mongo query
+func (self *DomainFilter) match(domain *Domain) bool {
+	if self.Cidr != "" {
+		_, network, err := net.ParseCIDR(self.Cidr)
+		if err != nil || !domain.HasAddressIn(network) {
+			return false
+		}
+	}
+	return true
+}
+
+// serverQuery matches the domains referencing the given server, directly or through one of their addresses
+func serverQuery(serverId primitive.ObjectID) bson.M {
+	return bson.M{"$or": []bson.M{{"serverid": serverId}, {"addresses.serverid": serverId}}}
+}
+
+// contactQuery matches the domains owned or registered by the given contact
+func contactQuery(contactId primitive.ObjectID) bson.M {
+	return bson.M{"$or": []bson.M{{"ownerid": contactId}, {"registrantid": contactId}}}
+}
@@ -0,0 +1,256 @@
+package domains
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"sync"
+	database "systems-management-api/core/database"
+	"systems-management-api/core/healthcheck"
+	"systems-management-api/core/notifications"
+	"time"
+)
+
+// healthRetention returns how long health check results are kept
+func healthRetention() time.Duration {
+	days := viper.GetInt("health.retentionDays")
+	if days <= 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// ensureHealthIndexes creates the indexes of the health check results
+// The expiry of the TTL index follows health.retentionDays, an existing index is changed in place when the setting changes
+func ensureHealthIndexes() {
+	db := database.DB()
+	expireAfter := int32(healthRetention().Seconds())
+	err := db.EnsureIndexes("health_check", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "domainid", Value: 1}, {Key: "checkedat", Value: -1}}, Options: options.Index().SetName("domain_checkedat")},
+	})
+	if err != nil {
+		zap.S().Error("Error creating health check indexes: ", err)
+	}
+
+	current, found, err := healthTTL()
+	if err != nil {
+		zap.S().Error("Error reading health check indexes: ", err)
+		return
+	}
+	if !found {
+		err = db.EnsureIndexes("health_check", []mongo.IndexModel{
+			{Keys: bson.D{{Key: "checkedat", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(expireAfter).SetName("ttl_checkedat")},
+		})
+	} else if current != expireAfter {
+		err = db.D.RunCommand(context.TODO(), bson.D{
+			{Key: "collMod", Value: "health_check"},
+			{Key: "index", Value: bson.M{"name": "ttl_checkedat", "expireAfterSeconds": expireAfter}},
+		}).Err()
+		if err == nil {
+			zap.S().Infow("Health check results retention changed", "from", current, "to", expireAfter)
+		}
+	}
+	if err != nil {
+		zap.S().Error("Error updating health check retention index: ", err)
+	}
+}
+
+// healthTTL returns the expiry (in seconds) of the TTL index of the health check results, if it exists
+func healthTTL() (int32, bool, error) {
+	db := database.DB()
+	cursor, err := db.D.Collection("health_check").Indexes().List(context.TODO())
+	if err != nil {
+		return 0, false, err
+	}
+	defer cursor.Close(context.TODO())
+	for cursor.Next(context.TODO()) {
+		var index struct {
+			Name        string `bson:"name"`
+			ExpireAfter int64  `bson:"expireAfterSeconds"`
+		}
+		if err := cursor.Decode(&index); err != nil {
+			return 0, false, err
+		}
+		if index.Name == "ttl_checkedat" {
+			return int32(index.ExpireAfter), true, nil
+		}
+	}
+	return 0, false, cursor.Err()
+}
+
+// healthTickInterval returns how often the due health checks are looked for
+func healthTickInterval() time.Duration {
+	seconds := viper.GetInt("health.tickSeconds")
+	if seconds <= 0 {
+		seconds = 30
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// healthWorkers returns the number of health checks run concurrently
+func healthWorkers() int {
+	workers := viper.GetInt("health.workers")
+	if workers <= 0 {
+		workers = 5
+	}
+	return workers
+}
+
+// healthInterval returns how often the domain health is checked
+func healthInterval(domain *Domain) time.Duration {
+	seconds := domain.HealthCheck.IntervalSeconds
+	if seconds <= 0 {
+		seconds = viper.GetInt("health.intervalSeconds")
+	}
+	if seconds <= 0 {
+		seconds = 300
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// healthTimeout returns how long the domain health check waits for the response
+func healthTimeout(domain *Domain) time.Duration {
+	seconds := domain.HealthCheck.TimeoutSeconds
+	if seconds <= 0 {
+		seconds = 10
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// healthCheckDue reports whether the interval since the last health check of the domain is elapsed
+func healthCheckDue(domain *Domain, now time.Time) bool {
+	if domain.Health.CheckedAt == 0 {
+		return true
+	}
+	return !now.Before(time.Unix(domain.Health.CheckedAt, 0).Add(healthInterval(domain)))
+}
+
+// CheckHealth runs the health check of the domain, storing the result and the new health state
+// A notification is sent when the state changes between up and down
+func CheckHealth(domain *Domain) error {
+	result := healthcheck.Run(healthcheck.Probe{
+		Url:             domain.HealthCheckUrl(),
+		ExpectedStatus:  domain.HealthCheck.ExpectedStatus,
+		ExpectedContent: domain.HealthCheck.ExpectedContent,
+		Timeout:         healthTimeout(domain),
+	})
+	now := time.Now()
+
+	domainService := new(DomainService)
+	err := domainService.AddHealthResult(&HealthResult{
+		DomainId:  domain.ID,
+		CheckedAt: now,
+		Up:        result.Up,
+		Status:    result.Status,
+		LatencyMs: result.LatencyMs,
+		Error:     result.Error,
+	})
+	if err != nil {
+		return err
+	}
+
+	state := HealthState{
+		Status:    HealthDown,
+		ChangedAt: domain.Health.ChangedAt,
+		CheckedAt: now.Unix(),
+		LatencyMs: result.LatencyMs,
+		Error:     result.Error,
+	}
+	if result.Up {
+		state.Status = HealthUp
+	}
+	previous := healthStatus(domain.Health.Status)
+	if state.Status != previous {
+		state.ChangedAt = now.Unix()
+	}
+	if err := domainService.SetHealth(domain, state); err != nil {
+		return err
+	}
+	// the first check of a domain sets its state without notifying
+	if previous != HealthUnknown && state.Status != previous {
+		sendHealthNotification(domain)
+	}
+	return nil
+}
+
+// sendHealthNotification notifies the health state change of the domain
+func sendHealthNotification(domain *Domain) {
+	subject := fmt.Sprintf("Domain %s is up", domain.UnicodeName)
+	body := fmt.Sprintf("The website of the domain %s (%s) is up again.", domain.UnicodeName, domain.HealthCheckUrl())
+	if domain.Health.Status == HealthDown {
+		subject = fmt.Sprintf("Domain %s is down", domain.UnicodeName)
+		body = fmt.Sprintf("The website of the domain %s (%s) is down: %s", domain.UnicodeName, domain.HealthCheckUrl(), domain.Health.Error)
+	}
+	notifications.Send(notifications.Notification{
+		Event:   "domain." + domain.Health.Status,
+		Subject: subject,
+		Body:    body,
+		Data: map[string]interface{}{
+			"id":        domain.ID.Hex(),
+			"name":      domain.Name,
+			"url":       domain.HealthCheckUrl(),
+			"status":    domain.Health.Status,
+			"changedAt": domain.Health.ChangedAt,
+			"error":     domain.Health.Error,
+		},
+	})
+}
+
+// checkDueHealth runs the due health checks using a pool of workers
+func checkDueHealth() {
+	domainService := new(DomainService)
+	domains, err := domainService.withHealthCheck()
+	if err != nil {
+		zap.S().Error("Error while getting domains health checks, Reason: ", err)
+		return
+	}
+
+	now := time.Now()
+	queue := make(chan *Domain)
+	var wg sync.WaitGroup
+	for i := 0; i < healthWorkers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for domain := range queue {
+				if err := CheckHealth(domain); err != nil {
+					zap.S().Errorw("Error while checking domain health", "domain", domain.Name, "error", err)
+				}
+			}
+		}()
+	}
+	for i := range *domains {
+		if healthCheckDue(&(*domains)[i], now) {
+			queue <- &(*domains)[i]
+		}
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// uptimeSummaries returns the uptime of the domain over the last day, week and month
+func uptimeSummaries(domain *Domain) ([]UptimeSummary, error) {
+	domainService := new(DomainService)
+	now := time.Now()
+	periods := []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"24h", 24 * time.Hour},
+		{"7d", 7 * 24 * time.Hour},
+		{"30d", 30 * 24 * time.Hour},
+	}
+	summaries := []UptimeSummary{}
+	for _, period := range periods {
+		summary, err := domainService.uptime(domain, period.name, now.Add(-period.duration))
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
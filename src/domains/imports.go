@@ -0,0 +1,304 @@
+package domains
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/mongo"
+	"io"
+	"strconv"
+	"strings"
+	"systems-management-api/customfields"
+	"time"
+)
+
+// Import actions, as reported for each row
+const (
+	ImportCreate = "create"
+	ImportUpdate = "update"
+	ImportError  = "error"
+)
+
+// maxImportSize the maximum size of an imported file
+const maxImportSize = 10 << 20
+
+// importAsyncThreshold returns the number of rows above which an import runs in background
+func importAsyncThreshold() int {
+	threshold := viper.GetInt("domains.import.asyncThreshold")
+	if threshold <= 0 {
+		threshold = 100
+	}
+	return threshold
+}
+
+// importItem a row of an imported file, err is set when the row cannot be read
+type importItem struct {
+	row  int
+	data DomainValidatorData
+	err  error
+}
+
+// ImportRow the outcome of the import of a row
+type ImportRow struct {
+	Row    int
+	Name   string
+	Action string
+	Id     string
+	Error  string
+}
+
+// ImportReport the outcome of an import
+type ImportReport struct {
+	DryRun  bool
+	Total   int
+	Created int
+	Updated int
+	Failed  int
+	Rows    []ImportRow
+}
+
+// parseImportJSON reads the rows of a json array of domains, having the same fields as the create domain request
+func parseImportJSON(r io.Reader) ([]importItem, error) {
+	var rows []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("Invalid json, an array of domains is expected: %v", err)
+	}
+	items := make([]importItem, 0, len(rows))
+	for i, row := range rows {
+		item := importItem{row: i + 1}
+		item.err = json.Unmarshal(row, &item.data)
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// importColumns the columns of an imported CSV file, with the function setting each field of the domain data
+// The addresses column is a semicolon separated list of ips, each one optionally followed by =purpose,
+// the tags column a semicolon separated list of tags. Custom fields are set by the field.<name> columns
+var importColumns = map[string]func(data *DomainValidatorData, value string) error{
+	"name":         func(data *DomainValidatorData, value string) error { data.Name = value; return nil },
+	"ownerid":      func(data *DomainValidatorData, value string) error { data.OwnerId = value; return nil },
+	"registrantid": func(data *DomainValidatorData, value string) error { data.RegistrantId = value; return nil },
+	"logininfo":    func(data *DomainValidatorData, value string) error { data.LoginInfo = value; return nil },
+	"packageid":    func(data *DomainValidatorData, value string) error { data.PackageId = value; return nil },
+	"serverid":     func(data *DomainValidatorData, value string) error { data.ServerId = value; return nil },
+	"registrar":    func(data *DomainValidatorData, value string) error { data.Registrar = value; return nil },
+	"ip":           func(data *DomainValidatorData, value string) error { data.Ip = value; return nil },
+	"lifecycle":    func(data *DomainValidatorData, value string) error { data.Lifecycle = value; return nil },
+	"mx":           func(data *DomainValidatorData, value string) error { return parseImportBool(value, &data.Mx) },
+	"autorenew":    func(data *DomainValidatorData, value string) error { return parseImportBool(value, &data.AutoRenew) },
+	"registeredat": func(data *DomainValidatorData, value string) error { return parseImportDate(value, &data.RegisteredAt) },
+	"expiresat":    func(data *DomainValidatorData, value string) error { return parseImportDate(value, &data.ExpiresAt) },
+	"renewalcost": func(data *DomainValidatorData, value string) error {
+		if value == "" {
+			return nil
+		}
+		cost, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("Invalid renewal cost %s", value)
+		}
+		data.RenewalCost = cost
+		return nil
+	},
+	"tags": func(data *DomainValidatorData, value string) error {
+		for _, tag := range strings.Split(value, ";") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				data.Tags = append(data.Tags, tag)
+			}
+		}
+		return nil
+	},
+	"addresses": func(data *DomainValidatorData, value string) error {
+		for _, address := range strings.Split(value, ";") {
+			address = strings.TrimSpace(address)
+			if address == "" {
+				continue
+			}
+			parts := strings.SplitN(address, "=", 2)
+			addressData := AddressValidatorData{Ip: parts[0]}
+			if len(parts) == 2 {
+				addressData.Purpose = parts[1]
+			}
+			data.Addresses = append(data.Addresses, addressData)
+		}
+		return nil
+	},
+}
+
+// fieldColumn returns the function setting the value of a custom field from a CSV column, if the field is defined
+func fieldColumn(name string) (func(data *DomainValidatorData, value string) error, bool) {
+	fieldService := new(customfields.FieldService)
+	field, err := fieldService.GetByName(name)
+	if err != nil {
+		return nil, false
+	}
+	return func(data *DomainValidatorData, value string) error {
+		if value == "" {
+			return nil
+		}
+		parsed, err := field.Parse(value)
+		if err != nil {
+			return err
+		}
+		if data.CustomFields == nil {
+			data.CustomFields = make(map[string]interface{})
+		}
+		data.CustomFields[field.Name] = parsed
+		return nil
+	}, true
+}
+
+// parseImportBool parses a boolean CSV field, empty meaning false
+func parseImportBool(value string, field *bool) error {
+	switch strings.ToLower(value) {
+	case "", "0", "false", "no", "n":
+		*field = false
+	case "1", "true", "yes", "y":
+		*field = true
+	default:
+		return fmt.Errorf("Invalid boolean %s", value)
+	}
+	return nil
+}
+
+// parseImportDate parses a date CSV field, as unix timestamp, YYYY-MM-DD date or RFC 3339 time (as exported), empty meaning no date
+func parseImportDate(value string, field *int64) error {
+	if value == "" {
+		return nil
+	}
+	if timestamp, err := strconv.ParseInt(value, 10, 64); err == nil {
+		*field = timestamp
+		return nil
+	}
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		*field = date.Unix()
+		return nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return fmt.Errorf("Invalid date %s, expected YYYY-MM-DD or unix timestamp", value)
+	}
+	*field = date.Unix()
+	return nil
+}
+
+// parseImportCSV reads the rows of a CSV file, whose header names the columns (see importColumns)
+func parseImportCSV(r io.Reader) ([]importItem, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid CSV, cannot read the header: %v", err)
+	}
+	setters := make([]func(data *DomainValidatorData, value string) error, len(header))
+	for i, column := range header {
+		column = strings.TrimSpace(column)
+		setter, ok := importColumns[strings.ToLower(column)]
+		if strings.HasPrefix(column, "field.") {
+			setter, ok = fieldColumn(strings.TrimPrefix(column, "field."))
+		}
+		if !ok {
+			return nil, fmt.Errorf("Invalid CSV, unknown column %s", column)
+		}
+		setters[i] = setter
+	}
+
+	items := []importItem{}
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		item := importItem{row: row}
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); !ok || len(record) == 0 {
+				return nil, fmt.Errorf("Invalid CSV: %v", err)
+			}
+			item.err = err
+		}
+		for i, value := range record {
+			if i >= len(setters) {
+				break
+			}
+			if err := setters[i](&item.data, strings.TrimSpace(value)); err != nil && item.err == nil {
+				item.err = err
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// runImport validates and, unless dry run, saves the imported domains, one row at a time
+// A domain whose name already exists is updated when upsert is set, otherwise the row fails
+// Rows writing a field denied by the policy of the importing user fail
+func runImport(items []importItem, dryRun bool, upsert bool, policy FieldPolicy, progress func(processed int)) ImportReport {
+	report := ImportReport{DryRun: dryRun, Total: len(items), Rows: []ImportRow{}}
+	domainService := new(DomainService)
+	seen := make(map[string]int)
+
+	for i, item := range items {
+		result := importRow(domainService, item, dryRun, upsert, policy, seen)
+		switch result.Action {
+		case ImportCreate:
+			report.Created++
+		case ImportUpdate:
+			report.Updated++
+		default:
+			report.Failed++
+		}
+		report.Rows = append(report.Rows, result)
+		progress(i + 1)
+	}
+	return report
+}
+
+// importRow imports a single row, seen maps the names already imported to their row
+func importRow(domainService *DomainService, item importItem, dryRun bool, upsert bool, policy FieldPolicy, seen map[string]int) ImportRow {
+	result := ImportRow{Row: item.row, Name: item.data.Name, Action: ImportError}
+	if item.err != nil {
+		result.Error = item.err.Error()
+		return result
+	}
+	name, _, err := NormalizeName(item.data.Name)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Name = name
+	if row, ok := seen[name]; ok {
+		result.Error = fmt.Sprintf("Domain %s already imported at row %d", name, row)
+		return result
+	}
+	seen[name] = item.row
+
+	domainValidator := DomainValidator{policy: policy}
+	existing, err := domainService.getByName(name)
+	switch {
+	case err == nil && existing.IsTrashed():
+		result.Error = fmt.Sprintf("Domain %s is in the trash, restore it first", name)
+		return result
+	case err == nil && !upsert:
+		result.Error = fmt.Sprintf("Domain %s already exists", name)
+		return result
+	case err == nil:
+		result.Action = ImportUpdate
+		err = domainValidator.BindDataUpdate(existing, item.data)
+	case err == mongo.ErrNoDocuments:
+		result.Action = ImportCreate
+		err = domainValidator.BindData(item.data)
+	}
+	if err == nil && !dryRun {
+		_, err = domainValidator.domain.Save()
+	}
+	if err != nil {
+		result.Action = ImportError
+		result.Error = err.Error()
+		return result
+	}
+	if !domainValidator.domain.ID.IsZero() {
+		result.Id = domainValidator.domain.ID.Hex()
+	}
+	return result
+}
@@ -0,0 +1,34 @@
+package domains
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestImportMultipartTooLarge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	part, err := form.CreateFormFile("file", "domains.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte("name\n"))
+	part.Write(bytes.Repeat([]byte("example.com\n"), maxImportSize/12+1))
+	form.Close()
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/import/domain", &buf)
+	c.Request.Header.Set("Content-Type", form.FormDataContentType())
+	importDomainsView(c)
+
+	if recorder.Code != http.StatusUnprocessableEntity || !strings.Contains(recorder.Body.String(), "too large") {
+		t.Fatalf("expected the upload to be rejected, got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,140 @@
+package domains
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"sort"
+	"systems-management-api/core/notifications"
+	"systems-management-api/core/scheduler"
+	"time"
+)
+
+// trashRetention returns how long trashed domains are kept before being purged
+func trashRetention() time.Duration {
+	days := viper.GetInt("domains.trash.retentionDays")
+	if days <= 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// purgeTrash permanently deletes the domains which stayed in the trash longer than the retention period
+func purgeTrash() {
+	domainService := new(DomainService)
+	count, err := domainService.PurgeTrashedBefore(time.Now().Add(-trashRetention()).Unix())
+	if err != nil {
+		zap.S().Error("Error while purging trashed domains, Reason: ", err)
+		return
+	}
+	if count > 0 {
+		zap.S().Infof("Purged %d trashed domains", count)
+	}
+}
+
+// expiryReminderDays returns the thresholds (days before expiry) at which reminders are sent, sorted descending
+func expiryReminderDays() []int {
+	days := viper.GetIntSlice("domains.expiry.reminderDays")
+	if len(days) == 0 {
+		days = []int{60, 30, 14, 7, 1}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(days)))
+	return days
+}
+
+// expiryCheckInterval returns how often the expiry reminders job runs
+func expiryCheckInterval() time.Duration {
+	hours := viper.GetInt("domains.expiry.checkIntervalHours")
+	if hours <= 0 {
+		hours = 24
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// dueExpiryReminder returns the threshold a reminder is due for, if any
+// The due threshold is the smallest one greater than or equal to the days left which has not been notified yet,
+// thresholds are ordered descending
+func dueExpiryReminder(daysLeft int, thresholds []int, sent []int) (int, bool) {
+	due, found := 0, false
+	for _, threshold := range thresholds {
+		if daysLeft <= threshold {
+			due, found = threshold, true
+		}
+	}
+	if !found {
+		return 0, false
+	}
+	for _, threshold := range sent {
+		if threshold == due {
+			return 0, false
+		}
+	}
+	return due, true
+}
+
+// sendExpiryReminders notifies the domains whose registration is going to expire
+func sendExpiryReminders() {
+	domainService := new(DomainService)
+	domains, err := domainService.withExpiry()
+	if err != nil {
+		zap.S().Error("Error while getting domains expiry, Reason: ", err)
+		return
+	}
+
+	now := time.Now()
+	thresholds := expiryReminderDays()
+	for _, domain := range *domains {
+		daysLeft, _ := domain.DaysToExpiry(now)
+		threshold, due := dueExpiryReminder(daysLeft, thresholds, domain.ExpiryReminders)
+		if !due || daysLeft < 0 {
+			continue
+		}
+		err := notifications.Send(notifications.Notification{
+			Event:   "domain.expiry",
+			Subject: fmt.Sprintf("Domain %s expires in %d days", domain.UnicodeName, daysLeft),
+			Body: fmt.Sprintf(
+				"The registration of the domain %s expires on %s (auto renew: %t, registrar: %s).",
+				domain.UnicodeName,
+				time.Unix(domain.ExpiresAt, 0).Format("2006-01-02"),
+				domain.AutoRenew,
+				domain.Registrar,
+			),
+			Data: map[string]interface{}{
+				"id":        domain.ID.Hex(),
+				"name":      domain.Name,
+				"expiresAt": domain.ExpiresAt,
+				"daysLeft":  daysLeft,
+				"threshold": threshold,
+			},
+		})
+		if err != nil {
+			continue
+		}
+		// thresholds greater than the notified one are marked as sent too, so that they are not sent late
+		reminders := []int{}
+		for _, t := range thresholds {
+			if t >= threshold {
+				reminders = append(reminders, t)
+			}
+		}
+		domainService.SetExpiryReminders(&domain, reminders)
+	}
+}
+
+// StartJobs starts the domains background jobs
+func StartJobs() {
+	scheduler.Every("domains.trash.purge", time.Hour, purgeTrash)
+	scheduler.Every("domains.expiry.reminders", expiryCheckInterval(), sendExpiryReminders)
+	if viper.GetBool("dns.check.enabled") {
+		scheduler.Every("domains.dns.check", dnsCheckInterval(), checkAllDns)
+	}
+	if viper.GetBool("tls.check.enabled") {
+		scheduler.Every("domains.tls.check", certificateCheckInterval(), checkAllCertificates)
+	}
+	if viper.GetBool("health.check.enabled") {
+		scheduler.Every("domains.health.check", healthTickInterval(), checkDueHealth)
+	}
+	if viper.GetBool("registration.sync.enabled") {
+		scheduler.Every("domains.registry.sync", registrySyncInterval(), syncRegistry)
+	}
+}
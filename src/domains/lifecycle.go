@@ -0,0 +1,136 @@
+package domains
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"strings"
+	"systems-management-api/core/notifications"
+	"systems-management-api/core/utils"
+	"time"
+)
+
+// Lifecycle statuses of a domain
+const (
+	LifecyclePending     = "pending"
+	LifecycleActive      = "active"
+	LifecycleTransferIn  = "transfer-in"
+	LifecycleTransferOut = "transfer-out"
+	LifecycleExpired     = "expired"
+	LifecycleCancelled   = "cancelled"
+)
+
+// LifecycleStatuses all the lifecycle statuses
+var LifecycleStatuses = []string{LifecyclePending, LifecycleActive, LifecycleTransferIn, LifecycleTransferOut, LifecycleExpired, LifecycleCancelled}
+
+// lifecycleTransitions the statuses a domain can move to from each status
+var lifecycleTransitions = map[string][]string{
+	LifecyclePending:     {LifecycleActive, LifecycleTransferIn, LifecycleCancelled},
+	LifecycleActive:      {LifecycleTransferOut, LifecycleExpired, LifecycleCancelled},
+	LifecycleTransferIn:  {LifecycleActive, LifecycleCancelled},
+	LifecycleTransferOut: {LifecycleActive, LifecycleCancelled},
+	LifecycleExpired:     {LifecycleActive, LifecycleCancelled},
+	LifecycleCancelled:   {LifecyclePending, LifecycleTransferIn},
+}
+
+// defaultLifecycleNotify the statuses notified when domains.lifecycle.notify is not set
+var defaultLifecycleNotify = []string{LifecycleTransferOut, LifecycleExpired, LifecycleCancelled}
+
+// LifecycleInfo the lifecycle status of the domain and the transitions which led to it
+type LifecycleInfo struct {
+	Status    string                `json:"status"`
+	ChangedAt int64                 `json:"changedAt"`
+	History   []LifecycleTransition `json:"history"`
+}
+
+// LifecycleTransition a change of the lifecycle status, with who made it, when and why
+type LifecycleTransition struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+	By     string `json:"by"`
+	At     int64  `json:"at"`
+}
+
+// lifecycleStatus returns the lifecycle status, domains stored before lifecycles are active
+func lifecycleStatus(status string) string {
+	if status == "" {
+		return LifecycleActive
+	}
+	return status
+}
+
+// NewLifecycle returns the lifecycle of a new domain, starting in the given status (active by default)
+func NewLifecycle(status string) LifecycleInfo {
+	return LifecycleInfo{Status: lifecycleStatus(status), ChangedAt: time.Now().Unix(), History: []LifecycleTransition{}}
+}
+
+// ErrLifecycleChanged error returned when the domain status changed while applying a transition
+var ErrLifecycleChanged = errors.New("The domain lifecycle status has changed meanwhile, reload the domain and retry")
+
+// LifecycleTransitionError error returned when a transition is not allowed by the lifecycle state machine
+type LifecycleTransitionError struct {
+	From string
+	To   string
+}
+
+func (self *LifecycleTransitionError) Error() string {
+	allowed := lifecycleTransitions[self.From]
+	if len(allowed) == 0 {
+		return fmt.Sprintf("Cannot move a domain from %s to %s", self.From, self.To)
+	}
+	return fmt.Sprintf("Cannot move a domain from %s to %s, allowed statuses: %s", self.From, self.To, strings.Join(allowed, ", "))
+}
+
+// Transition moves the domain to the given lifecycle status, checking that the transition is allowed
+// The transition is stored only if the domain status has not changed meanwhile, then notified if configured
+func Transition(domain *Domain, to string, reason string, by string) error {
+	from := lifecycleStatus(domain.Lifecycle.Status)
+	if !utils.Contains(lifecycleTransitions[from], to) {
+		return &LifecycleTransitionError{From: from, To: to}
+	}
+	transition := LifecycleTransition{From: from, To: to, Reason: reason, By: by, At: time.Now().Unix()}
+	domainService := new(DomainService)
+	if err := domainService.AddTransition(domain, transition); err != nil {
+		return err
+	}
+	zap.S().Infow("Domain lifecycle changed", "domain", domain.Name, "from", from, "to", to, "by", by)
+	if utils.Contains(lifecycleNotify(), to) {
+		sendLifecycleNotification(domain, transition)
+	}
+	return nil
+}
+
+// lifecycleNotify returns the statuses whose transitions are notified
+func lifecycleNotify() []string {
+	if viper.IsSet("domains.lifecycle.notify") {
+		return viper.GetStringSlice("domains.lifecycle.notify")
+	}
+	return defaultLifecycleNotify
+}
+
+// sendLifecycleNotification notifies the lifecycle transition of the domain
+func sendLifecycleNotification(domain *Domain, transition LifecycleTransition) {
+	body := fmt.Sprintf("The domain %s moved from %s to %s (by %s).", domain.UnicodeName, transition.From, transition.To, transition.By)
+	if transition.Reason != "" {
+		body += "\nReason: " + transition.Reason
+	}
+	err := notifications.Send(notifications.Notification{
+		Event:   "domain.lifecycle." + transition.To,
+		Subject: fmt.Sprintf("Domain %s is now %s", domain.UnicodeName, transition.To),
+		Body:    body,
+		Data: map[string]interface{}{
+			"id":     domain.ID.Hex(),
+			"name":   domain.Name,
+			"from":   transition.From,
+			"to":     transition.To,
+			"reason": transition.Reason,
+			"by":     transition.By,
+			"at":     transition.At,
+		},
+	})
+	if err != nil {
+		zap.S().Errorw("Error sending lifecycle notification", "domain", domain.Name, "error", err)
+	}
+}
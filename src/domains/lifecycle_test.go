@@ -0,0 +1,84 @@
+package domains
+
+import (
+	"errors"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"testing"
+)
+
+func TestTransition(t *testing.T) {
+	viper.Set("domains.lifecycle.notify", []string{})
+	defer viper.Set("domains.lifecycle.notify", nil)
+	filter := fakeUpdateDomain(t, &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1})
+
+	for from, allowed := range lifecycleTransitions {
+		for _, to := range LifecycleStatuses {
+			domain := Domain{ID: primitive.NewObjectID(), Name: "example.com", Lifecycle: LifecycleInfo{Status: from}}
+			err := Transition(&domain, to, "test", "tester")
+			valid := false
+			for _, status := range allowed {
+				valid = valid || status == to
+			}
+			if valid {
+				if err != nil {
+					t.Fatalf("expected %s to %s to be allowed, got %v", from, to, err)
+				}
+				if domain.Lifecycle.Status != to || len(domain.Lifecycle.History) != 1 || domain.Lifecycle.History[0].From != from {
+					t.Fatalf("expected the %s to %s transition to be recorded, got %+v", from, to, domain.Lifecycle)
+				}
+				if (*filter)["lifecycle.status"] != from {
+					t.Fatalf("expected the update to require the %s status, got %v", from, *filter)
+				}
+				continue
+			}
+			var forbidden *LifecycleTransitionError
+			if !errors.As(err, &forbidden) || forbidden.From != from || forbidden.To != to {
+				t.Fatalf("expected %s to %s to be refused, got %v", from, to, err)
+			}
+			if domain.Lifecycle.Status != from {
+				t.Fatalf("expected the refused transition not to change the status, got %s", domain.Lifecycle.Status)
+			}
+		}
+	}
+}
+
+func TestTransitionLegacyStatus(t *testing.T) {
+	viper.Set("domains.lifecycle.notify", []string{})
+	defer viper.Set("domains.lifecycle.notify", nil)
+	filter := fakeUpdateDomain(t, &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1})
+
+	domain := Domain{ID: primitive.NewObjectID(), Name: "example.com"}
+	if err := Transition(&domain, LifecycleExpired, "", "tester"); err != nil {
+		t.Fatalf("expected a domain without status to be active, got %v", err)
+	}
+	if domain.Lifecycle.History[0].From != LifecycleActive {
+		t.Fatalf("expected the transition to start from active, got %+v", domain.Lifecycle.History[0])
+	}
+	status, ok := (*filter)["lifecycle.status"].(bson.M)
+	if !ok || status["$in"] == nil {
+		t.Fatalf("expected the update to match a missing or empty status, got %v", *filter)
+	}
+
+	domain = Domain{ID: primitive.NewObjectID(), Name: "example.com"}
+	var forbidden *LifecycleTransitionError
+	if err := Transition(&domain, LifecycleTransferIn, "", "tester"); !errors.As(err, &forbidden) || forbidden.From != LifecycleActive {
+		t.Fatalf("expected the legacy status to follow the active transitions, got %v", err)
+	}
+}
+
+func TestTransitionChangedMeanwhile(t *testing.T) {
+	viper.Set("domains.lifecycle.notify", []string{})
+	defer viper.Set("domains.lifecycle.notify", nil)
+	fakeUpdateDomain(t, &mongo.UpdateResult{MatchedCount: 0, ModifiedCount: 0})
+
+	domain := Domain{ID: primitive.NewObjectID(), Name: "example.com", Lifecycle: LifecycleInfo{Status: LifecyclePending}}
+	if err := Transition(&domain, LifecycleActive, "", "tester"); err != ErrLifecycleChanged {
+		t.Fatalf("expected the concurrent change to be reported, got %v", err)
+	}
+	if domain.Lifecycle.Status != LifecyclePending || len(domain.Lifecycle.History) != 0 {
+		t.Fatalf("expected the domain to be left unchanged, got %+v", domain.Lifecycle)
+	}
+}
@@ -0,0 +1,439 @@
+package domains
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"net"
+	"strings"
+	"systems-management-api/contacts"
+	database "systems-management-api/core/database"
+	"systems-management-api/packages"
+	"systems-management-api/servers"
+	"time"
+)
+
+// legacyAddressDomain domain document as stored before multiple addresses support
+type legacyAddressDomain struct {
+	ID primitive.ObjectID `bson:"_id"`
+	Ip []byte             `bson:"ip"`
+}
+
+// migrateLegacyAddresses converts the single ip field of old documents into an addresses list
+func migrateLegacyAddresses() error {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+	cursor, err := collection.Find(context.TODO(), bson.M{"ip": bson.M{"$exists": true}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.TODO())
+
+	for cursor.Next(context.TODO()) {
+		var legacy legacyAddressDomain
+		if err := cursor.Decode(&legacy); err != nil {
+			return err
+		}
+		update := bson.M{"$unset": bson.M{"ip": ""}}
+		if ip := net.IP(legacy.Ip); len(legacy.Ip) > 0 && ip.To16() != nil {
+			update["$set"] = bson.M{"addresses": []Address{NewAddress(ip, "web", primitive.NilObjectID)}}
+		}
+		if _, err := collection.UpdateOne(context.TODO(), bson.M{"_id": legacy.ID}, update); err != nil {
+			return err
+		}
+		zap.S().Infow("Domain legacy ip migrated to addresses", "id", legacy.ID.Hex())
+	}
+	return cursor.Err()
+}
+
+// legacyServerDomain domain document as stored when servers were free text
+type legacyServerDomain struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	ServerName string             `bson:"servername"`
+	Addresses  []struct {
+		Server string `bson:"server"`
+	} `bson:"addresses"`
+}
+
+// legacyServerReference returns the ID of the server with the given name, creating it if it doesn't exist
+func legacyServerReference(name string) (primitive.ObjectID, error) {
+	hostname := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+	serverService := new(servers.ServerService)
+	if server, err := serverService.GetByHostname(hostname); err == nil {
+		return server.ID, nil
+	}
+	server := servers.Server{Hostname: hostname, Ips: []string{}, Created: time.Now().Unix(), Updated: time.Now().Unix()}
+	if _, err := server.Save(); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return server.ID, nil
+}
+
+// migrateLegacyServers converts the free text server names into server references
+// Servers are created from the names when they don't exist yet
+func migrateLegacyServers() error {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+	cursor, err := collection.Find(context.TODO(), bson.M{"$or": []bson.M{
+		{"servername": bson.M{"$exists": true}},
+		{"addresses.server": bson.M{"$exists": true}},
+	}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.TODO())
+
+	for cursor.Next(context.TODO()) {
+		var legacy legacyServerDomain
+		if err := cursor.Decode(&legacy); err != nil {
+			return err
+		}
+		set := bson.M{}
+		unset := bson.M{"servername": ""}
+		if strings.TrimSpace(legacy.ServerName) != "" {
+			serverId, err := legacyServerReference(legacy.ServerName)
+			if err != nil {
+				return err
+			}
+			set["serverid"] = serverId
+		}
+		for i, address := range legacy.Addresses {
+			unset[fmt.Sprintf("addresses.%d.server", i)] = ""
+			if strings.TrimSpace(address.Server) != "" {
+				serverId, err := legacyServerReference(address.Server)
+				if err != nil {
+					return err
+				}
+				set[fmt.Sprintf("addresses.%d.serverid", i)] = serverId
+			}
+		}
+		update := bson.M{"$unset": unset}
+		if len(set) > 0 {
+			update["$set"] = set
+		}
+		if _, err := collection.UpdateOne(context.TODO(), bson.M{"_id": legacy.ID}, update); err != nil {
+			return err
+		}
+		zap.S().Infow("Domain legacy server names migrated to server references", "id", legacy.ID.Hex())
+	}
+	return cursor.Err()
+}
+
+// legacyPackageDomain domain document as stored when hosting packages were free text
+type legacyPackageDomain struct {
+	ID      primitive.ObjectID `bson:"_id"`
+	Package string             `bson:"package"`
+}
+
+// legacyPackageReference returns the ID of the package with the given name, creating it if it doesn't exist
+// Created packages are not active, so that they can be reviewed before being assigned to other domains
+func legacyPackageReference(name string) (primitive.ObjectID, error) {
+	name = strings.TrimSpace(name)
+	packageService := new(packages.PackageService)
+	if pkg, err := packageService.GetByName(name); err == nil {
+		return pkg.ID, nil
+	}
+	pkg := packages.Package{Name: name, Active: false, Created: time.Now().Unix(), Updated: time.Now().Unix()}
+	if _, err := pkg.Save(); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return pkg.ID, nil
+}
+
+// migrateLegacyPackages converts the free text packages into hosting package references
+func migrateLegacyPackages() error {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+	cursor, err := collection.Find(context.TODO(), bson.M{"package": bson.M{"$exists": true}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.TODO())
+
+	for cursor.Next(context.TODO()) {
+		var legacy legacyPackageDomain
+		if err := cursor.Decode(&legacy); err != nil {
+			return err
+		}
+		update := bson.M{"$unset": bson.M{"package": ""}}
+		if strings.TrimSpace(legacy.Package) != "" {
+			packageId, err := legacyPackageReference(legacy.Package)
+			if err != nil {
+				return err
+			}
+			update["$set"] = bson.M{"packageid": packageId}
+		}
+		if _, err := collection.UpdateOne(context.TODO(), bson.M{"_id": legacy.ID}, update); err != nil {
+			return err
+		}
+		zap.S().Infow("Domain legacy package migrated to package reference", "id", legacy.ID.Hex())
+	}
+	return cursor.Err()
+}
+
+// legacyContactDomain domain document as stored when owner and registrant were free text
+type legacyContactDomain struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Owner      string             `bson:"owner"`
+	Registrant string             `bson:"registrant"`
+}
+
+// legacyContactReference returns the ID of the contact with the given name, creating it if it doesn't exist
+// Created contacts are companies, since most of the customers are
+func legacyContactReference(name string) (primitive.ObjectID, error) {
+	name = strings.TrimSpace(name)
+	contactService := new(contacts.ContactService)
+	if contact, err := contactService.GetByName(name); err == nil {
+		return contact.ID, nil
+	}
+	contact := contacts.Contact{
+		Kind:    contacts.KindCompany,
+		Name:    name,
+		Emails:  []string{},
+		Phones:  []string{},
+		Created: time.Now().Unix(),
+		Updated: time.Now().Unix(),
+	}
+	if _, err := contact.Save(); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return contact.ID, nil
+}
+
+// migrateLegacyContacts converts the free text owners and registrants into contact references
+func migrateLegacyContacts() error {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+	cursor, err := collection.Find(context.TODO(), bson.M{"$or": []bson.M{
+		{"owner": bson.M{"$exists": true}},
+		{"registrant": bson.M{"$exists": true}},
+	}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.TODO())
+
+	for cursor.Next(context.TODO()) {
+		var legacy legacyContactDomain
+		if err := cursor.Decode(&legacy); err != nil {
+			return err
+		}
+		set := bson.M{}
+		for field, name := range map[string]string{"ownerid": legacy.Owner, "registrantid": legacy.Registrant} {
+			if strings.TrimSpace(name) != "" {
+				contactId, err := legacyContactReference(name)
+				if err != nil {
+					return err
+				}
+				set[field] = contactId
+			}
+		}
+		update := bson.M{"$unset": bson.M{"owner": "", "registrant": ""}}
+		if len(set) > 0 {
+			update["$set"] = set
+		}
+		if _, err := collection.UpdateOne(context.TODO(), bson.M{"_id": legacy.ID}, update); err != nil {
+			return err
+		}
+		zap.S().Infow("Domain legacy owner and registrant migrated to contact references", "id", legacy.ID.Hex())
+	}
+	return cursor.Err()
+}
+
+// legacyNotesDomain domain document holding free text notes, as written before comments
+type legacyNotesDomain struct {
+	ID      primitive.ObjectID `bson:"_id"`
+	Notes   string             `bson:"notes"`
+	Updated int64              `bson:"updated"`
+}
+
+// migrateLegacyNotes copies the notes of the domains into a pinned comment of their timeline
+// The notes field is kept, read only, for the clients which don't know about comments
+func migrateLegacyNotes() error {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+	cursor, err := collection.Find(context.TODO(), bson.M{"notes": bson.M{"$gt": ""}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.TODO())
+
+	commentService := new(CommentService)
+	for cursor.Next(context.TODO()) {
+		var legacy legacyNotesDomain
+		if err := cursor.Decode(&legacy); err != nil {
+			return err
+		}
+		if strings.TrimSpace(legacy.Notes) == "" {
+			continue
+		}
+		migrated, err := commentService.hasLegacy(legacy.ID)
+		if err != nil {
+			return err
+		}
+		if migrated {
+			continue
+		}
+		comment := Comment{
+			DomainId: legacy.ID,
+			Author:   legacyNotesAuthor,
+			Body:     legacy.Notes,
+			Pinned:   true,
+			Legacy:   true,
+			History:  []CommentRevision{},
+			Created:  legacy.Updated,
+			Updated:  legacy.Updated,
+		}
+		if _, err := commentService.Save(&comment); err != nil {
+			return err
+		}
+		zap.S().Infow("Domain legacy notes migrated to a comment", "id", legacy.ID.Hex())
+	}
+	return cursor.Err()
+}
+
+// legacyNameDomain domain document as stored before names were normalized
+type legacyNameDomain struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	Name        string             `bson:"name"`
+	UnicodeName string             `bson:"unicodename"`
+}
+
+// nameMigration the changes needed to normalize the stored domain names
+type nameMigration struct {
+	updates    map[primitive.ObjectID]bson.M
+	collisions map[string][]legacyNameDomain // domains sharing the same normalized name, left unchanged
+	invalid    []legacyNameDomain
+}
+
+// planNameMigration computes the name and unicode name of each domain
+// Domains whose normalized names collide keep their name, since renaming them would break the unique name index
+func planNameMigration(domains []legacyNameDomain) nameMigration {
+	migration := nameMigration{updates: map[primitive.ObjectID]bson.M{}, collisions: map[string][]legacyNameDomain{}, invalid: []legacyNameDomain{}}
+	byName := map[string][]legacyNameDomain{}
+	names := map[primitive.ObjectID][2]string{}
+	for _, domain := range domains {
+		ascii, unicode, err := NormalizeName(domain.Name)
+		if err != nil {
+			migration.invalid = append(migration.invalid, domain)
+			continue
+		}
+		byName[ascii] = append(byName[ascii], domain)
+		names[domain.ID] = [2]string{ascii, unicode}
+	}
+	for ascii, group := range byName {
+		if len(group) > 1 {
+			migration.collisions[ascii] = group
+		}
+		for _, domain := range group {
+			set := bson.M{}
+			if len(group) == 1 && domain.Name != ascii {
+				set["name"] = ascii
+			}
+			if domain.UnicodeName != names[domain.ID][1] {
+				set["unicodename"] = names[domain.ID][1]
+			}
+			if len(set) > 0 {
+				migration.updates[domain.ID] = set
+			}
+		}
+	}
+	return migration
+}
+
+// migrateLegacyNames normalizes the names stored before names were validated to lowercase punycode and fills
+// their unicode names, trashed domains included
+// Names which are invalid or collide once normalized are reported, they have to be fixed by hand
+func migrateLegacyNames() error {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+	cursor, err := collection.Find(context.TODO(), bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.TODO())
+	domains := []legacyNameDomain{}
+	for cursor.Next(context.TODO()) {
+		var domain legacyNameDomain
+		if err := cursor.Decode(&domain); err != nil {
+			return err
+		}
+		domains = append(domains, domain)
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	migration := planNameMigration(domains)
+	for _, domain := range migration.invalid {
+		zap.S().Warnw("Domain name is not valid and cannot be normalized, rename it", "id", domain.ID.Hex(), "name", domain.Name)
+	}
+	for name, group := range migration.collisions {
+		ids := []string{}
+		for _, domain := range group {
+			ids = append(ids, fmt.Sprintf("%s (%s)", domain.ID.Hex(), domain.Name))
+		}
+		zap.S().Errorw("Domains have the same normalized name, rename or delete all but one of them to enforce unique names",
+			"name", name, "domains", strings.Join(ids, ", "))
+	}
+	for id, set := range migration.updates {
+		if _, err := collection.UpdateOne(context.TODO(), bson.M{"_id": id}, bson.M{"$set": set}); err != nil {
+			return err
+		}
+		zap.S().Infow("Domain legacy name normalized", "id", id.Hex())
+	}
+	return nil
+}
+
+// migrateLegacyLifecycles initializes the lifecycle of the domains stored before lifecycles, and the null
+// histories written by the updates of those domains
+func migrateLegacyLifecycles() error {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+	res, err := collection.UpdateMany(context.TODO(),
+		bson.M{"$or": []bson.M{{"lifecycle": bson.M{"$exists": false}}, {"lifecycle": nil}}},
+		bson.M{"$set": bson.M{"lifecycle": NewLifecycle("")}})
+	if err != nil {
+		return err
+	}
+	if res.ModifiedCount > 0 {
+		zap.S().Infow("Domain legacy lifecycles initialized", "count", res.ModifiedCount)
+	}
+	res, err = collection.UpdateMany(context.TODO(),
+		bson.M{"lifecycle.history": bson.M{"$not": bson.M{"$type": "array"}}},
+		bson.M{"$set": bson.M{"lifecycle.history": []LifecycleTransition{}}})
+	if err != nil {
+		return err
+	}
+	if res.ModifiedCount > 0 {
+		zap.S().Infow("Domain null lifecycle histories initialized", "count", res.ModifiedCount)
+	}
+	return nil
+}
+
+// Migrate updates the domain documents stored with an older schema
+func Migrate() {
+	if err := migrateLegacyAddresses(); err != nil {
+		zap.S().Error("Error migrating domain legacy addresses: ", err)
+	}
+	if err := migrateLegacyServers(); err != nil {
+		zap.S().Error("Error migrating domain legacy server names: ", err)
+	}
+	if err := migrateLegacyPackages(); err != nil {
+		zap.S().Error("Error migrating domain legacy packages: ", err)
+	}
+	if err := migrateLegacyContacts(); err != nil {
+		zap.S().Error("Error migrating domain legacy owners and registrants: ", err)
+	}
+	if err := migrateLegacyNotes(); err != nil {
+		zap.S().Error("Error migrating domain legacy notes: ", err)
+	}
+	if err := migrateLegacyNames(); err != nil {
+		zap.S().Error("Error migrating domain legacy names: ", err)
+	}
+	if err := migrateLegacyLifecycles(); err != nil {
+		zap.S().Error("Error migrating domain legacy lifecycles: ", err)
+	}
+}
@@ -0,0 +1,39 @@
+package domains
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+)
+
+func TestPlanNameMigration(t *testing.T) {
+	upper := legacyNameDomain{ID: primitive.NewObjectID(), Name: "Example.COM."}
+	unicode := legacyNameDomain{ID: primitive.NewObjectID(), Name: "bücher.example"}
+	normalized := legacyNameDomain{ID: primitive.NewObjectID(), Name: "ok.example", UnicodeName: "ok.example"}
+	first := legacyNameDomain{ID: primitive.NewObjectID(), Name: "dup.example"}
+	second := legacyNameDomain{ID: primitive.NewObjectID(), Name: "DUP.example"}
+	invalid := legacyNameDomain{ID: primitive.NewObjectID(), Name: "localhost"}
+
+	migration := planNameMigration([]legacyNameDomain{upper, unicode, normalized, first, second, invalid})
+
+	if set := migration.updates[upper.ID]; set["name"] != "example.com" || set["unicodename"] != "example.com" {
+		t.Fatalf("unexpected update %v", set)
+	}
+	if set := migration.updates[unicode.ID]; set["name"] != "xn--bcher-kva.example" || set["unicodename"] != "bücher.example" {
+		t.Fatalf("unexpected update %v", set)
+	}
+	if _, ok := migration.updates[normalized.ID]; ok {
+		t.Fatal("a normalized domain must not be updated")
+	}
+	if len(migration.collisions["dup.example"]) != 2 {
+		t.Fatalf("expected the collision to be reported, got %v", migration.collisions)
+	}
+	for _, domain := range []legacyNameDomain{first, second} {
+		set := migration.updates[domain.ID]
+		if _, ok := set["name"]; ok || set["unicodename"] != "dup.example" {
+			t.Fatalf("colliding domains must keep their name, got %v", set)
+		}
+	}
+	if len(migration.invalid) != 1 || migration.invalid[0].ID != invalid.ID {
+		t.Fatalf("expected the invalid name to be reported, got %v", migration.invalid)
+	}
+}
@@ -2,23 +2,235 @@ package domains
 
 import (
 	"go.mongodb.org/mongo-driver/bson/primitive" // for BSON ObjectID
+	"math"
 	"net"
+	"strings"
+	"time"
 )
 
+// IP address versions
+const (
+	IPv4 = "v4"
+	IPv6 = "v6"
+)
+
+// Address an IP address the domain resolves to or is hosted on
+type Address struct {
+	Ip       string             `json:"ip"`
+	Version  string             `json:"version"`
+	Purpose  string             `json:"purpose"`
+	ServerId primitive.ObjectID `json:"serverId" bson:",omitempty"`
+}
+
+// NewAddress returns an address given its ip, the ip is stored in its canonical form and the version is inferred
+func NewAddress(ip net.IP, purpose string, serverId primitive.ObjectID) Address {
+	version := IPv6
+	if ip.To4() != nil {
+		version = IPv4
+	}
+	return Address{
+		Ip:       ip.String(),
+		Version:  version,
+		Purpose:  purpose,
+		ServerId: serverId,
+	}
+}
+
+// RegistryInfo the registration data published by the registry (RDAP or WHOIS), as last checked
+type RegistryInfo struct {
+	Registrar    string   `json:"registrar"`
+	Statuses     []string `json:"statuses"`
+	Nameservers  []string `json:"nameservers"`
+	RegisteredAt int64    `json:"registeredAt"`
+	ExpiresAt    int64    `json:"expiresAt"`
+	Source       string   `json:"source"`
+	CheckedAt    int64    `json:"checkedAt"`
+	Error        string   `json:"error"`
+}
+
+// MxInfo a resolved MX record
+type MxInfo struct {
+	Host string `json:"host"`
+	Pref int    `json:"pref"`
+}
+
+// DnsInfo the DNS records resolved at the last check and their mismatches with the stored configuration
+type DnsInfo struct {
+	A          []string `json:"a"`
+	AAAA       []string `json:"aaaa"`
+	Mx         []MxInfo `json:"mx"`
+	Ns         []string `json:"ns"`
+	Mismatches []string `json:"mismatches"`
+	CheckedAt  int64    `json:"checkedAt"`
+	Error      string   `json:"error"`
+}
+
+// TlsSettings how to reach the domain HTTPS endpoint, empty fields fall back to the domain name and port 443
+type TlsSettings struct {
+	ServerName string `json:"serverName"`
+	Ip         string `json:"ip"`
+	Port       int    `json:"port"`
+}
+
+// CertificateInfo the certificate presented by the domain HTTPS endpoint at the last check
+type CertificateInfo struct {
+	Subject    string   `json:"subject"`
+	Issuer     string   `json:"issuer"`
+	Sans       []string `json:"sans"`
+	NotBefore  int64    `json:"notBefore"`
+	NotAfter   int64    `json:"notAfter"`
+	ChainError string   `json:"chainError"`
+	CheckedAt  int64    `json:"checkedAt"`
+	Error      string   `json:"error"`
+	Reminders  []int    `json:"reminders"` // thresholds (days) already notified for the current certificate
+}
+
+// HealthCheckSettings the HTTP(S) health check of the domain website
+type HealthCheckSettings struct {
+	Enabled         bool   `json:"enabled"`
+	Url             string `json:"url"`
+	ExpectedStatus  int    `json:"expectedStatus"`
+	ExpectedContent string `json:"expectedContent"`
+	IntervalSeconds int    `json:"intervalSeconds"`
+	TimeoutSeconds  int    `json:"timeoutSeconds"`
+}
+
+// Health check states
+const (
+	HealthUnknown = "unknown"
+	HealthUp      = "up"
+	HealthDown    = "down"
+)
+
+// HealthState the current state of the domain website, as resulting from the last health check
+type HealthState struct {
+	Status    string `json:"status"`
+	ChangedAt int64  `json:"changedAt"`
+	CheckedAt int64  `json:"checkedAt"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error"`
+}
+
+// HealthResult a health check result, stored in a collection whose TTL index expires old results
+type HealthResult struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	DomainId  primitive.ObjectID `json:"domainId"`
+	CheckedAt time.Time          `json:"checkedAt"`
+	Up        bool               `json:"up"`
+	Status    int                `json:"status"`
+	LatencyMs int64              `json:"latencyMs"`
+	Error     string             `json:"error"`
+}
+
+// UptimeSummary uptime and latency of the domain website over a period
+type UptimeSummary struct {
+	Period       string
+	Checks       int64
+	Uptime       float64
+	AvgLatencyMs float64
+	MaxLatencyMs int64
+}
+
+// Discrepancy a stored field whose value differs from the one published by the registry
+type Discrepancy struct {
+	Field   string
+	Stored  interface{}
+	Fetched interface{}
+}
+
 // User the user model
 type Domain struct {
-	ID         primitive.ObjectID `bson:"_id,omitempty"`
-	Name       string             `json:"name"`
-	Owner      string             `json:"owner"`
-	Registrant string             `json:"registrant"`
-	LoginInfo  string             `json:"loginInfo"`
-	Package    string             `json:"package"`
-	Mx         bool               `json:"mx"`
-	Ip         net.IP             `json:"ip"`
-	ServerName string             `json:"serverName"`
-	Notes      string             `json:"notes"`
-	Created    int64              `json:"created"`
-	Updated    int64              `json:"updated"`
+	ID              primitive.ObjectID     `bson:"_id,omitempty"`
+	Name            string                 `json:"name"`
+	UnicodeName     string                 `json:"unicodeName"`
+	OwnerId         primitive.ObjectID     `json:"ownerId" bson:",omitempty"`
+	RegistrantId    primitive.ObjectID     `json:"registrantId" bson:",omitempty"`
+	LoginInfo       string                 `json:"loginInfo"`
+	PackageId       primitive.ObjectID     `json:"packageId" bson:",omitempty"`
+	Mx              bool                   `json:"mx"`
+	Addresses       []Address              `json:"addresses"`
+	ServerId        primitive.ObjectID     `json:"serverId" bson:",omitempty"`
+	Registrar       string                 `json:"registrar"`
+	RegisteredAt    int64                  `json:"registeredAt"`
+	ExpiresAt       int64                  `json:"expiresAt"`
+	AutoRenew       bool                   `json:"autoRenew"`
+	RenewalCost     float64                `json:"renewalCost"`
+	ExpiryReminders []int                  `json:"expiryReminders"` // thresholds (days) already notified for the current expiry date
+	Registry        RegistryInfo           `json:"registry"`
+	Dns             DnsInfo                `json:"dns"`
+	Tls             TlsSettings            `json:"tls"`
+	Certificate     CertificateInfo        `json:"certificate"`
+	HealthCheck     HealthCheckSettings    `json:"healthCheck"`
+	Health          HealthState            `json:"health"`
+	Zone            ZoneInfo               `json:"zone"`
+	Lifecycle       LifecycleInfo          `json:"lifecycle"`
+	Billing         []LineItem             `json:"billing"`
+	Managers        []primitive.ObjectID   `json:"managers"` // users (user role) allowed to see and edit the domain
+	Tags            []string               `json:"tags"`
+	CustomFields    map[string]interface{} `json:"customFields"`
+	Notes           string                 `json:"notes"` // legacy, read only: replaced by the comments timeline
+	Created         int64                  `json:"created"`
+	Updated         int64                  `json:"updated"`
+	DeletedAt       int64                  `json:"deletedAt" bson:",omitempty"`
+}
+
+// HasManager returns true if the user is one of the domain managers
+func (self *Domain) HasManager(userId primitive.ObjectID) bool {
+	for _, id := range self.Managers {
+		if id == userId {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAddressIn returns true if one of the domain addresses belongs to the given network
+func (self *Domain) HasAddressIn(network *net.IPNet) bool {
+	for _, address := range self.Addresses {
+		if ip := net.ParseIP(address.Ip); ip != nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// DaysToExpiry returns the number of days left before the registration expires, negative if expired
+// Returns false if the expiry date is not known
+func (self *Domain) DaysToExpiry(now time.Time) (int, bool) {
+	if self.ExpiresAt == 0 {
+		return 0, false
+	}
+	return int(math.Ceil(float64(self.ExpiresAt-now.Unix()) / 86400)), true
+}
+
+// RegistryDiscrepancies compares the stored registration fields with the ones published by the registry
+// Fields not published by the registry are not compared
+func (self *Domain) RegistryDiscrepancies() []Discrepancy {
+	res := []Discrepancy{}
+	if self.Registry.CheckedAt == 0 || self.Registry.Error != "" {
+		return res
+	}
+	if self.Registry.Registrar != "" && !strings.EqualFold(strings.TrimSpace(self.Registrar), self.Registry.Registrar) {
+		res = append(res, Discrepancy{"registrar", self.Registrar, self.Registry.Registrar})
+	}
+	sameDay := func(a int64, b int64) bool {
+		return time.Unix(a, 0).UTC().Format("2006-01-02") == time.Unix(b, 0).UTC().Format("2006-01-02")
+	}
+	if self.Registry.RegisteredAt != 0 && !sameDay(self.RegisteredAt, self.Registry.RegisteredAt) {
+		res = append(res, Discrepancy{"registeredAt", self.RegisteredAt, self.Registry.RegisteredAt})
+	}
+	if self.Registry.ExpiresAt != 0 && !sameDay(self.ExpiresAt, self.Registry.ExpiresAt) {
+		res = append(res, Discrepancy{"expiresAt", self.ExpiresAt, self.Registry.ExpiresAt})
+	}
+	return res
+}
+
+// HealthCheckUrl returns the url checked by the health check, the domain home page by default
+func (self *Domain) HealthCheckUrl() string {
+	if self.HealthCheck.Url != "" {
+		return self.HealthCheck.Url
+	}
+	return "https://" + self.Name + "/"
 }
 
 func (self *Domain) Save() (bool, error) {
@@ -27,6 +239,26 @@ func (self *Domain) Save() (bool, error) {
 	return result, err
 }
 
+// IsTrashed returns true if the domain has been soft deleted
+func (self *Domain) IsTrashed() bool {
+	return self.DeletedAt != 0
+}
+
+// Trash soft deletes the domain, which is then excluded from normal queries
+func (self *Domain) Trash() (bool, error) {
+	domainService := new(DomainService) // @TODO factory method
+	result, err := domainService.Trash(self)
+	return result, err
+}
+
+// Restore brings back a soft deleted domain
+func (self *Domain) Restore() (bool, error) {
+	domainService := new(DomainService) // @TODO factory method
+	result, err := domainService.Restore(self)
+	return result, err
+}
+
+// Delete permanently removes the domain
 func (self *Domain) Delete() (bool, error) {
 	domainService := new(DomainService) // @TODO factory method
 	result, err := domainService.Delete(self)
@@ -0,0 +1,53 @@
+package domains
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/idna"
+	"strings"
+)
+
+// namesProfile maps names the way DNS lookups do (lowercase, unicode normalization) and enforces
+// the hostname rules (STD3) and the DNS length limits
+var namesProfile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.VerifyDNSLength(true),
+	idna.StrictDomainName(true),
+	idna.Transitional(false),
+)
+
+// NormalizeName validates a domain name as a fully qualified domain name and returns both
+// its lowercase punycode (ASCII) form, used for storage and lookups, and its unicode form, used for display.
+// A single trailing dot (root label) is accepted and removed.
+func NormalizeName(name string) (string, string, error) {
+	name = strings.TrimSuffix(strings.TrimSpace(name), ".")
+	if name == "" {
+		return "", "", errors.New("Domain name is required")
+	}
+
+	ascii, err := namesProfile.ToASCII(name)
+	if err != nil {
+		return "", "", fmt.Errorf("Invalid domain name %q: %v", name, err)
+	}
+
+	labels := strings.Split(ascii, ".")
+	if len(labels) < 2 {
+		return "", "", fmt.Errorf("Invalid domain name %q: a fully qualified domain name is required", name)
+	}
+	for _, label := range labels {
+		if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return "", "", fmt.Errorf("Invalid domain name %q: labels cannot start or end with an hyphen", name)
+		}
+	}
+	if strings.Trim(labels[len(labels)-1], "0123456789") == "" {
+		return "", "", fmt.Errorf("Invalid domain name %q: top level domain cannot be numeric", name)
+	}
+
+	unicode, err := namesProfile.ToUnicode(ascii)
+	if err != nil {
+		return "", "", fmt.Errorf("Invalid domain name %q: %v", name, err)
+	}
+
+	return ascii, unicode, nil
+}
@@ -0,0 +1,99 @@
+package domains
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"reflect"
+	"strings"
+	"systems-management-api/auth"
+	"systems-management-api/core/utils"
+)
+
+// defaultFieldPolicies the field policies of the roles which are not configured in domains.fieldPolicy
+var defaultFieldPolicies = map[string]FieldPolicy{
+	"user": {Hidden: []string{"loginInfo"}, ReadOnly: []string{"registrantId"}},
+}
+
+// FieldPolicy the domain fields a role cannot see (hidden) or change (read only), named as in the json documents
+// Hidden fields are read only as well
+type FieldPolicy struct {
+	Hidden   []string
+	ReadOnly []string
+}
+
+// FieldForbiddenError error returned when writing a field forbidden by the field policy
+type FieldForbiddenError struct {
+	Field string
+}
+
+func (self *FieldForbiddenError) Error() string {
+	return fmt.Sprintf("You are not allowed to change the field %s", self.Field)
+}
+
+// fieldPolicyFor returns the field policy of the user role, from the domains.fieldPolicy.<role> setting
+func fieldPolicyFor(user *auth.User) FieldPolicy {
+	key := "domains.fieldPolicy." + user.Role
+	if !viper.IsSet(key) {
+		return defaultFieldPolicies[user.Role]
+	}
+	return FieldPolicy{
+		Hidden:   viper.GetStringSlice(key + ".hidden"),
+		ReadOnly: viper.GetStringSlice(key + ".readOnly"),
+	}
+}
+
+// CanRead tells whether the field can be seen
+func (self *FieldPolicy) CanRead(field string) bool {
+	return !utils.Contains(self.Hidden, field)
+}
+
+// CanWrite tells whether the field can be changed
+func (self *FieldPolicy) CanWrite(field string) bool {
+	return self.CanRead(field) && !utils.Contains(self.ReadOnly, field)
+}
+
+// restricted returns the fields which cannot be changed
+func (self *FieldPolicy) restricted() []string {
+	return append(append([]string{}, self.Hidden...), self.ReadOnly...)
+}
+
+// checkCreate rejects the creation of a domain setting a field which cannot be changed
+func (self *FieldPolicy) checkCreate(data *DomainValidatorData) error {
+	for _, field := range self.restricted() {
+		if value := jsonField(reflect.ValueOf(data).Elem(), field); value.IsValid() && !value.IsZero() {
+			return &FieldForbiddenError{Field: field}
+		}
+	}
+	return nil
+}
+
+// checkUpdate rejects the update of a domain changing a field which cannot be changed
+// Omitted (zero valued) fields keep their current value, so that clients can send back what they read
+func (self *FieldPolicy) checkUpdate(data *DomainValidatorData, updated *Domain, current *Domain) error {
+	for _, field := range self.restricted() {
+		target := jsonField(reflect.ValueOf(updated).Elem(), field)
+		if !target.IsValid() {
+			continue
+		}
+		value := jsonField(reflect.ValueOf(current).Elem(), field)
+		if sent := jsonField(reflect.ValueOf(data).Elem(), field); !sent.IsValid() || sent.IsZero() {
+			target.Set(value)
+			continue
+		}
+		if !reflect.DeepEqual(target.Interface(), value.Interface()) {
+			return &FieldForbiddenError{Field: field}
+		}
+	}
+	return nil
+}
+
+// jsonField returns the field of the struct having the given json name, the zero Value if there is none
+func jsonField(value reflect.Value, name string) reflect.Value {
+	for i := 0; i < value.NumField(); i++ {
+		tag := strings.Split(value.Type().Field(i).Tag.Get("json"), ",")[0]
+		if tag == name {
+			return value.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
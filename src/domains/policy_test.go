@@ -0,0 +1,102 @@
+package domains
+
+import (
+	"encoding/json"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+)
+
+func TestSerializersHideFields(t *testing.T) {
+	serializer := &domainSerializer{policy: FieldPolicy{Hidden: []string{"loginInfo", "registrantId"}}}
+	domain := Domain{
+		ID:           primitive.NewObjectID(),
+		Name:         "example.com",
+		LoginInfo:    "secret",
+		RegistrantId: primitive.NewObjectID(),
+		OwnerId:      primitive.NewObjectID(),
+	}
+
+	many := serializer.SerializeMany(&[]Domain{domain})
+	serialized := map[string]DomainData{
+		"Serialize":           serializer.Serialize(&domain),
+		"SerializeMany":       many[0],
+		"SerializeWithUptime": serializer.SerializeWithUptime(&domain, []UptimeSummary{}),
+	}
+	for name, data := range serialized {
+		if data.LoginInfo != "" || data.RegistrantId != "" {
+			t.Fatalf("%s: expected the hidden fields to be cleared, got %+v", name, data)
+		}
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fields := map[string]interface{}{}
+		if err := json.Unmarshal(encoded, &fields); err != nil {
+			t.Fatal(err)
+		}
+		for _, field := range []string{"loginInfo", "registrantId"} {
+			if _, ok := fields[field]; ok {
+				t.Fatalf("%s: expected the hidden field %s to be removed, got %s", name, field, encoded)
+			}
+		}
+		if fields["ownerId"] != domain.OwnerId.Hex() {
+			t.Fatalf("%s: expected the visible fields to be kept, got %s", name, encoded)
+		}
+	}
+
+	for _, column := range visibleExportColumns(serializer.policy) {
+		switch column.Name {
+		case "loginInfo", "registrantId", "registrant":
+			t.Fatalf("expected the hidden column %s not to be exported", column.Name)
+		}
+	}
+}
+
+func TestCheckCreate(t *testing.T) {
+	policy := FieldPolicy{Hidden: []string{"loginInfo"}, ReadOnly: []string{"registrantId"}}
+	if err := policy.checkCreate(&DomainValidatorData{Name: "example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	var forbidden *FieldForbiddenError
+	err := policy.checkCreate(&DomainValidatorData{Name: "example.com", LoginInfo: "secret"})
+	if !errors.As(err, &forbidden) || forbidden.Field != "loginInfo" {
+		t.Fatalf("expected setting a hidden field to be refused, got %v", err)
+	}
+	err = policy.checkCreate(&DomainValidatorData{Name: "example.com", RegistrantId: primitive.NewObjectID().Hex()})
+	if !errors.As(err, &forbidden) || forbidden.Field != "registrantId" {
+		t.Fatalf("expected setting a read only field to be refused, got %v", err)
+	}
+}
+
+func TestCheckUpdateReadOnly(t *testing.T) {
+	policy := FieldPolicy{Hidden: []string{"loginInfo"}, ReadOnly: []string{"registrantId"}}
+	current := Domain{Name: "example.com", RegistrantId: primitive.NewObjectID(), LoginInfo: "secret"}
+
+	// sent unchanged
+	updated := Domain{Name: "example.com", RegistrantId: current.RegistrantId}
+	data := DomainValidatorData{Name: "example.com", RegistrantId: current.RegistrantId.Hex()}
+	if err := policy.checkUpdate(&data, &updated, &current); err != nil {
+		t.Fatalf("expected an unchanged read only field to be accepted, got %v", err)
+	}
+	if updated.LoginInfo != "secret" {
+		t.Fatal("expected the omitted hidden field to keep its value")
+	}
+
+	// omitted
+	updated = Domain{Name: "example.com"}
+	data = DomainValidatorData{Name: "example.com"}
+	if err := policy.checkUpdate(&data, &updated, &current); err != nil || updated.RegistrantId != current.RegistrantId {
+		t.Fatalf("expected the omitted read only field to keep its value, got %v", err)
+	}
+
+	// sent changed
+	other := primitive.NewObjectID()
+	updated = Domain{Name: "example.com", RegistrantId: other}
+	data = DomainValidatorData{Name: "example.com", RegistrantId: other.Hex()}
+	var forbidden *FieldForbiddenError
+	err := policy.checkUpdate(&data, &updated, &current)
+	if !errors.As(err, &forbidden) || forbidden.Field != "registrantId" {
+		t.Fatalf("expected a changed read only field to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package domains
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"sync"
+	"systems-management-api/core/utils"
+)
+
+// Finding severities, from the most to the least serious
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Severities all the finding severities
+var Severities = []string{SeverityError, SeverityWarning, SeverityInfo}
+
+// Finding a data quality issue spotted by a check, with the suggested way to solve it
+// Fix is set when the issue can be solved automatically by the fix endpoint
+type Finding struct {
+	ID         string // stable while the issue is not solved, used to select the fixes to apply
+	Check      string
+	Severity   string
+	Message    string
+	DomainIds  []primitive.ObjectID
+	Suggestion string
+	Fix        *QualityFix
+}
+
+// QualityFix an automatic fix of a finding, applied by the fixer registered for its action
+type QualityFix struct {
+	Action string
+	Params map[string]string
+}
+
+// QualityCheck a data quality check run over the domains which are not trashed
+type QualityCheck struct {
+	Name        string
+	Description string
+	Run         func(domains *[]Domain) ([]Finding, error)
+}
+
+// QualityFixer applies a fix, by is the user applying it
+type QualityFixer func(fix *QualityFix, by string) error
+
+var qualityMutex sync.RWMutex
+var qualityChecks = []QualityCheck{}
+var qualityFixers = map[string]QualityFixer{}
+
+// RegisterQualityCheck registers a data quality check, checks are run in registration order
+// Packages register their checks in their init function
+func RegisterQualityCheck(check QualityCheck) {
+	qualityMutex.Lock()
+	defer qualityMutex.Unlock()
+	qualityChecks = append(qualityChecks, check)
+}
+
+// RegisterQualityFixer registers the fixer applying the fixes of the given action
+func RegisterQualityFixer(action string, fixer QualityFixer) {
+	qualityMutex.Lock()
+	defer qualityMutex.Unlock()
+	qualityFixers[action] = fixer
+}
+
+// QualityChecks returns the registered data quality checks
+func QualityChecks() []QualityCheck {
+	qualityMutex.RLock()
+	defer qualityMutex.RUnlock()
+	return append([]QualityCheck{}, qualityChecks...)
+}
+
+// NewFinding returns a finding of the check about the given subject, whose ID is derived from the check and subject
+func NewFinding(check string, subject string, severity string, message string, suggestion string) Finding {
+	return Finding{
+		ID:         fmt.Sprintf("%x", sha1.Sum([]byte(check+"\x00"+subject)))[:16],
+		Check:      check,
+		Severity:   severity,
+		Message:    message,
+		DomainIds:  []primitive.ObjectID{},
+		Suggestion: suggestion,
+	}
+}
+
+// UnknownCheckError error returned when running a check which is not registered
+type UnknownCheckError struct {
+	Name string
+}
+
+func (self *UnknownCheckError) Error() string {
+	return fmt.Sprintf("Unknown check %s", self.Name)
+}
+
+// runQualityChecks runs the named checks (all of them when names is empty) over the domains which are not trashed
+// Findings are sorted by severity, then by check in registration order
+func runQualityChecks(names []string) ([]Finding, error) {
+	checks := QualityChecks()
+	for _, name := range names {
+		found := false
+		for _, check := range checks {
+			found = found || check.Name == name
+		}
+		if !found {
+			return nil, &UnknownCheckError{Name: name}
+		}
+	}
+
+	filter := NewDomainFilter()
+	domains, err := new(DomainService).list(&filter)
+	if err != nil {
+		return nil, err
+	}
+	return runChecks(checks, names, domains)
+}
+
+// runChecks runs the named checks (all of them when names is empty) over the given domains
+func runChecks(checks []QualityCheck, names []string, domains *[]Domain) ([]Finding, error) {
+	findings := []Finding{}
+	for _, check := range checks {
+		if len(names) > 0 && !utils.Contains(names, check.Name) {
+			continue
+		}
+		res, err := check.Run(domains)
+		if err != nil {
+			return nil, fmt.Errorf("Check %s failed: %w", check.Name, err)
+		}
+		findings = append(findings, res...)
+	}
+	rank := map[string]int{SeverityError: 0, SeverityWarning: 1, SeverityInfo: 2}
+	sort.SliceStable(findings, func(i, j int) bool { return rank[findings[i].Severity] < rank[findings[j].Severity] })
+	return findings, nil
+}
+
+// FixResult the outcome of the fix of a finding
+type FixResult struct {
+	FindingId string
+	Check     string
+	Action    string
+	Applied   bool
+	Error     string
+}
+
+// applyQualityFixes applies the fixes of the given findings, checks are run again so that only the current findings are fixed
+// Findings which no longer exist or have no automatic fix are reported as not applied, as well as the fixes which fail
+// With dryRun the fixes are only reported
+func applyQualityFixes(ids []string, dryRun bool, by string) ([]FixResult, error) {
+	findings, err := runQualityChecks(nil)
+	if err != nil {
+		return nil, err
+	}
+	return applyFixes(findings, ids, dryRun, by), nil
+}
+
+// applyFixes applies the fixes of the given findings, selected among the current ones
+func applyFixes(findings []Finding, ids []string, dryRun bool, by string) []FixResult {
+	byId := map[string]*Finding{}
+	for i := range findings {
+		byId[findings[i].ID] = &findings[i]
+	}
+
+	res := []FixResult{}
+	for _, id := range ids {
+		finding, ok := byId[id]
+		if !ok {
+			res = append(res, FixResult{FindingId: id, Error: "Finding not found, it may have been solved meanwhile"})
+			continue
+		}
+		result := FixResult{FindingId: id, Check: finding.Check}
+		if finding.Fix == nil {
+			result.Error = "The finding has no automatic fix: " + finding.Suggestion
+			res = append(res, result)
+			continue
+		}
+		result.Action = finding.Fix.Action
+		qualityMutex.RLock()
+		fixer, ok := qualityFixers[finding.Fix.Action]
+		qualityMutex.RUnlock()
+		switch {
+		case !ok:
+			result.Error = fmt.Sprintf("Unknown fix action %s", finding.Fix.Action)
+		case dryRun:
+			result.Applied = false
+		default:
+			if err := fixer(finding.Fix, by); err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Applied = true
+			}
+		}
+		res = append(res, result)
+	}
+	return res
+}
@@ -0,0 +1,169 @@
+package domains
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"testing"
+)
+
+// fakeUpdateDomain replaces the domain updates with one recording the filter and returning the given result
+func fakeUpdateDomain(t *testing.T, res *mongo.UpdateResult) *bson.M {
+	filter := &bson.M{}
+	previous := updateDomain
+	updateDomain = func(f interface{}, update interface{}) (*mongo.UpdateResult, error) {
+		*filter = f.(bson.M)
+		return res, nil
+	}
+	t.Cleanup(func() { updateDomain = previous })
+	return filter
+}
+
+func TestFindingId(t *testing.T) {
+	a := NewFinding("missing-owner", "example.com", SeverityError, "first", "")
+	b := NewFinding("missing-owner", "example.com", SeverityWarning, "second", "other")
+	if a.ID == "" || a.ID != b.ID {
+		t.Fatalf("the finding ID must only depend on the check and subject, got %s and %s", a.ID, b.ID)
+	}
+	if c := NewFinding("missing-owner", "example.org", SeverityError, "first", ""); c.ID == a.ID {
+		t.Fatal("findings about different subjects must have different IDs")
+	}
+	if c := NewFinding("missing-registrant", "example.com", SeverityError, "first", ""); c.ID == a.ID {
+		t.Fatal("findings of different checks must have different IDs")
+	}
+}
+
+func TestRunChecksSeverityOrder(t *testing.T) {
+	check := func(name string, severities ...string) QualityCheck {
+		return QualityCheck{Name: name, Run: func(domains *[]Domain) ([]Finding, error) {
+			findings := []Finding{}
+			for i, severity := range severities {
+				findings = append(findings, NewFinding(name, string(rune('a'+i)), severity, name, ""))
+			}
+			return findings, nil
+		}}
+	}
+	checks := []QualityCheck{
+		check("first", SeverityInfo, SeverityError),
+		check("second", SeverityWarning, SeverityError),
+		check("skipped", SeverityError),
+	}
+
+	findings, err := runChecks(checks, []string{"first", "second"}, &[]Domain{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct{ check, severity string }{
+		{"first", SeverityError}, {"second", SeverityError}, {"second", SeverityWarning}, {"first", SeverityInfo},
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %v", len(expected), findings)
+	}
+	for i, finding := range findings {
+		if finding.Check != expected[i].check || finding.Severity != expected[i].severity {
+			t.Fatalf("finding %d: expected %v, got %s %s", i, expected[i], finding.Check, finding.Severity)
+		}
+	}
+
+	failing := QualityCheck{Name: "failing", Run: func(domains *[]Domain) ([]Finding, error) { return nil, errors.New("boom") }}
+	if _, err := runChecks([]QualityCheck{failing}, nil, &[]Domain{}); err == nil {
+		t.Fatal("expected the check error to be returned")
+	}
+}
+
+func TestRunUnknownCheck(t *testing.T) {
+	_, err := runQualityChecks([]string{"missing-owner", "nope"})
+	var unknown *UnknownCheckError
+	if !errors.As(err, &unknown) || unknown.Name != "nope" {
+		t.Fatalf("expected an unknown check error, got %v", err)
+	}
+}
+
+func TestApplyFixes(t *testing.T) {
+	calls := 0
+	RegisterQualityFixer("test-counting", func(fix *QualityFix, by string) error {
+		calls++
+		return nil
+	})
+	RegisterQualityFixer("test-failing", func(fix *QualityFix, by string) error {
+		return errors.New("fix failed")
+	})
+	fixable := NewFinding("test", "fixable", SeverityError, "", "")
+	fixable.Fix = &QualityFix{Action: "test-counting"}
+	failing := NewFinding("test", "failing", SeverityError, "", "")
+	failing.Fix = &QualityFix{Action: "test-failing"}
+	unknown := NewFinding("test", "unknown", SeverityError, "", "")
+	unknown.Fix = &QualityFix{Action: "test-unknown"}
+	manual := NewFinding("test", "manual", SeverityError, "", "Do it by hand")
+	findings := []Finding{fixable, failing, unknown, manual}
+	ids := []string{fixable.ID, failing.ID, unknown.ID, manual.ID, "gone"}
+
+	res := applyFixes(findings, ids, true, "tester")
+	if calls != 0 {
+		t.Fatal("a dry run must not apply any fix")
+	}
+	if res[0].Applied || res[0].Error != "" || res[0].Action != "test-counting" {
+		t.Fatalf("expected the dry run fix to be reported, got %+v", res[0])
+	}
+
+	res = applyFixes(findings, ids, false, "tester")
+	if calls != 1 || !res[0].Applied {
+		t.Fatalf("expected the fix to be applied once, got %+v", res[0])
+	}
+	expected := []string{"", "fix failed", "Unknown fix action test-unknown", "The finding has no automatic fix: Do it by hand",
+		"Finding not found, it may have been solved meanwhile"}
+	for i, result := range res {
+		if result.FindingId != ids[i] || result.Error != expected[i] || (i > 0 && result.Applied) {
+			t.Fatalf("fix %d: expected error %q, got %+v", i, expected[i], result)
+		}
+	}
+}
+
+func TestFixRegistrantSetMeanwhile(t *testing.T) {
+	filter := fakeUpdateDomain(t, &mongo.UpdateResult{MatchedCount: 0, ModifiedCount: 0})
+	domainId := primitive.NewObjectID()
+	fix := &QualityFix{Action: "set-registrant", Params: map[string]string{
+		"domainId": domainId.Hex(), "registrantId": primitive.NewObjectID().Hex(),
+	}}
+
+	err := fixRegistrant(fix, "tester")
+	if err == nil || err.Error() != "The domain registrant has been set meanwhile" {
+		t.Fatalf("expected the registrant set meanwhile to be reported, got %v", err)
+	}
+	if (*filter)["_id"] != domainId {
+		t.Fatalf("expected the domain to be matched, got %v", *filter)
+	}
+	if registrant, ok := (*filter)["registrantid"].(bson.M); !ok || registrant["$exists"] != false {
+		t.Fatalf("expected the update to require no registrant, got %v", *filter)
+	}
+
+	fakeUpdateDomain(t, &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1})
+	if err := fixRegistrant(fix, "tester"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFixAddressServerChangedMeanwhile(t *testing.T) {
+	filter := fakeUpdateDomain(t, &mongo.UpdateResult{MatchedCount: 0, ModifiedCount: 0})
+	fromServerId := primitive.NewObjectID()
+	fix := &QualityFix{Action: "set-address-server", Params: map[string]string{
+		"domainId": primitive.NewObjectID().Hex(), "ip": "192.0.2.1",
+		"fromServerId": fromServerId.Hex(), "serverId": primitive.NewObjectID().Hex(),
+	}}
+
+	err := fixAddressServer(fix, "tester")
+	if err == nil || err.Error() != "The domain address has been changed meanwhile" {
+		t.Fatalf("expected the address changed meanwhile to be reported, got %v", err)
+	}
+	addresses, _ := (*filter)["addresses"].(bson.M)
+	match, _ := addresses["$elemMatch"].(bson.M)
+	if match["ip"] != "192.0.2.1" || match["serverid"] != fromServerId {
+		t.Fatalf("expected the update to require the address still linked to the previous server, got %v", *filter)
+	}
+
+	fakeUpdateDomain(t, &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1})
+	if err := fixAddressServer(fix, "tester"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,258 @@
+package domains
+
+import (
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"strings"
+	"systems-management-api/contacts"
+	"systems-management-api/servers"
+	"time"
+)
+
+// checkMissingOwner reports the domains which have no owner
+func checkMissingOwner(domains *[]Domain) ([]Finding, error) {
+	findings := []Finding{}
+	for _, domain := range *domains {
+		if !domain.OwnerId.IsZero() {
+			continue
+		}
+		finding := NewFinding("missing-owner", domain.ID.Hex(), SeverityError,
+			fmt.Sprintf("The domain %s has no owner", domain.Name), "Set the owner of the domain")
+		finding.DomainIds = append(finding.DomainIds, domain.ID)
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
+
+// checkMissingRegistrant reports the domains which have no registrant, suggesting the owner as registrant
+func checkMissingRegistrant(domains *[]Domain) ([]Finding, error) {
+	findings := []Finding{}
+	for _, domain := range *domains {
+		if !domain.RegistrantId.IsZero() {
+			continue
+		}
+		finding := NewFinding("missing-registrant", domain.ID.Hex(), SeverityWarning,
+			fmt.Sprintf("The domain %s has no registrant", domain.Name), "Set the registrant of the domain")
+		finding.DomainIds = append(finding.DomainIds, domain.ID)
+		if !domain.OwnerId.IsZero() {
+			finding.Suggestion = "Use the domain owner as registrant"
+			finding.Fix = &QualityFix{Action: "set-registrant", Params: map[string]string{"domainId": domain.ID.Hex(), "registrantId": domain.OwnerId.Hex()}}
+		}
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
+
+// checkAddressServers reports the domain addresses whose ip no server claims, and the addresses linked to a server
+// which does not claim their ip while another one does
+func checkAddressServers(domains *[]Domain) ([]Finding, error) {
+	all, err := new(servers.ServerService).All()
+	if err != nil {
+		return nil, err
+	}
+	claimers := map[string][]servers.Server{}
+	for _, server := range *all {
+		for _, ip := range server.Ips {
+			claimers[ip] = append(claimers[ip], server)
+		}
+	}
+
+	findings := []Finding{}
+	for _, domain := range *domains {
+		for _, address := range domain.Addresses {
+			claimedBy := claimers[address.Ip]
+			claimed := false
+			for _, server := range claimedBy {
+				claimed = claimed || server.ID == address.ServerId
+			}
+			switch {
+			case len(claimedBy) == 0:
+				finding := NewFinding("unclaimed-ip", domain.ID.Hex()+"/"+address.Ip, SeverityWarning,
+					fmt.Sprintf("The domain %s points to %s, which no server claims", domain.Name, address.Ip),
+					"Add the ip to the server hosting the domain, or remove the address from the domain")
+				finding.DomainIds = append(finding.DomainIds, domain.ID)
+				findings = append(findings, finding)
+			case !address.ServerId.IsZero() && !claimed:
+				finding := NewFinding("unclaimed-ip", domain.ID.Hex()+"/"+address.Ip, SeverityWarning,
+					fmt.Sprintf("The address %s of the domain %s is linked to a server which does not claim it", address.Ip, domain.Name),
+					"Link the address to the server claiming the ip")
+				finding.DomainIds = append(finding.DomainIds, domain.ID)
+				if len(claimedBy) == 1 {
+					finding.Suggestion = fmt.Sprintf("Link the address to the server %s, which claims the ip", claimedBy[0].Hostname)
+					finding.Fix = &QualityFix{Action: "set-address-server", Params: map[string]string{
+						"domainId": domain.ID.Hex(), "ip": address.Ip, "fromServerId": address.ServerId.Hex(), "serverId": claimedBy[0].ID.Hex(),
+					}}
+				}
+				findings = append(findings, finding)
+			}
+		}
+	}
+	return findings, nil
+}
+
+// checkDuplicateOwners reports the groups of near-duplicate contacts (same normalized name, email or VAT number)
+// owning or registering domains, suggesting to merge them into the one having most domains
+func checkDuplicateOwners(domains *[]Domain) ([]Finding, error) {
+	duplicates, err := new(contacts.ContactService).Duplicates()
+	if err != nil {
+		return nil, err
+	}
+	counts := map[primitive.ObjectID]int{}
+	domainIds := map[primitive.ObjectID][]primitive.ObjectID{}
+	for _, domain := range *domains {
+		for _, id := range []primitive.ObjectID{domain.OwnerId, domain.RegistrantId} {
+			if !id.IsZero() {
+				counts[id]++
+				domainIds[id] = append(domainIds[id], domain.ID)
+			}
+		}
+	}
+
+	findings := []Finding{}
+	for _, group := range *duplicates {
+		ids := []string{}
+		names := []string{}
+		total := 0
+		for _, contact := range group.Contacts {
+			ids = append(ids, contact.ID.Hex())
+			names = append(names, contact.Name)
+			total += counts[contact.ID]
+		}
+		if total == 0 {
+			continue
+		}
+		sort.Strings(ids)
+		// the target is the contact having most domains, the oldest one on ties
+		target := group.Contacts[0]
+		for _, contact := range group.Contacts[1:] {
+			if counts[contact.ID] > counts[target.ID] || (counts[contact.ID] == counts[target.ID] && contact.Created < target.Created) {
+				target = contact
+			}
+		}
+		others := []string{}
+		for _, contact := range group.Contacts {
+			if contact.ID != target.ID {
+				others = append(others, contact.ID.Hex())
+			}
+		}
+
+		finding := NewFinding("duplicate-owners", group.Reason+"/"+strings.Join(ids, ","), SeverityWarning,
+			fmt.Sprintf("The contacts %s share the same %s (%s)", strings.Join(names, ", "), group.Reason, group.Value),
+			fmt.Sprintf("Merge the contacts into %s, which has most domains", target.Name))
+		for _, contact := range group.Contacts {
+			finding.DomainIds = append(finding.DomainIds, domainIds[contact.ID]...)
+		}
+		finding.Fix = &QualityFix{Action: "merge-contacts", Params: map[string]string{"targetId": target.ID.Hex(), "contactIds": strings.Join(others, ",")}}
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
+
+// checkExpiredActive reports the active domains whose registration has expired
+func checkExpiredActive(domains *[]Domain) ([]Finding, error) {
+	findings := []Finding{}
+	now := time.Now().Unix()
+	for _, domain := range *domains {
+		if lifecycleStatus(domain.Lifecycle.Status) != LifecycleActive || domain.ExpiresAt == 0 || domain.ExpiresAt > now {
+			continue
+		}
+		finding := NewFinding("expired-active", domain.ID.Hex(), SeverityInfo,
+			fmt.Sprintf("The domain %s is active but its registration expired on %s", domain.Name, time.Unix(domain.ExpiresAt, 0).Format("2006-01-02")),
+			"Refresh the registry data if the domain has been renewed, otherwise move it to expired")
+		finding.DomainIds = append(finding.DomainIds, domain.ID)
+		finding.Fix = &QualityFix{Action: "lifecycle", Params: map[string]string{"domainId": domain.ID.Hex(), "status": LifecycleExpired}}
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
+
+// fixRegistrant sets the registrant of a domain which has none
+func fixRegistrant(fix *QualityFix, by string) error {
+	domainId, err := primitive.ObjectIDFromHex(fix.Params["domainId"])
+	if err != nil {
+		return err
+	}
+	registrantId, err := primitive.ObjectIDFromHex(fix.Params["registrantId"])
+	if err != nil {
+		return err
+	}
+	res, err := updateDomain(
+		bson.M{"_id": domainId, "registrantid": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"registrantid": registrantId, "updated": time.Now().Unix()}})
+	if err != nil {
+		return err
+	}
+	if res.ModifiedCount == 0 {
+		return errors.New("The domain registrant has been set meanwhile")
+	}
+	return nil
+}
+
+// fixAddressServer links the address of a domain to the server claiming its ip, if still linked to the same server
+func fixAddressServer(fix *QualityFix, by string) error {
+	domainId, err := primitive.ObjectIDFromHex(fix.Params["domainId"])
+	if err != nil {
+		return err
+	}
+	fromServerId, err := primitive.ObjectIDFromHex(fix.Params["fromServerId"])
+	if err != nil {
+		return err
+	}
+	serverId, err := primitive.ObjectIDFromHex(fix.Params["serverId"])
+	if err != nil {
+		return err
+	}
+	res, err := updateDomain(
+		bson.M{"_id": domainId, "addresses": bson.M{"$elemMatch": bson.M{"ip": fix.Params["ip"], "serverid": fromServerId}}},
+		bson.M{"$set": bson.M{"addresses.$.serverid": serverId, "updated": time.Now().Unix()}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("The domain address has been changed meanwhile")
+	}
+	return nil
+}
+
+// fixMergeContacts merges the duplicate contacts into the target one
+func fixMergeContacts(fix *QualityFix, by string) error {
+	contactService := new(contacts.ContactService)
+	target, err := contactService.GetById(fix.Params["targetId"])
+	if err != nil {
+		return err
+	}
+	others := []contacts.Contact{}
+	for _, id := range strings.Split(fix.Params["contactIds"], ",") {
+		contact, err := contactService.GetById(id)
+		if err != nil {
+			return err
+		}
+		others = append(others, *contact)
+	}
+	_, err = contactService.Merge(target, &others)
+	return err
+}
+
+// fixLifecycle moves a domain to the given lifecycle status
+func fixLifecycle(fix *QualityFix, by string) error {
+	domain, err := new(DomainService).GetById(fix.Params["domainId"])
+	if err != nil {
+		return err
+	}
+	return Transition(domain, fix.Params["status"], "Data quality fix", by)
+}
+
+func init() {
+	RegisterQualityCheck(QualityCheck{Name: "missing-owner", Description: "Domains without owner", Run: checkMissingOwner})
+	RegisterQualityCheck(QualityCheck{Name: "missing-registrant", Description: "Domains without registrant", Run: checkMissingRegistrant})
+	RegisterQualityCheck(QualityCheck{Name: "unclaimed-ip", Description: "Domain addresses whose ip is not claimed by their server, or by any server", Run: checkAddressServers})
+	RegisterQualityCheck(QualityCheck{Name: "duplicate-owners", Description: "Near-duplicate contacts owning or registering domains", Run: checkDuplicateOwners})
+	RegisterQualityCheck(QualityCheck{Name: "expired-active", Description: "Active domains whose registration has expired", Run: checkExpiredActive})
+	RegisterQualityFixer("set-registrant", fixRegistrant)
+	RegisterQualityFixer("set-address-server", fixAddressServer)
+	RegisterQualityFixer("merge-contacts", fixMergeContacts)
+	RegisterQualityFixer("lifecycle", fixLifecycle)
+}
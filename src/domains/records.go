@@ -0,0 +1,423 @@
+package domains
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"net"
+	"regexp"
+	"strings"
+	database "systems-management-api/core/database"
+	"systems-management-api/core/utils"
+	"time"
+)
+
+// Record types which can be managed
+var RecordTypes = []string{"A", "AAAA", "CNAME", "MX", "TXT", "SRV", "CAA"}
+
+// Record a DNS resource record of the domain zone
+// Name is relative to the zone, @ being the zone apex. Value holds the address, target host, text or CAA value,
+// Priority is used by MX and SRV records, Weight and Port by SRV records, Flags and Tag by CAA records
+type Record struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty"`
+	DomainId primitive.ObjectID `json:"domainId"`
+	Name     string             `json:"name"`
+	Type     string             `json:"type"`
+	Ttl      uint32             `json:"ttl"`
+	Value    string             `json:"value"`
+	Priority uint16             `json:"priority"`
+	Weight   uint16             `json:"weight"`
+	Port     uint16             `json:"port"`
+	Flags    uint8              `json:"flags"`
+	Tag      string             `json:"tag"`
+	Created  int64              `json:"created"`
+	Updated  int64              `json:"updated"`
+}
+
+// ZoneInfo the state of the domain DNS zone
+type ZoneInfo struct {
+	Serial    uint32 `json:"serial"`
+	ChangedAt int64  `json:"changedAt"`
+}
+
+// nextSerial returns the zone serial following the current one, in the YYYYMMDDnn format
+// The serial never decreases, if more than 99 changes happen in a day the following day is used
+func nextSerial(current uint32, now time.Time) uint32 {
+	today := uint32(now.Year()*1000000 + int(now.Month())*10000 + now.Day()*100)
+	if current < today {
+		return today
+	}
+	return current + 1
+}
+
+// recordLabel a label of a record name, underscores are allowed for service records (SRV, DKIM, ...)
+var recordLabel = regexp.MustCompile(`^[a-z0-9_]([a-z0-9_-]{0,61}[a-z0-9])?$`)
+
+// defaultRecordTtl returns the TTL of the records created without one
+func defaultRecordTtl() uint32 {
+	ttl := viper.GetInt("dns.records.defaultTtl")
+	if ttl <= 0 {
+		ttl = 3600
+	}
+	return uint32(ttl)
+}
+
+// recordName returns the name of a record relative to the zone, @ for the zone apex
+// Names ending with a dot are absolute and must belong to the zone, other names are relative to the zone
+func recordName(name string, zone string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || name == "@" || name == zone+"." {
+		return "@", nil
+	}
+	if strings.HasSuffix(name, ".") {
+		if !strings.HasSuffix(name, "."+zone+".") {
+			return "", fmt.Errorf("Record name %s is outside of the zone %s", name, zone)
+		}
+		name = strings.TrimSuffix(name, "."+zone+".")
+	}
+	for i, label := range strings.Split(name, ".") {
+		if label == "*" && i == 0 {
+			continue
+		}
+		if !recordLabel.MatchString(label) {
+			return "", fmt.Errorf("Invalid record name %s", name)
+		}
+	}
+	return name, nil
+}
+
+// recordTarget returns the fully qualified host name a CNAME, MX or SRV record points to, without trailing dot
+// A single dot (the root) means no target, i.e. for null MX and SRV records
+func recordTarget(target string) (string, error) {
+	target = strings.ToLower(strings.TrimSpace(target))
+	if target == "." {
+		return target, nil
+	}
+	target = strings.TrimSuffix(target, ".")
+	labels := strings.Split(target, ".")
+	if len(labels) < 2 {
+		return "", fmt.Errorf("Invalid target %s: a fully qualified host name is required", target)
+	}
+	for _, label := range labels {
+		if !recordLabel.MatchString(label) {
+			return "", fmt.Errorf("Invalid target %s", target)
+		}
+	}
+	return target, nil
+}
+
+// Normalize validates the record according to its type and normalizes its name and value
+func (self *Record) Normalize(zone string) error {
+	name, err := recordName(self.Name, zone)
+	if err != nil {
+		return err
+	}
+	self.Name = name
+	self.Type = strings.ToUpper(self.Type)
+	if self.Ttl == 0 {
+		self.Ttl = defaultRecordTtl()
+	}
+	if self.Ttl < 60 || self.Ttl > 604800 {
+		return fmt.Errorf("Invalid TTL %d, must be between 60 and 604800 seconds", self.Ttl)
+	}
+
+	switch self.Type {
+	case "A", "AAAA":
+		ip := net.ParseIP(strings.TrimSpace(self.Value))
+		if ip == nil || (self.Type == "A") != (ip.To4() != nil) {
+			return fmt.Errorf("Invalid %s record value %s", self.Type, self.Value)
+		}
+		self.Value = ip.String()
+	case "CNAME":
+		if self.Name == "@" {
+			return fmt.Errorf("A CNAME record cannot be defined at the zone apex")
+		}
+		target, err := recordTarget(self.Value)
+		if err != nil || target == "." {
+			return fmt.Errorf("Invalid CNAME record value %s", self.Value)
+		}
+		self.Value = target
+	case "MX":
+		if self.Value, err = recordTarget(self.Value); err != nil {
+			return err
+		}
+	case "TXT":
+		if self.Value == "" || len(self.Value) > 4000 {
+			return fmt.Errorf("TXT record value must be between 1 and 4000 characters")
+		}
+		for _, r := range self.Value {
+			if r < 0x20 || r > 0x7e {
+				return fmt.Errorf("TXT record value must contain printable ASCII characters only")
+			}
+		}
+	case "SRV":
+		labels := strings.Split(self.Name, ".")
+		if len(labels) < 2 || !strings.HasPrefix(labels[0], "_") || !strings.HasPrefix(labels[1], "_") {
+			return fmt.Errorf("Invalid SRV record name %s, expected _service._protocol[.name]", self.Name)
+		}
+		if self.Port == 0 && self.Value != "." {
+			return fmt.Errorf("SRV record port is required")
+		}
+		if self.Value, err = recordTarget(self.Value); err != nil {
+			return err
+		}
+	case "CAA":
+		if self.Flags != 0 && self.Flags != 128 {
+			return fmt.Errorf("Invalid CAA record flags %d, expected 0 or 128", self.Flags)
+		}
+		self.Tag = strings.ToLower(self.Tag)
+		if !utils.Contains([]string{"issue", "issuewild", "iodef"}, self.Tag) {
+			return fmt.Errorf("Invalid CAA record tag %s, expected issue, issuewild or iodef", self.Tag)
+		}
+		if self.Value == "" || strings.ContainsAny(self.Value, "\"\n") {
+			return fmt.Errorf("Invalid CAA record value %s", self.Value)
+		}
+	default:
+		return fmt.Errorf("Unsupported record type %s", self.Type)
+	}
+
+	// fields which do not apply to the record type are cleared
+	if self.Type != "MX" && self.Type != "SRV" {
+		self.Priority = 0
+	}
+	if self.Type != "SRV" {
+		self.Weight, self.Port = 0, 0
+	}
+	if self.Type != "CAA" {
+		self.Flags, self.Tag = 0, ""
+	}
+	return nil
+}
+
+// cnameConflict checks that a CNAME record is the only record of its name (RFC 1034)
+// The record itself, if already stored, is ignored among the others
+func cnameConflict(record *Record, others []Record) error {
+	for _, other := range others {
+		if other.Name != record.Name || (!record.ID.IsZero() && other.ID == record.ID) {
+			continue
+		}
+		if record.Type == "CNAME" || other.Type == "CNAME" {
+			return fmt.Errorf("A CNAME record cannot coexist with other records named %s", record.Name)
+		}
+	}
+	return nil
+}
+
+// sameRecord tells whether two records have the same data, ttl and timestamps excluded
+func sameRecord(a *Record, b *Record) bool {
+	return a.Name == b.Name && a.Type == b.Type && a.Value == b.Value && a.Priority == b.Priority &&
+		a.Weight == b.Weight && a.Port == b.Port && a.Flags == b.Flags && a.Tag == b.Tag
+}
+
+// newRecords returns the records which are not identical to one of the existing records, and the identical ones
+func newRecords(records []Record, existing []Record) ([]Record, []Record) {
+	res := []Record{}
+	duplicates := []Record{}
+	for _, record := range records {
+		duplicate := false
+		for _, other := range existing {
+			duplicate = duplicate || sameRecord(&record, &other)
+		}
+		if duplicate {
+			duplicates = append(duplicates, record)
+		} else {
+			res = append(res, record)
+		}
+	}
+	return res, duplicates
+}
+
+// ensureRecordIndexes creates the DNS records collection indexes
+func ensureRecordIndexes() {
+	db := database.DB()
+	err := db.EnsureIndexes("record", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "domainid", Value: 1}, {Key: "name", Value: 1}, {Key: "type", Value: 1}}, Options: options.Index().SetName("domain_name_type")},
+	})
+	if err != nil {
+		zap.S().Error("Error creating record indexes: ", err)
+	}
+}
+
+// RecordService service which provides methods to access and modify the domains DNS records
+type RecordService struct{}
+
+// Retrieves all DNS records of the domain, sorted by name and type
+func (service *RecordService) all(domain *Domain) (*[]Record, error) {
+	return service.find(bson.M{"domainid": domain.ID})
+}
+
+// Retrieves the DNS records of the domain having the given name
+func (service *RecordService) byName(domain *Domain, name string) (*[]Record, error) {
+	return service.find(bson.M{"domainid": domain.ID, "name": name})
+}
+
+// Retrieves all DNS records matching the given filter, sorted by name and type
+func (service *RecordService) find(filter interface{}) (*[]Record, error) {
+	db := database.DB()
+	collection := db.D.Collection("record")
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "type", Value: 1}, {Key: "priority", Value: 1}})
+	cursor, err := collection.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	records := []Record{}
+	if err := cursor.All(context.TODO(), &records); err != nil {
+		return nil, err
+	}
+	return &records, nil
+}
+
+// Retrieves a DNS record of the domain given its ID
+func (service *RecordService) GetById(domain *Domain, id string) (*Record, error) {
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	db := database.DB()
+	collection := db.D.Collection("record")
+	record := Record{}
+
+	if err := collection.FindOne(context.TODO(), bson.M{"_id": objId, "domainid": domain.ID}).Decode(&record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Saves the DNS record to database and increments the zone serial of the domain
+// Returns boolean result and error
+func (service *RecordService) Save(domain *Domain, record *Record) (bool, error) {
+	db := database.DB()
+	collection := db.D.Collection("record")
+
+	if record.ID.IsZero() {
+		res, err := collection.InsertOne(context.TODO(), record)
+		if err != nil {
+			zap.S().Error("Error inserting record: ", err)
+			return false, err
+		}
+		record.ID = res.InsertedID.(primitive.ObjectID)
+	} else {
+		if _, err := collection.ReplaceOne(context.TODO(), bson.M{"_id": record.ID}, record); err != nil {
+			zap.S().Error("Error updating record: ", err)
+			return false, err
+		}
+	}
+	zap.S().Info(fmt.Sprintf("Record %s %s of domain %s saved succesfully", record.Name, record.Type, domain.Name))
+	return true, service.bumpSerial(domain, 0)
+}
+
+// Deletes the DNS record from database and increments the zone serial of the domain
+// Returns boolean result and error
+func (service *RecordService) Delete(domain *Domain, record *Record) (bool, error) {
+	db := database.DB()
+	collection := db.D.Collection("record")
+
+	if _, err := collection.DeleteOne(context.TODO(), bson.M{"_id": record.ID}); err != nil {
+		zap.S().Error("Error deleting record: ", err)
+		return false, err
+	}
+	zap.S().Info(fmt.Sprintf("Record %s %s of domain %s deleted succesfully", record.Name, record.Type, domain.Name))
+	return true, service.bumpSerial(domain, 0)
+}
+
+// Import stores the given DNS records of the domain, replacing the existing ones if requested
+// The zone serial is incremented once, starting from the imported serial if greater than the current one
+func (service *RecordService) Import(domain *Domain, records []Record, replace bool, serial uint32) error {
+	db := database.DB()
+	collection := db.D.Collection("record")
+
+	now := time.Now().Unix()
+	// IDs are set beforehand, so that the records inserted before a failure can be removed
+	inserted := []primitive.ObjectID{}
+	if len(records) > 0 {
+		docs := make([]interface{}, 0, len(records))
+		for _, record := range records {
+			record.ID = primitive.NewObjectID()
+			record.Created, record.Updated = now, now
+			docs = append(docs, record)
+			inserted = append(inserted, record.ID)
+		}
+		if _, err := collection.InsertMany(context.TODO(), docs); err != nil {
+			zap.S().Error("Error importing records: ", err)
+			if _, cleanErr := collection.DeleteMany(context.TODO(), bson.M{"_id": bson.M{"$in": inserted}}); cleanErr != nil {
+				zap.S().Error("Error removing the records of a failed import: ", cleanErr)
+			}
+			return err
+		}
+	}
+	// the replaced records are deleted once the new ones are stored, so that a failed import leaves the zone unchanged
+	if replace {
+		_, err := collection.DeleteMany(context.TODO(), bson.M{"domainid": domain.ID, "_id": bson.M{"$nin": inserted}})
+		if err != nil {
+			zap.S().Error("Error deleting replaced records: ", err)
+			return err
+		}
+	}
+	zap.S().Info(fmt.Sprintf("Imported %d records of domain %s", len(records), domain.Name))
+	return service.bumpSerial(domain, serial)
+}
+
+// Deletes all the DNS records of the given domains
+// Returns the number of deleted records and error
+func (service *RecordService) DeleteByDomains(ids []primitive.ObjectID) (int64, error) {
+	db := database.DB()
+	collection := db.D.Collection("record")
+
+	res, err := collection.DeleteMany(context.TODO(), bson.M{"domainid": bson.M{"$in": ids}})
+	if err != nil {
+		zap.S().Error("Error deleting records: ", err)
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// maxSerialRetries the number of attempts to increment a zone serial changed concurrently
+const maxSerialRetries = 5
+
+// ErrSerialChanged error returned when the zone serial keeps changing while being incremented
+var ErrSerialChanged = errors.New("The zone serial has changed meanwhile, retry")
+
+// bumpSerial stores the next zone serial of the domain, starting from the given one if greater than the current
+// The serial is only replaced if it didn't change meanwhile, otherwise it is read again and incremented from its new value
+func (service *RecordService) bumpSerial(domain *Domain, from uint32) error {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+
+	stored := domain.Zone.Serial
+	for attempt := 0; attempt < maxSerialRetries; attempt++ {
+		current := stored
+		if from > current {
+			current = from
+		}
+		now := time.Now()
+		zone := ZoneInfo{Serial: nextSerial(current, now), ChangedAt: now.Unix()}
+		var serial interface{} = stored
+		if stored == 0 {
+			serial = bson.M{"$in": []interface{}{nil, 0}}
+		}
+		res, err := collection.UpdateOne(context.TODO(), bson.M{"_id": domain.ID, "zone.serial": serial}, bson.M{"$set": bson.M{"zone": zone}})
+		if err != nil {
+			zap.S().Error("Error updating domain zone serial: ", err)
+			return err
+		}
+		if res.MatchedCount > 0 {
+			domain.Zone = zone
+			return nil
+		}
+
+		var latest struct {
+			Zone ZoneInfo `bson:"zone"`
+		}
+		if err := collection.FindOne(context.TODO(), bson.M{"_id": domain.ID}).Decode(&latest); err != nil {
+			return err
+		}
+		stored = latest.Zone.Serial
+	}
+	return ErrSerialChanged
+}
@@ -0,0 +1,26 @@
+package domains
+
+import (
+	"testing"
+)
+
+func TestNewRecords(t *testing.T) {
+	existing := []Record{
+		{Name: "@", Type: "A", Ttl: 3600, Value: "192.0.2.1"},
+		{Name: "@", Type: "MX", Ttl: 3600, Value: "mail.example.com.", Priority: 10},
+	}
+	records := []Record{
+		{Name: "@", Type: "A", Ttl: 300, Value: "192.0.2.1"},
+		{Name: "@", Type: "A", Ttl: 3600, Value: "192.0.2.2"},
+		{Name: "@", Type: "MX", Ttl: 3600, Value: "mail.example.com.", Priority: 20},
+		{Name: "@", Type: "MX", Ttl: 3600, Value: "mail.example.com.", Priority: 10},
+	}
+
+	res, duplicates := newRecords(records, existing)
+	if len(res) != 2 || res[0].Value != "192.0.2.2" || res[1].Priority != 20 {
+		t.Fatalf("unexpected new records %+v", res)
+	}
+	if len(duplicates) != 2 {
+		t.Fatalf("expected the identical records to be skipped, got %+v", duplicates)
+	}
+}
@@ -0,0 +1,74 @@
+package domains
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	database "systems-management-api/core/database"
+	"systems-management-api/core/references"
+	"systems-management-api/customfields"
+)
+
+// countServerReferences counts the domains referencing the given server, trashed domains included
+func countServerReferences(serverId primitive.ObjectID) (int64, error) {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+	return collection.CountDocuments(context.TODO(), serverQuery(serverId))
+}
+
+// countPackageReferences counts the domains referencing the given hosting package, trashed domains included
+func countPackageReferences(packageId primitive.ObjectID) (int64, error) {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+	return collection.CountDocuments(context.TODO(), bson.M{"packageid": packageId})
+}
+
+// countContactReferences counts the domains owned or registered by the given contact, trashed domains included
+func countContactReferences(contactId primitive.ObjectID) (int64, error) {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+	return collection.CountDocuments(context.TODO(), contactQuery(contactId))
+}
+
+// countFieldReferences counts the domains having a value for the given custom field, trashed domains included
+func countFieldReferences(fieldId primitive.ObjectID) (int64, error) {
+	fieldService := new(customfields.FieldService)
+	field, err := fieldService.GetById(fieldId.Hex())
+	if err != nil {
+		return 0, err
+	}
+	db := database.DB()
+	collection := db.D.Collection("domain")
+	return collection.CountDocuments(context.TODO(), bson.M{"customfields." + field.Name: bson.M{"$exists": true}})
+}
+
+// countManagerReferences counts the domains managed by the given user, trashed domains included
+func countManagerReferences(userId primitive.ObjectID) (int64, error) {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+	return collection.CountDocuments(context.TODO(), bson.M{"managers": userId})
+}
+
+// replaceContactReferences moves the ownership and registration of the domains from the given contacts to another one
+func replaceContactReferences(from []primitive.ObjectID, to primitive.ObjectID) (int64, error) {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+	var count int64
+	for _, field := range []string{"ownerid", "registrantid"} {
+		res, err := collection.UpdateMany(context.TODO(), bson.M{field: bson.M{"$in": from}}, bson.M{"$set": bson.M{field: to}})
+		if err != nil {
+			return count, err
+		}
+		count += res.ModifiedCount
+	}
+	return count, nil
+}
+
+func init() {
+	references.Register("server", "domain", countServerReferences)
+	references.Register("package", "domain", countPackageReferences)
+	references.Register("contact", "domain", countContactReferences)
+	references.Register("customfield", "domain", countFieldReferences)
+	references.Register("user", "domain", countManagerReferences)
+	references.RegisterReplacer("contact", "domain", replaceContactReferences)
+}
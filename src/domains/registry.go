@@ -0,0 +1,92 @@
+package domains
+
+import (
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+	"systems-management-api/core/registration"
+	"time"
+)
+
+// RefreshRegistry looks up the domain registration data and stores it in the domain registry info
+// If apply is true the stored registrar and registration dates are overwritten with the published ones
+func RefreshRegistry(domain *Domain, client *registration.Client, apply bool) error {
+	info := RegistryInfo{CheckedAt: time.Now().Unix(), Statuses: []string{}, Nameservers: []string{}}
+	res, err := client.Lookup(domain.Name)
+	if err != nil {
+		zap.S().Infow("Registry lookup failed", "domain", domain.Name, "error", err)
+		info.Error = err.Error()
+	} else {
+		info.Registrar = res.Registrar
+		info.Statuses = res.Statuses
+		info.Nameservers = res.Nameservers
+		info.RegisteredAt = res.RegisteredAt
+		info.ExpiresAt = res.ExpiresAt
+		info.Source = res.Source
+	}
+
+	domainService := new(DomainService)
+	if !apply || info.Error != "" {
+		return domainService.SetRegistry(domain, info)
+	}
+
+	return domainService.ApplyRegistry(domain, applyRegistry(domain, info, time.Now().Unix()))
+}
+
+// applyRegistry overwrites the domain registrar and registration dates which differ from the registry data
+// Returns the update of the registry owned fields, only those are written since the domain may have been edited meanwhile
+func applyRegistry(domain *Domain, info RegistryInfo, now int64) bson.M {
+	domain.Registry = info
+	set := bson.M{"registry": info}
+	for _, discrepancy := range domain.RegistryDiscrepancies() {
+		switch discrepancy.Field {
+		case "registrar":
+			domain.Registrar = info.Registrar
+			set["registrar"] = info.Registrar
+		case "registeredAt":
+			domain.RegisteredAt = info.RegisteredAt
+			set["registeredat"] = info.RegisteredAt
+		case "expiresAt":
+			domain.ExpiresAt = info.ExpiresAt
+			// reminders are sent again for the new expiry date
+			domain.ExpiryReminders = []int{}
+			set["expiresat"] = info.ExpiresAt
+			set["expiryreminders"] = domain.ExpiryReminders
+		}
+	}
+	if len(set) > 1 {
+		domain.Updated = now
+		set["updated"] = now
+	}
+	return set
+}
+
+// registrySyncInterval returns how often the registry data of all the domains is refreshed
+func registrySyncInterval() time.Duration {
+	hours := viper.GetInt("registration.sync.intervalHours")
+	if hours <= 0 {
+		hours = 24
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// syncRegistry refreshes the registry data of all the domains, a delay between lookups
+// avoids hitting the registries rate limits
+func syncRegistry() {
+	domainService := new(DomainService)
+	domains, err := domainService.all()
+	if err != nil {
+		zap.S().Error("Error while getting all domains, Reason: ", err)
+		return
+	}
+
+	client := registration.NewClient()
+	apply := viper.GetBool("registration.sync.apply")
+	delay := time.Duration(viper.GetInt("registration.sync.delayMilliseconds")) * time.Millisecond
+	for i := range *domains {
+		if err := RefreshRegistry(&(*domains)[i], client, apply); err != nil {
+			zap.S().Errorw("Error while refreshing domain registry data", "domain", (*domains)[i].Name, "error", err)
+		}
+		time.Sleep(delay)
+	}
+}
@@ -0,0 +1,39 @@
+package domains
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyRegistry(t *testing.T) {
+	expires := time.Date(2030, 8, 13, 0, 0, 0, 0, time.UTC).Unix()
+	domain := Domain{
+		Name:            "example.com",
+		Registrar:       "Example Registrar",
+		ExpiresAt:       time.Date(2029, 8, 13, 0, 0, 0, 0, time.UTC).Unix(),
+		ExpiryReminders: []int{30},
+		Tags:            []string{"edited"},
+	}
+	info := RegistryInfo{Registrar: "example registrar", ExpiresAt: expires, CheckedAt: 1000, Statuses: []string{}, Nameservers: []string{}}
+
+	set := applyRegistry(&domain, info, 2000)
+	if set["expiresat"] != expires || set["updated"] != int64(2000) || len(domain.ExpiryReminders) != 0 {
+		t.Fatalf("expected the expiry date to be updated, got %v", set)
+	}
+	if _, ok := set["registrar"]; ok {
+		t.Fatal("the registrar only differs in case and must not be updated")
+	}
+	for key := range set {
+		switch key {
+		case "registry", "registrar", "registeredat", "expiresat", "expiryreminders", "updated":
+		default:
+			t.Fatalf("only the registry owned fields must be written, got %s", key)
+		}
+	}
+
+	// without discrepancies only the registry data is stored
+	set = applyRegistry(&domain, info, 3000)
+	if len(set) != 1 || domain.Updated != 2000 {
+		t.Fatalf("unexpected update %v", set)
+	}
+}
@@ -11,4 +11,76 @@ func RoutesRegister(router *gin.RouterGroup) {
 	router.POST("", CreateDomainView)
 	router.PUT("/:id", UpdateDomainView)
 	router.DELETE("/:id", DeleteDomainView)
+	router.POST("/:id/managers", AddManagerView)
+	router.DELETE("/:id/managers/:userId", RemoveManagerView)
+	router.POST("/:id/lifecycle", TransitionView)
+	router.POST("/:id/registry/refresh", RefreshRegistryView)
+	router.POST("/:id/dns/check", CheckDnsView)
+	router.POST("/:id/certificate/check", CheckCertificateView)
+	router.POST("/:id/health/check", CheckHealthView)
+	router.GET("/:id/health/history", HealthHistoryView)
+	router.GET("/:id/records", RecordListView)
+	router.GET("/:id/records/:recordId", RecordDetailView)
+	router.POST("/:id/records", CreateRecordView)
+	router.PUT("/:id/records/:recordId", UpdateRecordView)
+	router.DELETE("/:id/records/:recordId", DeleteRecordView)
+	router.GET("/:id/comments", CommentListView)
+	router.POST("/:id/comments", CreateCommentView)
+	router.PUT("/:id/comments/:commentId", UpdateCommentView)
+	router.DELETE("/:id/comments/:commentId", DeleteCommentView)
+	router.POST("/:id/comments/:commentId/pin", PinCommentView)
+	router.DELETE("/:id/comments/:commentId/pin", UnpinCommentView)
+	router.GET("/:id/attachments", AttachmentListView)
+	router.POST("/:id/attachments", UploadAttachmentView)
+	router.GET("/:id/attachments/:attachmentId", DownloadAttachmentView)
+	router.DELETE("/:id/attachments/:attachmentId", DeleteAttachmentView)
+	router.GET("/:id/zone", ExportZoneView)
+	router.POST("/:id/zone", ImportZoneView)
+}
+
+// TrashRoutesRegister attaches trash routes (path + view) to the given gin router group (paths namespace)
+func TrashRoutesRegister(router *gin.RouterGroup) {
+	router.GET("", TrashedDomainListView)
+	router.POST("/:id/restore", RestoreDomainView)
+	router.DELETE("/:id", PurgeDomainView)
+}
+
+// ServerRoutesRegister attaches server related routes (path + view) to the given gin router group (paths namespace)
+func ServerRoutesRegister(router *gin.RouterGroup) {
+	router.GET("/:id/domains", ServerDomainListView)
+}
+
+// ContactRoutesRegister attaches contact related routes (path + view) to the given gin router group (paths namespace)
+func ContactRoutesRegister(router *gin.RouterGroup) {
+	router.GET("/:id/domains", ContactDomainListView)
+}
+
+// ReportRoutesRegister attaches reports routes (path + view) to the given gin router group (paths namespace)
+func ReportRoutesRegister(router *gin.RouterGroup) {
+	router.GET("/package", PackageReportView)
+	router.GET("/registry", RegistryReportView)
+	router.GET("/billing", BillingReportView)
+	router.GET("/quality", QualityReportView)
+	router.POST("/quality/fix", QualityFixView)
+}
+
+// CalendarRoutesRegister attaches calendar feed routes (path + view) to the given gin router group (paths namespace)
+func CalendarRoutesRegister(router *gin.RouterGroup) {
+	router.GET("/domain.ics", CalendarFeedView)
+}
+
+// ImportRoutesRegister attaches import routes (path + view) to the given gin router group (paths namespace)
+func ImportRoutesRegister(router *gin.RouterGroup) {
+	router.POST("", ImportDomainsView)
+}
+
+// BulkRoutesRegister attaches bulk operations routes (path + view) to the given gin router group (paths namespace)
+func BulkRoutesRegister(router *gin.RouterGroup) {
+	router.POST("", BulkDomainsView)
+}
+
+// ExportRoutesRegister attaches export routes (path + view) to the given gin router group (paths namespace)
+func ExportRoutesRegister(router *gin.RouterGroup) {
+	router.GET("/domain", ExportDomainsView)
+	router.GET("/billing", ExportBillingView)
 }
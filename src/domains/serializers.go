@@ -1,44 +1,451 @@
 package domains
 
-type domainSerializer struct{}
+import (
+	"encoding/json"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math"
+	"reflect"
+	"systems-management-api/auth"
+	"systems-management-api/packages"
+)
 
-type DomainData struct {
-	ID         string `json:"id"`
-	Name       string `json:"name"`
-	Owner      string `json:"owner"`
-	Registrant string `json:"registrant"`
-	LoginInfo  string `json:"loginInfo"`
-	Package    string `json:"package"`
-	Mx         bool   `json:"mx"`
-	Ip         string `json:"ip"`
+type domainSerializer struct {
+	policy FieldPolicy
+}
+
+type AddressData struct {
+	Ip       string `json:"ip"`
+	Version  string `json:"version"`
+	Purpose  string `json:"purpose"`
+	ServerId string `json:"serverId"`
+}
+
+type RegistryData struct {
+	Registrar    string   `json:"registrar"`
+	Statuses     []string `json:"statuses"`
+	Nameservers  []string `json:"nameservers"`
+	RegisteredAt int64    `json:"registeredAt"`
+	ExpiresAt    int64    `json:"expiresAt"`
+	Source       string   `json:"source"`
+	CheckedAt    int64    `json:"checkedAt"`
+	Error        string   `json:"error"`
+}
+
+type MxData struct {
+	Host string `json:"host"`
+	Pref int    `json:"pref"`
+}
+
+type DnsData struct {
+	A          []string `json:"a"`
+	AAAA       []string `json:"aaaa"`
+	Mx         []MxData `json:"mx"`
+	Ns         []string `json:"ns"`
+	Mismatches []string `json:"mismatches"`
+	CheckedAt  int64    `json:"checkedAt"`
+	Error      string   `json:"error"`
+}
+
+type TlsData struct {
 	ServerName string `json:"serverName"`
-	Notes      string `json:"notes"`
-	Created    int64  `json:"created"`
-	Updated    int64  `json:"updated"`
+	Ip         string `json:"ip"`
+	Port       int    `json:"port"`
+}
+
+type CertificateData struct {
+	Subject    string   `json:"subject"`
+	Issuer     string   `json:"issuer"`
+	Sans       []string `json:"sans"`
+	NotBefore  int64    `json:"notBefore"`
+	NotAfter   int64    `json:"notAfter"`
+	ChainError string   `json:"chainError"`
+	CheckedAt  int64    `json:"checkedAt"`
+	Error      string   `json:"error"`
+}
+
+type LifecycleData struct {
+	Status    string                    `json:"status"`
+	ChangedAt int64                     `json:"changedAt"`
+	History   []LifecycleTransitionData `json:"history"`
+}
+
+type LifecycleTransitionData struct {
+	From   string `json:"from,omitempty"`
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+	By     string `json:"by"`
+	At     int64  `json:"at"`
+}
+
+type LineItemData struct {
+	Kind        string  `json:"kind"`
+	Description string  `json:"description"`
+	Cost        float64 `json:"cost"`
+	Price       float64 `json:"price"`
+	Currency    string  `json:"currency"`
+	Period      string  `json:"period"`
+	Start       int64   `json:"start"`
+	End         int64   `json:"end"`
+}
+
+type BillingInvoiceData struct {
+	OwnerId string             `json:"ownerId"`
+	Owner   string             `json:"owner"`
+	From    int64              `json:"from"`
+	To      int64              `json:"to"`
+	Lines   []BillingLineData  `json:"lines"`
+	Totals  []BillingTotalData `json:"totals"`
+}
+
+type BillingLineData struct {
+	DomainId    string  `json:"domainId"`
+	Domain      string  `json:"domain"`
+	Kind        string  `json:"kind"`
+	Description string  `json:"description"`
+	Currency    string  `json:"currency"`
+	Period      string  `json:"period"`
+	Charges     int     `json:"charges"`
+	UnitCost    float64 `json:"unitCost"`
+	UnitPrice   float64 `json:"unitPrice"`
+	Cost        float64 `json:"cost"`
+	Price       float64 `json:"price"`
+	Margin      float64 `json:"margin"`
+}
+
+type BillingTotalData struct {
+	Currency string  `json:"currency"`
+	Cost     float64 `json:"cost"`
+	Price    float64 `json:"price"`
+	Margin   float64 `json:"margin"`
+}
+
+type QualityReportData struct {
+	Checks   []QualityCheckData `json:"checks"`
+	Summary  map[string]int     `json:"summary"` // number of findings per severity
+	Findings []FindingData      `json:"findings"`
+}
+
+type QualityCheckData struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type FindingData struct {
+	ID         string          `json:"id"`
+	Check      string          `json:"check"`
+	Severity   string          `json:"severity"`
+	Message    string          `json:"message"`
+	DomainIds  []string        `json:"domainIds"`
+	Suggestion string          `json:"suggestion"`
+	Fix        *QualityFixData `json:"fix"`
+}
+
+type QualityFixData struct {
+	Action string            `json:"action"`
+	Params map[string]string `json:"params"`
+}
+
+type FixResultData struct {
+	FindingId string `json:"findingId"`
+	Check     string `json:"check"`
+	Action    string `json:"action"`
+	Applied   bool   `json:"applied"`
+	Error     string `json:"error"`
+}
+
+type BulkReportData struct {
+	DryRun    bool                 `json:"dryRun"`
+	Matched   int                  `json:"matched"`
+	Updated   int                  `json:"updated"`
+	Deleted   int                  `json:"deleted"`
+	Unchanged int                  `json:"unchanged"`
+	NotFound  int                  `json:"notFound"`
+	Failed    int                  `json:"failed"`
+	Items     []BulkItemResultData `json:"items"`
+}
+
+type BulkItemResultData struct {
+	DomainId string   `json:"domainId"`
+	Name     string   `json:"name"`
+	Status   string   `json:"status"`
+	Changes  []string `json:"changes"`
+	Error    string   `json:"error"`
+}
+
+type ZoneData struct {
+	Serial    uint32 `json:"serial"`
+	ChangedAt int64  `json:"changedAt"`
+}
+
+type RecordData struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Ttl      uint32 `json:"ttl"`
+	Value    string `json:"value"`
+	Priority uint16 `json:"priority,omitempty"`
+	Weight   uint16 `json:"weight,omitempty"`
+	Port     uint16 `json:"port,omitempty"`
+	Flags    uint8  `json:"flags,omitempty"`
+	Tag      string `json:"tag,omitempty"`
+	Created  int64  `json:"created"`
+	Updated  int64  `json:"updated"`
+}
+
+type CommentData struct {
+	ID       string                `json:"id"`
+	AuthorId string                `json:"authorId,omitempty"`
+	Author   string                `json:"author"`
+	Body     string                `json:"body"`
+	Pinned   bool                  `json:"pinned"`
+	Legacy   bool                  `json:"legacy"`
+	Edited   bool                  `json:"edited"`
+	History  []CommentRevisionData `json:"history"`
+	Created  int64                 `json:"created"`
+	Updated  int64                 `json:"updated"`
+}
+
+type CommentRevisionData struct {
+	Body     string `json:"body"`
+	EditedAt int64  `json:"editedAt"`
+}
+
+type AttachmentData struct {
+	ID          string `json:"id"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	Sha256      string `json:"sha256"`
+	UploadedBy  string `json:"uploadedBy"`
+	Uploaded    int64  `json:"uploaded"`
+}
+
+type ZoneImportData struct {
+	Imported int      `json:"imported"`
+	Serial   uint32   `json:"serial"`
+	Skipped  []string `json:"skipped"`
+}
+
+type ImportRowData struct {
+	Row    int    `json:"row"`
+	Name   string `json:"name"`
+	Action string `json:"action"`
+	Id     string `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ImportReportData struct {
+	DryRun  bool            `json:"dryRun"`
+	Total   int             `json:"total"`
+	Created int             `json:"created"`
+	Updated int             `json:"updated"`
+	Failed  int             `json:"failed"`
+	Rows    []ImportRowData `json:"rows"`
+}
+
+type HealthCheckData struct {
+	Enabled         bool   `json:"enabled"`
+	Url             string `json:"url"`
+	ExpectedStatus  int    `json:"expectedStatus"`
+	ExpectedContent string `json:"expectedContent"`
+	IntervalSeconds int    `json:"intervalSeconds"`
+	TimeoutSeconds  int    `json:"timeoutSeconds"`
+}
+
+type HealthData struct {
+	Status    string `json:"status"`
+	ChangedAt int64  `json:"changedAt"`
+	CheckedAt int64  `json:"checkedAt"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error"`
+}
+
+type UptimeSummaryData struct {
+	Period       string  `json:"period"`
+	Checks       int64   `json:"checks"`
+	Uptime       float64 `json:"uptime"`
+	AvgLatencyMs float64 `json:"avgLatencyMs"`
+	MaxLatencyMs int64   `json:"maxLatencyMs"`
+}
+
+type HealthResultData struct {
+	CheckedAt int64  `json:"checkedAt"`
+	Up        bool   `json:"up"`
+	Status    int    `json:"status"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error"`
+}
+
+type DiscrepancyData struct {
+	Field   string      `json:"field"`
+	Stored  interface{} `json:"stored"`
+	Fetched interface{} `json:"fetched"`
+}
+
+type RegistryReportData struct {
+	ID            string            `json:"id"`
+	Name          string            `json:"name"`
+	CheckedAt     int64             `json:"checkedAt"`
+	Discrepancies []DiscrepancyData `json:"discrepancies"`
+}
+
+type DomainData struct {
+	ID           string                 `json:"id"`
+	Name         string                 `json:"name"`
+	UnicodeName  string                 `json:"unicodeName"`
+	OwnerId      string                 `json:"ownerId"`
+	RegistrantId string                 `json:"registrantId"`
+	LoginInfo    string                 `json:"loginInfo"`
+	PackageId    string                 `json:"packageId"`
+	Mx           bool                   `json:"mx"`
+	Ip           string                 `json:"ip,omitempty"` // deprecated, first address
+	Addresses    []AddressData          `json:"addresses"`
+	ServerId     string                 `json:"serverId"`
+	Registrar    string                 `json:"registrar"`
+	RegisteredAt int64                  `json:"registeredAt"`
+	ExpiresAt    int64                  `json:"expiresAt"`
+	AutoRenew    bool                   `json:"autoRenew"`
+	RenewalCost  float64                `json:"renewalCost"`
+	Registry     RegistryData           `json:"registry"`
+	Dns          DnsData                `json:"dns"`
+	Tls          TlsData                `json:"tls"`
+	Certificate  CertificateData        `json:"certificate"`
+	HealthCheck  HealthCheckData        `json:"healthCheck"`
+	Health       HealthData             `json:"health"`
+	Zone         ZoneData               `json:"zone"`
+	Lifecycle    LifecycleData          `json:"lifecycle"`
+	Uptime       []UptimeSummaryData    `json:"uptime,omitempty"`
+	Billing      []LineItemData         `json:"billing"`
+	Managers     []string               `json:"managers"`
+	Tags         []string               `json:"tags"`
+	CustomFields map[string]interface{} `json:"customFields"`
+	Notes        string                 `json:"notes"` // legacy, read only
+	Created      int64                  `json:"created"`
+	Updated      int64                  `json:"updated"`
+	DeletedAt    int64                  `json:"deletedAt,omitempty"`
+	hidden       []string               // fields removed by the field policy
+}
+
+// MarshalJSON encodes the domain data without the fields hidden by the field policy
+func (self DomainData) MarshalJSON() ([]byte, error) {
+	type domainData DomainData
+	data, err := json.Marshal(domainData(self))
+	if err != nil || len(self.hidden) == 0 {
+		return data, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, field := range self.hidden {
+		delete(fields, field)
+	}
+	return json.Marshal(fields)
+}
+
+type PackageReportData struct {
+	PackageId      string  `json:"packageId"`
+	Name           string  `json:"name"`
+	Active         bool    `json:"active"`
+	Domains        int64   `json:"domains"`
+	MonthlyRevenue float64 `json:"monthlyRevenue"`
+	YearlyRevenue  float64 `json:"yearlyRevenue"`
 }
 
 func NewDomainSerializer() *domainSerializer {
 	return &domainSerializer{}
 }
 
+// NewDomainSerializerFor returns a serializer hiding the fields the user cannot see
+func NewDomainSerializerFor(user *auth.User) *domainSerializer {
+	return &domainSerializer{policy: fieldPolicyFor(user)}
+}
+
 func (self *domainSerializer) Serialize(domain *Domain) DomainData {
 	domainData := DomainData{
-		ID:         domain.ID.Hex(),
-		Name:       domain.Name,
-		Owner:      domain.Owner,
-		Registrant: domain.Registrant,
-		LoginInfo:  domain.LoginInfo,
-		Package:    domain.Package,
-		Mx:         domain.Mx,
-		Ip:         domain.Ip.String(),
-		ServerName: domain.ServerName,
-		Notes:      domain.Notes,
-		Created:    domain.Created,
-		Updated:    domain.Updated,
+		ID:           domain.ID.Hex(),
+		Name:         domain.Name,
+		UnicodeName:  domain.UnicodeName,
+		OwnerId:      hexOrEmpty(domain.OwnerId),
+		RegistrantId: hexOrEmpty(domain.RegistrantId),
+		LoginInfo:    domain.LoginInfo,
+		PackageId:    hexOrEmpty(domain.PackageId),
+		Mx:           domain.Mx,
+		Addresses:    self.serializeAddresses(domain.Addresses),
+		ServerId:     hexOrEmpty(domain.ServerId),
+		Registrar:    domain.Registrar,
+		RegisteredAt: domain.RegisteredAt,
+		ExpiresAt:    domain.ExpiresAt,
+		AutoRenew:    domain.AutoRenew,
+		RenewalCost:  domain.RenewalCost,
+		Registry:     self.serializeRegistry(&domain.Registry),
+		Dns:          self.serializeDns(&domain.Dns),
+		Tls: TlsData{
+			ServerName: domain.Tls.ServerName,
+			Ip:         domain.Tls.Ip,
+			Port:       domain.Tls.Port,
+		},
+		HealthCheck: HealthCheckData{
+			Enabled:         domain.HealthCheck.Enabled,
+			Url:             domain.HealthCheckUrl(),
+			ExpectedStatus:  domain.HealthCheck.ExpectedStatus,
+			ExpectedContent: domain.HealthCheck.ExpectedContent,
+			IntervalSeconds: domain.HealthCheck.IntervalSeconds,
+			TimeoutSeconds:  domain.HealthCheck.TimeoutSeconds,
+		},
+		Zone:      ZoneData{Serial: domain.Zone.Serial, ChangedAt: domain.Zone.ChangedAt},
+		Lifecycle: self.serializeLifecycle(&domain.Lifecycle),
+		Health: HealthData{
+			Status:    healthStatus(domain.Health.Status),
+			ChangedAt: domain.Health.ChangedAt,
+			CheckedAt: domain.Health.CheckedAt,
+			LatencyMs: domain.Health.LatencyMs,
+			Error:     domain.Health.Error,
+		},
+		Certificate: CertificateData{
+			Subject:    domain.Certificate.Subject,
+			Issuer:     domain.Certificate.Issuer,
+			Sans:       nonNilStrings(domain.Certificate.Sans),
+			NotBefore:  domain.Certificate.NotBefore,
+			NotAfter:   domain.Certificate.NotAfter,
+			ChainError: domain.Certificate.ChainError,
+			CheckedAt:  domain.Certificate.CheckedAt,
+			Error:      domain.Certificate.Error,
+		},
+		Billing:      self.serializeLineItems(domain.Billing),
+		Managers:     hexIds(domain.Managers),
+		Tags:         nonNilStrings(domain.Tags),
+		CustomFields: nonNilFields(domain.CustomFields),
+		Notes:        domain.Notes,
+		Created:      domain.Created,
+		Updated:      domain.Updated,
+		DeletedAt:    domain.DeletedAt,
+	}
+	if len(domain.Addresses) > 0 {
+		domainData.Ip = domain.Addresses[0].Ip
+	}
+	for _, field := range self.policy.Hidden {
+		if value := jsonField(reflect.ValueOf(&domainData).Elem(), field); value.IsValid() {
+			value.Set(reflect.Zero(value.Type()))
+			domainData.hidden = append(domainData.hidden, field)
+		}
 	}
 	return domainData
 }
 
+func (self *domainSerializer) serializeAddresses(addresses []Address) []AddressData {
+	res := make([]AddressData, 0)
+	for _, address := range addresses {
+		res = append(res, AddressData{
+			Ip:       address.Ip,
+			Version:  address.Version,
+			Purpose:  address.Purpose,
+			ServerId: hexOrEmpty(address.ServerId),
+		})
+	}
+	return res
+}
+
 func (self *domainSerializer) SerializeMany(domains *[]Domain) []DomainData {
 	var res []DomainData
 	res = make([]DomainData, 0)
@@ -47,3 +454,384 @@ func (self *domainSerializer) SerializeMany(domains *[]Domain) []DomainData {
 	}
 	return res
 }
+
+// hexOrEmpty returns the hex representation of a reference, or an empty string if not set
+func hexOrEmpty(id primitive.ObjectID) string {
+	if id.IsZero() {
+		return ""
+	}
+	return id.Hex()
+}
+
+// SerializePackageReport returns the domains count and the revenue of every package
+func (self *domainSerializer) SerializePackageReport(pkgs *[]packages.Package, counts map[primitive.ObjectID]int64) []PackageReportData {
+	res := make([]PackageReportData, 0)
+	for _, pkg := range *pkgs {
+		count := counts[pkg.ID]
+		res = append(res, PackageReportData{
+			PackageId:      pkg.ID.Hex(),
+			Name:           pkg.Name,
+			Active:         pkg.Active,
+			Domains:        count,
+			MonthlyRevenue: float64(count) * pkg.MonthlyPrice,
+			YearlyRevenue:  float64(count) * pkg.YearlyPrice,
+		})
+	}
+	return res
+}
+
+func (self *domainSerializer) serializeRegistry(info *RegistryInfo) RegistryData {
+	return RegistryData{
+		Registrar:    info.Registrar,
+		Statuses:     nonNilStrings(info.Statuses),
+		Nameservers:  nonNilStrings(info.Nameservers),
+		RegisteredAt: info.RegisteredAt,
+		ExpiresAt:    info.ExpiresAt,
+		Source:       info.Source,
+		CheckedAt:    info.CheckedAt,
+		Error:        info.Error,
+	}
+}
+
+func (self *domainSerializer) serializeDns(info *DnsInfo) DnsData {
+	mx := make([]MxData, 0)
+	for _, record := range info.Mx {
+		mx = append(mx, MxData{Host: record.Host, Pref: record.Pref})
+	}
+	return DnsData{
+		A:          nonNilStrings(info.A),
+		AAAA:       nonNilStrings(info.AAAA),
+		Mx:         mx,
+		Ns:         nonNilStrings(info.Ns),
+		Mismatches: nonNilStrings(info.Mismatches),
+		CheckedAt:  info.CheckedAt,
+		Error:      info.Error,
+	}
+}
+
+// SerializeWithUptime returns the domain data including the uptime summaries
+func (self *domainSerializer) serializeLifecycle(info *LifecycleInfo) LifecycleData {
+	res := LifecycleData{
+		Status:    lifecycleStatus(info.Status),
+		ChangedAt: info.ChangedAt,
+		History:   []LifecycleTransitionData{},
+	}
+	for _, transition := range info.History {
+		res.History = append(res.History, LifecycleTransitionData{
+			From:   transition.From,
+			To:     transition.To,
+			Reason: transition.Reason,
+			By:     transition.By,
+			At:     transition.At,
+		})
+	}
+	return res
+}
+
+func (self *domainSerializer) serializeLineItems(items []LineItem) []LineItemData {
+	res := make([]LineItemData, 0)
+	for _, item := range items {
+		res = append(res, LineItemData{
+			Kind:        item.Kind,
+			Description: item.Description,
+			Cost:        item.Cost,
+			Price:       item.Price,
+			Currency:    item.Currency,
+			Period:      item.Period,
+			Start:       item.Start,
+			End:         item.End,
+		})
+	}
+	return res
+}
+
+func (self *domainSerializer) SerializeInvoices(invoices []BillingInvoice) []BillingInvoiceData {
+	res := make([]BillingInvoiceData, 0)
+	for _, invoice := range invoices {
+		data := BillingInvoiceData{
+			OwnerId: invoice.OwnerId.Hex(),
+			Owner:   invoice.Owner,
+			From:    invoice.From.Unix(),
+			To:      invoice.To.Unix(),
+			Lines:   []BillingLineData{},
+			Totals:  []BillingTotalData{},
+		}
+		for _, line := range invoice.Lines {
+			data.Lines = append(data.Lines, BillingLineData{
+				DomainId:    line.DomainId.Hex(),
+				Domain:      line.Domain,
+				Kind:        line.Item.Kind,
+				Description: line.Item.Description,
+				Currency:    line.Item.Currency,
+				Period:      line.Item.Period,
+				Charges:     line.Charges,
+				UnitCost:    line.Item.Cost,
+				UnitPrice:   line.Item.Price,
+				Cost:        roundAmount(line.Cost),
+				Price:       roundAmount(line.Price),
+				Margin:      roundAmount(line.Price - line.Cost),
+			})
+		}
+		for _, total := range invoice.Totals {
+			data.Totals = append(data.Totals, BillingTotalData{
+				Currency: total.Currency,
+				Cost:     roundAmount(total.Cost),
+				Price:    roundAmount(total.Price),
+				Margin:   roundAmount(total.Price - total.Cost),
+			})
+		}
+		res = append(res, data)
+	}
+	return res
+}
+
+// roundAmount rounds an amount to cents
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func (self *domainSerializer) SerializeWithUptime(domain *Domain, summaries []UptimeSummary) DomainData {
+	domainData := self.Serialize(domain)
+	domainData.Uptime = make([]UptimeSummaryData, 0)
+	for _, summary := range summaries {
+		domainData.Uptime = append(domainData.Uptime, UptimeSummaryData{
+			Period:       summary.Period,
+			Checks:       summary.Checks,
+			Uptime:       summary.Uptime,
+			AvgLatencyMs: summary.AvgLatencyMs,
+			MaxLatencyMs: summary.MaxLatencyMs,
+		})
+	}
+	return domainData
+}
+
+func (self *domainSerializer) SerializeHealthResults(results *[]HealthResult) []HealthResultData {
+	res := make([]HealthResultData, 0)
+	for _, result := range *results {
+		res = append(res, HealthResultData{
+			CheckedAt: result.CheckedAt.Unix(),
+			Up:        result.Up,
+			Status:    result.Status,
+			LatencyMs: result.LatencyMs,
+			Error:     result.Error,
+		})
+	}
+	return res
+}
+
+func (self *domainSerializer) SerializeRecord(record *Record) RecordData {
+	return RecordData{
+		ID:       record.ID.Hex(),
+		Name:     record.Name,
+		Type:     record.Type,
+		Ttl:      record.Ttl,
+		Value:    record.Value,
+		Priority: record.Priority,
+		Weight:   record.Weight,
+		Port:     record.Port,
+		Flags:    record.Flags,
+		Tag:      record.Tag,
+		Created:  record.Created,
+		Updated:  record.Updated,
+	}
+}
+
+func (self *domainSerializer) SerializeRecords(records *[]Record) []RecordData {
+	res := make([]RecordData, 0)
+	for _, record := range *records {
+		res = append(res, self.SerializeRecord(&record))
+	}
+	return res
+}
+
+func (self *domainSerializer) SerializeComment(comment *Comment) CommentData {
+	res := CommentData{
+		ID:      comment.ID.Hex(),
+		Author:  comment.Author,
+		Body:    comment.Body,
+		Pinned:  comment.Pinned,
+		Legacy:  comment.Legacy,
+		Edited:  len(comment.History) > 0,
+		History: []CommentRevisionData{},
+		Created: comment.Created,
+		Updated: comment.Updated,
+	}
+	if !comment.AuthorId.IsZero() {
+		res.AuthorId = comment.AuthorId.Hex()
+	}
+	for _, revision := range comment.History {
+		res.History = append(res.History, CommentRevisionData{Body: revision.Body, EditedAt: revision.EditedAt})
+	}
+	return res
+}
+
+func (self *domainSerializer) SerializeComments(comments *[]Comment) []CommentData {
+	res := make([]CommentData, 0)
+	for _, comment := range *comments {
+		res = append(res, self.SerializeComment(&comment))
+	}
+	return res
+}
+
+func (self *domainSerializer) SerializeAttachment(attachment *Attachment) AttachmentData {
+	return AttachmentData{
+		ID:          attachment.ID.Hex(),
+		Filename:    attachment.Filename,
+		ContentType: attachment.Metadata.ContentType,
+		Size:        attachment.Length,
+		Sha256:      attachment.Metadata.Sha256,
+		UploadedBy:  attachment.Metadata.UploadedBy,
+		Uploaded:    attachment.UploadDate.Unix(),
+	}
+}
+
+func (self *domainSerializer) SerializeAttachments(attachments *[]Attachment) []AttachmentData {
+	res := make([]AttachmentData, 0)
+	for _, attachment := range *attachments {
+		res = append(res, self.SerializeAttachment(&attachment))
+	}
+	return res
+}
+
+func (self *domainSerializer) SerializeImportReport(report *ImportReport) ImportReportData {
+	res := ImportReportData{
+		DryRun:  report.DryRun,
+		Total:   report.Total,
+		Created: report.Created,
+		Updated: report.Updated,
+		Failed:  report.Failed,
+		Rows:    make([]ImportRowData, 0),
+	}
+	for _, row := range report.Rows {
+		res.Rows = append(res.Rows, ImportRowData{
+			Row:    row.Row,
+			Name:   row.Name,
+			Action: row.Action,
+			Id:     row.Id,
+			Error:  row.Error,
+		})
+	}
+	return res
+}
+
+// nonNilFields returns the custom fields values, an empty map when no value is set
+func nonNilFields(values map[string]interface{}) map[string]interface{} {
+	if values == nil {
+		return make(map[string]interface{})
+	}
+	return values
+}
+
+// healthStatus returns the health status, unknown if the domain has never been checked
+func healthStatus(status string) string {
+	if status == "" {
+		return HealthUnknown
+	}
+	return status
+}
+
+// SerializeRegistryReport returns the domains whose stored registration fields differ from the registry ones
+func (self *domainSerializer) SerializeRegistryReport(domains *[]Domain) []RegistryReportData {
+	res := make([]RegistryReportData, 0)
+	for _, domain := range *domains {
+		discrepancies := domain.RegistryDiscrepancies()
+		if len(discrepancies) == 0 {
+			continue
+		}
+		row := RegistryReportData{
+			ID:            domain.ID.Hex(),
+			Name:          domain.Name,
+			CheckedAt:     domain.Registry.CheckedAt,
+			Discrepancies: make([]DiscrepancyData, 0),
+		}
+		for _, discrepancy := range discrepancies {
+			row.Discrepancies = append(row.Discrepancies, DiscrepancyData{
+				Field:   discrepancy.Field,
+				Stored:  discrepancy.Stored,
+				Fetched: discrepancy.Fetched,
+			})
+		}
+		res = append(res, row)
+	}
+	return res
+}
+
+// hexIds returns the hexadecimal form of the IDs
+func hexIds(ids []primitive.ObjectID) []string {
+	res := make([]string, 0)
+	for _, id := range ids {
+		res = append(res, id.Hex())
+	}
+	return res
+}
+
+// nonNilStrings returns an empty slice instead of nil, so that it's serialized as an empty array
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return make([]string, 0)
+	}
+	return values
+}
+
+func (self *domainSerializer) SerializeQualityReport(checks []QualityCheck, findings []Finding) QualityReportData {
+	data := QualityReportData{Checks: []QualityCheckData{}, Summary: map[string]int{}, Findings: []FindingData{}}
+	for _, check := range checks {
+		data.Checks = append(data.Checks, QualityCheckData{Name: check.Name, Description: check.Description})
+	}
+	for _, severity := range Severities {
+		data.Summary[severity] = 0
+	}
+	for _, finding := range findings {
+		findingData := FindingData{
+			ID:         finding.ID,
+			Check:      finding.Check,
+			Severity:   finding.Severity,
+			Message:    finding.Message,
+			DomainIds:  hexIds(finding.DomainIds),
+			Suggestion: finding.Suggestion,
+		}
+		if finding.Fix != nil {
+			findingData.Fix = &QualityFixData{Action: finding.Fix.Action, Params: finding.Fix.Params}
+		}
+		data.Summary[finding.Severity]++
+		data.Findings = append(data.Findings, findingData)
+	}
+	return data
+}
+
+func (self *domainSerializer) SerializeFixResults(results []FixResult) []FixResultData {
+	res := make([]FixResultData, 0)
+	for _, result := range results {
+		res = append(res, FixResultData{
+			FindingId: result.FindingId,
+			Check:     result.Check,
+			Action:    result.Action,
+			Applied:   result.Applied,
+			Error:     result.Error,
+		})
+	}
+	return res
+}
+
+func (self *domainSerializer) SerializeBulkReport(report *BulkReport) BulkReportData {
+	data := BulkReportData{
+		DryRun:    report.DryRun,
+		Matched:   report.Matched,
+		Updated:   report.Updated,
+		Deleted:   report.Deleted,
+		Unchanged: report.Unchanged,
+		NotFound:  report.NotFound,
+		Failed:    report.Failed,
+		Items:     []BulkItemResultData{},
+	}
+	for _, item := range report.Items {
+		data.Items = append(data.Items, BulkItemResultData{
+			DomainId: item.DomainId.Hex(),
+			Name:     item.Name,
+			Status:   item.Status,
+			Changes:  nonNilStrings(item.Changes),
+			Error:    item.Error,
+		})
+	}
+	return data
+}
@@ -2,21 +2,129 @@ package domains
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
+	"systems-management-api/auth"
 	database "systems-management-api/core/database"
+	"time"
 )
 
-// UserService service which provides methos to access and modify database data
-type DomainService struct{}
+// notTrashed filter matching domains which have not been soft deleted
+var notTrashed = bson.M{"deletedat": bson.M{"$exists": false}}
 
-// Retrieves all domains instances
+// trashed filter matching soft deleted domains
+var trashed = bson.M{"deletedat": bson.M{"$exists": true}}
+
+// EnsureIndexes creates the domain collection indexes
+// Domain names are unique, trashed domains included
+func EnsureIndexes() {
+	db := database.DB()
+	err := db.EnsureIndexes("domain", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetUnique(true).SetName("unique_name")},
+	})
+	if err != nil {
+		zap.S().Error("Error creating domain indexes: ", err)
+	}
+	ensureHealthIndexes()
+	ensureRecordIndexes()
+	ensureCommentIndexes()
+	ensureAttachmentIndexes()
+}
+
+// updateDomain updates the domain document matching the filter
+// Compare and set updates go through it, so that tests can simulate the concurrent changes
+var updateDomain = func(filter interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+	return collection.UpdateOne(context.TODO(), filter, update)
+}
+
+// DomainService service which provides methods to access and modify the domains
+// A service scoped to a manager (see NewDomainService) reads and updates only the domains the manager is assigned to
+type DomainService struct {
+	scoped    bool
+	managerId primitive.ObjectID
+}
+
+// NewDomainService returns the domain service to use on behalf of the given user
+// Members of the user role only access the domains they manage, admins access all the domains
+func NewDomainService(user *auth.User) *DomainService {
+	service := new(DomainService)
+	if user.Role == "user" {
+		service.scoped = true
+		service.managerId = user.ID
+	}
+	return service
+}
+
+// scope restricts the given filter to the domains the service can access
+func (service *DomainService) scope(filter interface{}) interface{} {
+	if !service.scoped {
+		return filter
+	}
+	return bson.M{"$and": []interface{}{filter, bson.M{"managers": service.managerId}}}
+}
+
+// Retrieves all domains instances, trashed domains excluded
 func (service *DomainService) all() (*[]Domain, error) {
+	return service.find(notTrashed)
+}
+
+// Retrieves all domains instances matching the given list filter, trashed domains excluded
+func (service *DomainService) list(filter *DomainFilter) (*[]Domain, error) {
+	domains, err := service.find(filter.query())
+	if err != nil {
+		return nil, err
+	}
+	res := []Domain{}
+	for _, domain := range *domains {
+		if filter.match(&domain) {
+			res = append(res, domain)
+		}
+	}
+	return &res, nil
+}
+
+// Iterates over the domains matching the given list filter without loading them all in memory, trashed domains excluded
+func (service *DomainService) each(filter *DomainFilter, fn func(domain *Domain) error) error {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+	cursor, err := collection.Find(context.TODO(), service.scope(filter.query()), options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.TODO())
+
+	for cursor.Next(context.TODO()) {
+		var domain Domain
+		if err := cursor.Decode(&domain); err != nil {
+			return err
+		}
+		if !filter.match(&domain) {
+			continue
+		}
+		if err := fn(&domain); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// Retrieves all trashed domains instances
+func (service *DomainService) trashed() (*[]Domain, error) {
+	return service.find(trashed)
+}
+
+// Retrieves all domains instances matching the given filter
+func (service *DomainService) find(filter interface{}) (*[]Domain, error) {
 	db := database.DB()
 	collection := db.D.Collection("domain")
-	cursor, err := collection.Find(context.TODO(), bson.D{{}})
+	cursor, err := collection.Find(context.TODO(), service.scope(filter))
 
 	if err != nil {
 		return nil, err
@@ -31,24 +139,54 @@ func (service *DomainService) all() (*[]Domain, error) {
 	}
 }
 
-// Retrieves a domain instance given its ID
+// Retrieves a domain instance given its ID, trashed domains excluded
 func (service *DomainService) GetById(id string) (*Domain, error) {
+	return service.getOne(id, notTrashed)
+}
+
+// Retrieves a trashed domain instance given its ID
+func (service *DomainService) GetTrashedById(id string) (*Domain, error) {
+	return service.getOne(id, trashed)
+}
+
+// Retrieves a domain instance given its ID and matching the given filter
+func (service *DomainService) getOne(id string, filter bson.M) (*Domain, error) {
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
 	db := database.DB()
+	collection := db.D.Collection("domain")
 	domain := Domain{}
 
-	if err := db.GetById("domain", id, &domain); err != nil {
+	if err := collection.FindOne(context.TODO(), service.scope(bson.M{"$and": []bson.M{{"_id": objId}, filter}})).Decode(&domain); err != nil {
 		return nil, err
 	} else {
 		return &domain, nil
 	}
 }
 
+// Retrieves a domain instance given its name, trashed domains included
+func (service *DomainService) getByName(name string) (*Domain, error) {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+	domain := Domain{}
+
+	if err := collection.FindOne(context.TODO(), bson.M{"name": name}).Decode(&domain); err != nil {
+		return nil, err
+	}
+	return &domain, nil
+}
+
 // Saves the domain model to database
 // Returns boolean result and error
 func (service *DomainService) Save(domain *Domain) (bool, error) {
 	db := database.DB()
 	collection := db.D.Collection("domain")
 
+	if domain.ID.IsZero() && service.scoped {
+		return false, errors.New("Domains can only be created by admins")
+	}
 	if domain.ID.IsZero() {
 		// insert
 		res, err := collection.InsertOne(context.TODO(), domain)
@@ -65,8 +203,11 @@ func (service *DomainService) Save(domain *Domain) (bool, error) {
 	} else {
 		// update
 		filter := bson.M{"_id": domain.ID}
-		_, err := collection.ReplaceOne(context.TODO(), filter, domain)
+		res, err := collection.ReplaceOne(context.TODO(), service.scope(filter), domain)
 
+		if err == nil && res.MatchedCount == 0 {
+			err = mongo.ErrNoDocuments
+		}
 		if err != nil {
 			zap.S().Error("Error inserting domain: ", err)
 			return false, err
@@ -78,12 +219,346 @@ func (service *DomainService) Save(domain *Domain) (bool, error) {
 	}
 }
 
+// Returns the number of domains per hosting package, trashed domains excluded
+func (service *DomainService) countByPackage() (map[primitive.ObjectID]int64, error) {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+	cursor, err := collection.Aggregate(context.TODO(), mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"$and": []bson.M{notTrashed, {"packageid": bson.M{"$exists": true}}}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$packageid", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	res := map[primitive.ObjectID]int64{}
+	for cursor.Next(context.TODO()) {
+		var row struct {
+			ID    primitive.ObjectID `bson:"_id"`
+			Count int64              `bson:"count"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+		res[row.ID] = row.Count
+	}
+	return res, cursor.Err()
+}
+
+// Retrieves the domains having an expiry date, trashed domains excluded
+func (service *DomainService) withExpiry() (*[]Domain, error) {
+	return service.find(bson.M{"$and": []bson.M{notTrashed, {"expiresat": bson.M{"$gt": 0}}}})
+}
+
+// Stores the expiry reminders thresholds already notified for the domain
+func (service *DomainService) SetExpiryReminders(domain *Domain, reminders []int) error {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+
+	_, err := collection.UpdateOne(context.TODO(), bson.M{"_id": domain.ID}, bson.M{"$set": bson.M{"expiryreminders": reminders}})
+	if err != nil {
+		zap.S().Error("Error updating domain expiry reminders: ", err)
+		return err
+	}
+	domain.ExpiryReminders = reminders
+	return nil
+}
+
+// Retrieves the domains whose registry data has been checked, trashed domains excluded
+func (service *DomainService) withRegistry() (*[]Domain, error) {
+	return service.find(bson.M{"$and": []bson.M{notTrashed, {"registry.checkedat": bson.M{"$gt": 0}}}})
+}
+
+// Stores the registry data of the domain
+func (service *DomainService) SetRegistry(domain *Domain, info RegistryInfo) error {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+
+	_, err := collection.UpdateOne(context.TODO(), bson.M{"_id": domain.ID}, bson.M{"$set": bson.M{"registry": info}})
+	if err != nil {
+		zap.S().Error("Error updating domain registry data: ", err)
+		return err
+	}
+	domain.Registry = info
+	return nil
+}
+
+// Stores the registry data of the domain along with the domain fields updated from it, set is keyed by bson field
+func (service *DomainService) ApplyRegistry(domain *Domain, set bson.M) error {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+
+	_, err := collection.UpdateOne(context.TODO(), bson.M{"_id": domain.ID}, bson.M{"$set": set})
+	if err != nil {
+		zap.S().Error("Error updating domain registry data: ", err)
+		return err
+	}
+	return nil
+}
+
+// Stores the DNS check result of the domain
+func (service *DomainService) SetDns(domain *Domain, info DnsInfo) error {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+
+	_, err := collection.UpdateOne(context.TODO(), bson.M{"_id": domain.ID}, bson.M{"$set": bson.M{"dns": info}})
+	if err != nil {
+		zap.S().Error("Error updating domain DNS check: ", err)
+		return err
+	}
+	domain.Dns = info
+	return nil
+}
+
+// Stores the certificate check result of the domain
+func (service *DomainService) SetCertificate(domain *Domain, info CertificateInfo) error {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+
+	_, err := collection.UpdateOne(context.TODO(), bson.M{"_id": domain.ID}, bson.M{"$set": bson.M{"certificate": info}})
+	if err != nil {
+		zap.S().Error("Error updating domain certificate: ", err)
+		return err
+	}
+	domain.Certificate = info
+	return nil
+}
+
+// Retrieves the domains with an enabled health check, trashed domains excluded
+func (service *DomainService) withHealthCheck() (*[]Domain, error) {
+	return service.find(bson.M{"$and": []bson.M{notTrashed, {"healthcheck.enabled": true}}})
+}
+
+// Stores the health state of the domain
+func (service *DomainService) SetHealth(domain *Domain, state HealthState) error {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+
+	_, err := collection.UpdateOne(context.TODO(), bson.M{"_id": domain.ID}, bson.M{"$set": bson.M{"health": state}})
+	if err != nil {
+		zap.S().Error("Error updating domain health: ", err)
+		return err
+	}
+	domain.Health = state
+	return nil
+}
+
+// Stores a health check result, old results are expired by the TTL index of the collection
+func (service *DomainService) AddHealthResult(result *HealthResult) error {
+	db := database.DB()
+	collection := db.D.Collection("health_check")
+
+	_, err := collection.InsertOne(context.TODO(), result)
+	if err != nil {
+		zap.S().Error("Error storing health check result: ", err)
+	}
+	return err
+}
+
+// Retrieves the health check results of the domain since the given time, most recent first
+func (service *DomainService) healthResults(domain *Domain, since time.Time) (*[]HealthResult, error) {
+	db := database.DB()
+	collection := db.D.Collection("health_check")
+
+	opts := options.Find().SetSort(bson.D{{Key: "checkedat", Value: -1}})
+	cursor, err := collection.Find(context.TODO(), bson.M{"domainid": domain.ID, "checkedat": bson.M{"$gte": since}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []HealthResult{}
+	if err := cursor.All(context.TODO(), &results); err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
+
+// Computes the uptime percentage and latency of the domain since the given time
+func (service *DomainService) uptime(domain *Domain, period string, since time.Time) (UptimeSummary, error) {
+	db := database.DB()
+	collection := db.D.Collection("health_check")
+
+	summary := UptimeSummary{Period: period}
+	cursor, err := collection.Aggregate(context.TODO(), mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"domainid": domain.ID, "checkedat": bson.M{"$gte": since}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":          nil,
+			"checks":       bson.M{"$sum": 1},
+			"up":           bson.M{"$sum": bson.M{"$cond": bson.A{"$up", 1, 0}}},
+			"avglatencyms": bson.M{"$avg": "$latencyms"},
+			"maxlatencyms": bson.M{"$max": "$latencyms"},
+		}}},
+	})
+	if err != nil {
+		return summary, err
+	}
+	defer cursor.Close(context.TODO())
+
+	if cursor.Next(context.TODO()) {
+		var row struct {
+			Checks       int64
+			Up           int64
+			AvgLatencyMs float64
+			MaxLatencyMs int64
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return summary, err
+		}
+		summary.Checks = row.Checks
+		if row.Checks > 0 {
+			summary.Uptime = float64(row.Up) * 100 / float64(row.Checks)
+		}
+		summary.AvgLatencyMs = row.AvgLatencyMs
+		summary.MaxLatencyMs = row.MaxLatencyMs
+	}
+	return summary, cursor.Err()
+}
+
+// Soft deletes the domain model, setting its deletion timestamp
+// Returns boolean result and error
+func (service *DomainService) Trash(domain *Domain) (bool, error) {
+	domain.DeletedAt = time.Now().Unix()
+	if result, err := service.Save(domain); err != nil {
+		domain.DeletedAt = 0
+		return result, err
+	}
+	zap.S().Info(fmt.Sprintf("Domain %s moved to trash", domain.Name))
+	return true, nil
+}
+
+// Restores a soft deleted domain model
+// Returns boolean result and error
+func (service *DomainService) Restore(domain *Domain) (bool, error) {
+	deletedAt := domain.DeletedAt
+	domain.DeletedAt = 0
+	if result, err := service.Save(domain); err != nil {
+		domain.DeletedAt = deletedAt
+		return result, err
+	}
+	zap.S().Info(fmt.Sprintf("Domain %s restored from trash", domain.Name))
+	return true, nil
+}
+
+// Applies the write models to the domains with a single unordered bulk write, a failed write does not stop the others
+func (service *DomainService) bulkWrite(models []mongo.WriteModel) (*mongo.BulkWriteResult, error) {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+	return collection.BulkWrite(context.TODO(), models, options.BulkWrite().SetOrdered(false))
+}
+
+// Stores the lifecycle transition of the domain, if its status has not changed since it was read
+func (service *DomainService) AddTransition(domain *Domain, transition LifecycleTransition) error {
+	var current interface{} = domain.Lifecycle.Status
+	if domain.Lifecycle.Status == "" {
+		current = bson.M{"$in": []interface{}{nil, ""}}
+	}
+	// the history of the domains stored before lifecycles may be missing or null, which $push rejects
+	res, err := updateDomain(bson.M{"_id": domain.ID, "lifecycle.status": current}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"lifecycle.status":    bson.M{"$literal": transition.To},
+			"lifecycle.changedat": bson.M{"$literal": transition.At},
+			"lifecycle.history": bson.M{"$concatArrays": bson.A{
+				bson.M{"$ifNull": bson.A{"$lifecycle.history", bson.A{}}},
+				bson.M{"$literal": bson.A{transition}},
+			}},
+		}}},
+	})
+	if err != nil {
+		zap.S().Error("Error updating domain lifecycle: ", err)
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrLifecycleChanged
+	}
+	domain.Lifecycle.Status = transition.To
+	domain.Lifecycle.ChangedAt = transition.At
+	domain.Lifecycle.History = append(domain.Lifecycle.History, transition)
+	return nil
+}
+
+// Assigns the user as manager of the domain
+func (service *DomainService) AddManager(domain *Domain, userId primitive.ObjectID) error {
+	return service.updateManagers(domain, bson.M{"$addToSet": bson.M{"managers": userId}})
+}
+
+// Unassigns the user from the managers of the domain
+func (service *DomainService) RemoveManager(domain *Domain, userId primitive.ObjectID) error {
+	return service.updateManagers(domain, bson.M{"$pull": bson.M{"managers": userId}})
+}
+
+// updateManagers applies the update to the managers of the domain and reloads them
+func (service *DomainService) updateManagers(domain *Domain, update bson.M) error {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated Domain
+	if err := collection.FindOneAndUpdate(context.TODO(), service.scope(bson.M{"_id": domain.ID}), update, opts).Decode(&updated); err != nil {
+		zap.S().Error("Error updating domain managers: ", err)
+		return err
+	}
+	domain.Managers = updated.Managers
+	zap.S().Info(fmt.Sprintf("Managers of domain %s updated succesfully", domain.Name))
+	return nil
+}
+
+// Permanently deletes all the domains trashed before the given unix timestamp
+// Returns the number of deleted domains and error
+func (service *DomainService) PurgeTrashedBefore(timestamp int64) (int64, error) {
+	db := database.DB()
+	collection := db.D.Collection("domain")
+	filter := bson.M{"deletedat": bson.M{"$lt": timestamp}}
+
+	domains, err := service.find(filter)
+	if err != nil {
+		return 0, err
+	}
+	if len(*domains) == 0 {
+		return 0, nil
+	}
+	ids := []primitive.ObjectID{}
+	for _, domain := range *domains {
+		ids = append(ids, domain.ID)
+	}
+	recordService := new(RecordService)
+	if _, err := recordService.DeleteByDomains(ids); err != nil {
+		return 0, err
+	}
+	commentService := new(CommentService)
+	if _, err := commentService.DeleteByDomains(ids); err != nil {
+		return 0, err
+	}
+	attachmentService := new(AttachmentService)
+	if _, err := attachmentService.DeleteByDomains(ids); err != nil {
+		return 0, err
+	}
+	res, err := collection.DeleteMany(context.TODO(), bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		zap.S().Error("Error purging trashed domains: ", err)
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
 // Deletes the domain model from databse
 // Returns boolean result and error
 func (service *DomainService) Delete(domain *Domain) (bool, error) {
 	db := database.DB()
 	collection := db.D.Collection("domain")
 
+	recordService := new(RecordService)
+	if _, err := recordService.DeleteByDomains([]primitive.ObjectID{domain.ID}); err != nil {
+		return false, err
+	}
+	commentService := new(CommentService)
+	if _, err := commentService.DeleteByDomains([]primitive.ObjectID{domain.ID}); err != nil {
+		return false, err
+	}
+	attachmentService := new(AttachmentService)
+	if _, err := attachmentService.DeleteByDomains([]primitive.ObjectID{domain.ID}); err != nil {
+		return false, err
+	}
+
 	_, err := collection.DeleteOne(context.TODO(), bson.M{"_id": domain.ID})
 
 	if err != nil {
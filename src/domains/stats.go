@@ -0,0 +1,164 @@
+package domains
+
+import (
+	"context"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	database "systems-management-api/core/database"
+	"time"
+)
+
+// DomainStats the domains aggregates shown on the dashboard, trashed domains excluded
+type DomainStats struct {
+	Total        int64
+	Mx           int64
+	ExpiringSoon int64
+	ExpiringDays int
+	ByPackage    []StatsCount
+	ByServer     []StatsCount
+	ByOwner      []StatsCount // the owners having most domains
+	PerMonth     []MonthCount // domains created in the last months, oldest first
+}
+
+// StatsCount the number of domains referencing an item
+type StatsCount struct {
+	ID    primitive.ObjectID
+	Name  string
+	Count int64
+}
+
+// MonthCount the number of domains created in a month (YYYY-MM)
+type MonthCount struct {
+	Month string
+	Count int64
+}
+
+// statsSetting returns a positive integer setting of the stats, the given default when unset
+func statsSetting(key string, defaultValue int) int {
+	value := viper.GetInt("stats." + key)
+	if value <= 0 {
+		value = defaultValue
+	}
+	return value
+}
+
+// statsRow a row of the grouping facets
+type statsRow struct {
+	ID    interface{} `bson:"_id"`
+	Count int64       `bson:"count"`
+}
+
+// Computes the dashboard aggregates of the domains with a single aggregation pipeline
+func (service *DomainService) Stats() (*DomainStats, error) {
+	now := time.Now().UTC()
+	expiringDays := statsSetting("expiringDays", 30)
+	months := statsSetting("months", 12)
+	since := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1-months, 0)
+	count := bson.D{{Key: "$count", Value: "count"}}
+	groupBy := func(key interface{}) bson.D {
+		return bson.D{{Key: "$group", Value: bson.M{"_id": key, "count": bson.M{"$sum": 1}}}}
+	}
+	byCount := bson.D{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}}
+
+	db := database.DB()
+	collection := db.D.Collection("domain")
+	cursor, err := collection.Aggregate(context.TODO(), mongo.Pipeline{
+		{{Key: "$match", Value: notTrashed}},
+		{{Key: "$facet", Value: bson.M{
+			"total": bson.A{count},
+			"mx":    bson.A{bson.D{{Key: "$match", Value: bson.M{"mx": true}}}, count},
+			"expiring": bson.A{
+				bson.D{{Key: "$match", Value: bson.M{"expiresat": bson.M{"$gt": now.Unix(), "$lte": now.AddDate(0, 0, expiringDays).Unix()}}}},
+				count,
+			},
+			"packages": bson.A{bson.D{{Key: "$match", Value: bson.M{"packageid": bson.M{"$exists": true}}}}, groupBy("$packageid"), byCount},
+			"servers": bson.A{
+				// domains are hosted on their server and on the servers of their addresses
+				bson.D{{Key: "$project", Value: bson.M{"servers": bson.M{"$setUnion": bson.A{
+					bson.M{"$cond": bson.A{bson.M{"$ifNull": bson.A{"$serverid", false}}, bson.A{"$serverid"}, bson.A{}}},
+					bson.M{"$ifNull": bson.A{"$addresses.serverid", bson.A{}}},
+				}}}}},
+				bson.D{{Key: "$unwind", Value: "$servers"}},
+				groupBy("$servers"),
+				byCount,
+			},
+			"owners": bson.A{
+				bson.D{{Key: "$match", Value: bson.M{"ownerid": bson.M{"$exists": true}}}},
+				groupBy("$ownerid"),
+				byCount,
+				bson.D{{Key: "$limit", Value: statsSetting("topOwners", 10)}},
+			},
+			"months": bson.A{
+				bson.D{{Key: "$match", Value: bson.M{"created": bson.M{"$gte": since.Unix()}}}},
+				groupBy(bson.M{"$dateToString": bson.M{"format": "%Y-%m", "date": bson.M{"$toDate": bson.M{"$multiply": bson.A{"$created", 1000}}}}}),
+			},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var facets struct {
+		Total    []statsRow `bson:"total"`
+		Mx       []statsRow `bson:"mx"`
+		Expiring []statsRow `bson:"expiring"`
+		Packages []statsRow `bson:"packages"`
+		Servers  []statsRow `bson:"servers"`
+		Owners   []statsRow `bson:"owners"`
+		Months   []statsRow `bson:"months"`
+	}
+	if cursor.Next(context.TODO()) {
+		if err := cursor.Decode(&facets); err != nil {
+			return nil, err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	names := newExportNames()
+	stats := DomainStats{
+		Total:        statsTotal(facets.Total),
+		Mx:           statsTotal(facets.Mx),
+		ExpiringSoon: statsTotal(facets.Expiring),
+		ExpiringDays: expiringDays,
+		ByPackage:    statsCounts(facets.Packages, names.pkg),
+		ByServer:     statsCounts(facets.Servers, names.server),
+		ByOwner:      statsCounts(facets.Owners, names.contact),
+		PerMonth:     []MonthCount{},
+	}
+	perMonth := map[string]int64{}
+	for _, row := range facets.Months {
+		if month, ok := row.ID.(string); ok {
+			perMonth[month] = row.Count
+		}
+	}
+	for month := since; month.Before(now); month = month.AddDate(0, 1, 0) {
+		stats.PerMonth = append(stats.PerMonth, MonthCount{Month: month.Format("2006-01"), Count: perMonth[month.Format("2006-01")]})
+	}
+	return &stats, nil
+}
+
+// statsTotal returns the count of a $count facet, which is empty when no document matches
+func statsTotal(rows []statsRow) int64 {
+	if len(rows) == 0 {
+		return 0
+	}
+	return rows[0].Count
+}
+
+// statsCounts returns the counts of a grouping facet, resolving the names of the referenced items
+func statsCounts(rows []statsRow, name func(id primitive.ObjectID) string) []StatsCount {
+	res := []StatsCount{}
+	for _, row := range rows {
+		id, ok := row.ID.(primitive.ObjectID)
+		if !ok {
+			continue
+		}
+		res = append(res, StatsCount{ID: id, Name: name(id), Count: row.Count})
+	}
+	return res
+}
@@ -1,38 +1,265 @@
 package domains
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 	"net"
+	"strings"
+	"systems-management-api/auth"
+	"systems-management-api/contacts"
+	"systems-management-api/customfields"
+	"systems-management-api/packages"
+	"systems-management-api/servers"
 	"time"
 )
 
 type DomainValidatorData struct {
-	Name       string `json:"name" binding:"required"`
-	Owner      string `json:"owner" binding:"required"`
-	Registrant string `json:"registrant" binding:"required"`
-	LoginInfo  string `json:"loginInfo"`
-	Package    string `json:"package"`
-	Mx         bool   `json:"mx"`
-	Ip         string `json:"ip,omitempty" binding:"ip"`
-	ServerName string `json:"serverName"`
-	Notes      string `json:"notes"`
+	Name         string                   `json:"name" binding:"required"`
+	OwnerId      string                   `json:"ownerId" binding:"required,len=24,hexadecimal"`
+	RegistrantId string                   `json:"registrantId" binding:"required,len=24,hexadecimal"`
+	LoginInfo    string                   `json:"loginInfo"`
+	PackageId    string                   `json:"packageId" binding:"omitempty,len=24,hexadecimal"`
+	Mx           bool                     `json:"mx"`
+	Ip           string                   `json:"ip,omitempty" binding:"omitempty,ip"` // deprecated, use addresses
+	Addresses    []AddressValidatorData   `json:"addresses" binding:"dive"`
+	ServerId     string                   `json:"serverId" binding:"omitempty,len=24,hexadecimal"`
+	Registrar    string                   `json:"registrar" binding:"max=255"`
+	RegisteredAt int64                    `json:"registeredAt" binding:"gte=0"`
+	ExpiresAt    int64                    `json:"expiresAt" binding:"omitempty,gtfield=RegisteredAt"`
+	AutoRenew    bool                     `json:"autoRenew"`
+	RenewalCost  float64                  `json:"renewalCost" binding:"gte=0"`
+	Tls          TlsValidatorData         `json:"tls"`
+	HealthCheck  HealthCheckValidatorData `json:"healthCheck"`
+	Tags         []string                 `json:"tags" binding:"dive,required,max=64"`
+	CustomFields map[string]interface{}   `json:"customFields"`
+	Billing      []LineItemValidatorData  `json:"billing" binding:"dive"`
+	Lifecycle    string                   `json:"lifecycle" binding:"omitempty,oneof=pending active transfer-in"` // initial status, on create only
+}
+type LineItemValidatorData struct {
+	Kind        string  `json:"kind" binding:"required,oneof=registration hosting ssl other"`
+	Description string  `json:"description" binding:"max=255"`
+	Cost        float64 `json:"cost" binding:"gte=0"`
+	Price       float64 `json:"price" binding:"gte=0"`
+	Currency    string  `json:"currency" binding:"required,len=3,alpha"` // ISO 4217 code
+	Period      string  `json:"period" binding:"required,oneof=once monthly quarterly yearly"`
+	Start       int64   `json:"start" binding:"gte=0"`
+	End         int64   `json:"end" binding:"omitempty,gtfield=Start"`
+}
+type AddressValidatorData struct {
+	Ip       string `json:"ip" binding:"required,ip"`
+	Purpose  string `json:"purpose" binding:"omitempty,oneof=web mail dns ftp other"`
+	ServerId string `json:"serverId" binding:"omitempty,len=24,hexadecimal"`
+}
+type TlsValidatorData struct {
+	ServerName string `json:"serverName" binding:"omitempty,hostname_rfc1123"`
+	Ip         string `json:"ip" binding:"omitempty,ip"`
+	Port       int    `json:"port" binding:"omitempty,min=1,max=65535"`
+}
+type HealthCheckValidatorData struct {
+	Enabled         bool   `json:"enabled"`
+	Url             string `json:"url" binding:"omitempty,url"`
+	ExpectedStatus  int    `json:"expectedStatus" binding:"omitempty,min=100,max=599"`
+	ExpectedContent string `json:"expectedContent" binding:"max=255"`
+	IntervalSeconds int    `json:"intervalSeconds" binding:"omitempty,min=30"`
+	TimeoutSeconds  int    `json:"timeoutSeconds" binding:"omitempty,min=1,max=60"`
 }
 type DomainValidator struct {
 	DomainData DomainValidatorData `json:"domain"`
 	domain     Domain              `json:"-"`
+	policy     FieldPolicy         `json:"-"`
 }
 
-func (self *DomainValidator) fillModelData() {
-	self.domain.Name = self.DomainData.Name
-	self.domain.Owner = self.DomainData.Owner
-	self.domain.Registrant = self.DomainData.Registrant
+func (self *DomainValidator) fillModelData() error {
+	name, unicodeName, err := NormalizeName(self.DomainData.Name)
+	if err != nil {
+		return err
+	}
+	self.domain.Name = name
+	self.domain.UnicodeName = unicodeName
+	ownerId, err := contactReference(self.DomainData.OwnerId)
+	if err != nil {
+		return err
+	}
+	self.domain.OwnerId = ownerId
+	registrantId, err := contactReference(self.DomainData.RegistrantId)
+	if err != nil {
+		return err
+	}
+	self.domain.RegistrantId = registrantId
 	self.domain.LoginInfo = self.DomainData.LoginInfo
-	self.domain.Package = self.DomainData.Package
+	packageId, err := packageReference(self.DomainData.PackageId, self.domain.PackageId)
+	if err != nil {
+		return err
+	}
+	self.domain.PackageId = packageId
 	self.domain.Mx = self.DomainData.Mx
-	self.domain.Ip = net.ParseIP(self.DomainData.Ip)
-	self.domain.ServerName = self.DomainData.ServerName
-	self.domain.Notes = self.DomainData.Notes
+	if err := self.fillAddresses(); err != nil {
+		return err
+	}
+	serverId, err := serverReference(self.DomainData.ServerId)
+	if err != nil {
+		return err
+	}
+	self.domain.ServerId = serverId
+	self.domain.Registrar = self.DomainData.Registrar
+	self.domain.RegisteredAt = self.DomainData.RegisteredAt
+	self.domain.ExpiresAt = self.DomainData.ExpiresAt
+	self.domain.AutoRenew = self.DomainData.AutoRenew
+	self.domain.RenewalCost = self.DomainData.RenewalCost
+	self.domain.HealthCheck = HealthCheckSettings{
+		Enabled:         self.DomainData.HealthCheck.Enabled,
+		Url:             self.DomainData.HealthCheck.Url,
+		ExpectedStatus:  self.DomainData.HealthCheck.ExpectedStatus,
+		ExpectedContent: self.DomainData.HealthCheck.ExpectedContent,
+		IntervalSeconds: self.DomainData.HealthCheck.IntervalSeconds,
+		TimeoutSeconds:  self.DomainData.HealthCheck.TimeoutSeconds,
+	}
+	self.domain.Tls = TlsSettings{
+		ServerName: strings.ToLower(self.DomainData.Tls.ServerName),
+		Ip:         self.DomainData.Tls.Ip,
+		Port:       self.DomainData.Tls.Port,
+	}
+	self.domain.Tags = normalizeTags(self.DomainData.Tags)
+	self.domain.Billing = []LineItem{}
+	for _, item := range self.DomainData.Billing {
+		self.domain.Billing = append(self.domain.Billing, LineItem{
+			Kind:        item.Kind,
+			Description: strings.TrimSpace(item.Description),
+			Cost:        item.Cost,
+			Price:       item.Price,
+			Currency:    strings.ToUpper(item.Currency),
+			Period:      item.Period,
+			Start:       item.Start,
+			End:         item.End,
+		})
+	}
+	customFields, err := validateCustomFields(self.DomainData.CustomFields)
+	if err != nil {
+		return err
+	}
+	self.domain.CustomFields = customFields
+
+	return nil
+}
+
+// normalizeTags returns the tags lowercased and without duplicates, keeping their order
+func normalizeTags(tags []string) []string {
+	res := []string{}
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			res = append(res, tag)
+			seen[tag] = true
+		}
+	}
+	return res
+}
+
+// validateCustomFields checks the custom field values against the field definitions
+// Values of undefined fields are rejected, as well as missing values of required fields
+func validateCustomFields(values map[string]interface{}) (map[string]interface{}, error) {
+	fieldService := new(customfields.FieldService)
+	fields, err := fieldService.All()
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]interface{})
+	for name := range values {
+		defined := false
+		for _, field := range *fields {
+			defined = defined || field.Name == name
+		}
+		if !defined {
+			return nil, fmt.Errorf("Unknown custom field %s", name)
+		}
+	}
+	for _, field := range *fields {
+		value, ok := values[field.Name]
+		if !ok || value == nil || value == "" {
+			if field.Required {
+				return nil, fmt.Errorf("Custom field %s is required", field.Name)
+			}
+			continue
+		}
+		if res[field.Name], err = field.Validate(value); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// contactReference returns the ID of the referenced contact, checking that it exists
+func contactReference(id string) (primitive.ObjectID, error) {
+	contactService := new(contacts.ContactService)
+	contact, err := contactService.GetById(id)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("Contact %s not found", id)
+	}
+	return contact.ID, nil
+}
+
+// serverReference returns the ID of the referenced server, checking that it exists
+// An empty id means no server is referenced
+func serverReference(id string) (primitive.ObjectID, error) {
+	if id == "" {
+		return primitive.NilObjectID, nil
+	}
+	serverService := new(servers.ServerService)
+	server, err := serverService.GetById(id)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("Server %s not found", id)
+	}
+	return server.ID, nil
+}
+
+// packageReference returns the ID of the referenced hosting package, checking that it exists and is active
+// The current package of the domain is accepted even if it has been deactivated meanwhile
+// An empty id means no package is referenced
+func packageReference(id string, current primitive.ObjectID) (primitive.ObjectID, error) {
+	if id == "" {
+		return primitive.NilObjectID, nil
+	}
+	packageService := new(packages.PackageService)
+	pkg, err := packageService.GetById(id)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("Package %s not found", id)
+	}
+	if !pkg.Active && pkg.ID != current {
+		return primitive.NilObjectID, fmt.Errorf("Package %s is not active", pkg.Name)
+	}
+	return pkg.ID, nil
+}
+
+// fillAddresses sets the domain addresses, the legacy ip field is added as a web address
+// The same ip cannot be added twice
+func (self *DomainValidator) fillAddresses() error {
+	data := self.DomainData.Addresses
+	if self.DomainData.Ip != "" {
+		data = append(data, AddressValidatorData{Ip: self.DomainData.Ip, Purpose: "web"})
+	}
+
+	addresses := make([]Address, 0)
+	seen := make(map[string]bool)
+	for _, addressData := range data {
+		serverId, err := serverReference(addressData.ServerId)
+		if err != nil {
+			return err
+		}
+		address := NewAddress(net.ParseIP(addressData.Ip), addressData.Purpose, serverId)
+		if seen[address.Ip] {
+			return fmt.Errorf("Duplicated address %s", address.Ip)
+		}
+		seen[address.Ip] = true
+		addresses = append(addresses, address)
+	}
+	self.domain.Addresses = addresses
+
+	return nil
 }
 
 func (self *DomainValidator) Bind(c *gin.Context) error {
@@ -41,9 +268,30 @@ func (self *DomainValidator) Bind(c *gin.Context) error {
 		zap.S().Debug("Domain Validation Error: ", err)
 		return err
 	}
-	self.fillModelData()
+	return self.create()
+}
+
+// BindData validates the given domain data, i.e. a row of a bulk import, as Bind does with the request body
+func (self *DomainValidator) BindData(data DomainValidatorData) error {
+	self.DomainData = data
+	if err := binding.Validator.ValidateStruct(&self.DomainData); err != nil {
+		return err
+	}
+	return self.create()
+}
+
+// create fills the model of a new domain
+func (self *DomainValidator) create() error {
+	if err := self.policy.checkCreate(&self.DomainData); err != nil {
+		return err
+	}
+	if err := self.fillModelData(); err != nil {
+		zap.S().Debug("Domain Validation Error: ", err)
+		return err
+	}
 	self.domain.Created = time.Now().Unix()
 	self.domain.Updated = time.Now().Unix()
+	self.domain.Lifecycle = NewLifecycle(self.DomainData.Lifecycle)
 
 	return nil
 }
@@ -54,15 +302,388 @@ func (self *DomainValidator) BindUpdate(domain *Domain, c *gin.Context) error {
 		zap.S().Debug("Domain Validation Error: ", err)
 		return err
 	}
+	return self.update(domain)
+}
+
+// BindDataUpdate validates the given domain data as update of an existing domain, as BindUpdate does with the request body
+func (self *DomainValidator) BindDataUpdate(domain *Domain, data DomainValidatorData) error {
+	self.DomainData = data
+	if err := binding.Validator.ValidateStruct(&self.DomainData); err != nil {
+		return err
+	}
+	return self.update(domain)
+}
+
+// update fills the model of an existing domain, keeping the data not managed through the API
+func (self *DomainValidator) update(domain *Domain) error {
 	self.domain.ID = domain.ID
-	self.fillModelData()
+	self.domain.PackageId = domain.PackageId
+	if err := self.fillModelData(); err != nil {
+		zap.S().Debug("Domain Validation Error: ", err)
+		return err
+	}
+	if err := self.policy.checkUpdate(&self.DomainData, &self.domain, domain); err != nil {
+		return err
+	}
+	self.domain.Created = domain.Created
+	// reminders are sent again when the expiry date changes, i.e. after a renewal
+	if self.domain.ExpiresAt == domain.ExpiresAt {
+		self.domain.ExpiryReminders = domain.ExpiryReminders
+	}
+	self.domain.Registry = domain.Registry
+	self.domain.Dns = domain.Dns
+	self.domain.Certificate = domain.Certificate
+	self.domain.Health = domain.Health
+	self.domain.Zone = domain.Zone
+	self.domain.Notes = domain.Notes
+	self.domain.Managers = domain.Managers
+	self.domain.Lifecycle = domain.Lifecycle
+	// a null history would make the next transition fail
+	if self.domain.Lifecycle.History == nil {
+		self.domain.Lifecycle.History = []LifecycleTransition{}
+	}
 	self.domain.Updated = time.Now().Unix()
 
 	return nil
 }
 
+type RecordValidatorData struct {
+	Name     string `json:"name"`
+	Type     string `json:"type" binding:"required,oneof=A AAAA CNAME MX TXT SRV CAA"`
+	Ttl      uint32 `json:"ttl" binding:"omitempty,min=60,max=604800"`
+	Value    string `json:"value" binding:"required"`
+	Priority uint16 `json:"priority"`
+	Weight   uint16 `json:"weight"`
+	Port     uint16 `json:"port"`
+	Flags    uint8  `json:"flags"`
+	Tag      string `json:"tag"`
+}
+type RecordValidator struct {
+	RecordData RecordValidatorData `json:"record"`
+	record     Record              `json:"-"`
+}
+
+// fillModelData sets and validates the record fields according to the record type
+// A CNAME record cannot share its name with other records of the domain
+func (self *RecordValidator) fillModelData(domain *Domain) error {
+	self.record.DomainId = domain.ID
+	self.record.Name = self.RecordData.Name
+	self.record.Type = self.RecordData.Type
+	self.record.Ttl = self.RecordData.Ttl
+	self.record.Value = self.RecordData.Value
+	self.record.Priority = self.RecordData.Priority
+	self.record.Weight = self.RecordData.Weight
+	self.record.Port = self.RecordData.Port
+	self.record.Flags = self.RecordData.Flags
+	self.record.Tag = self.RecordData.Tag
+	if err := self.record.Normalize(domain.Name); err != nil {
+		return err
+	}
+
+	recordService := new(RecordService)
+	others, err := recordService.byName(domain, self.record.Name)
+	if err != nil {
+		return err
+	}
+	return cnameConflict(&self.record, *others)
+}
+
+func (self *RecordValidator) Bind(domain *Domain, c *gin.Context) error {
+	err := c.ShouldBind(&self.RecordData)
+	if err != nil {
+		zap.S().Debug("Record Validation Error: ", err)
+		return err
+	}
+	if err := self.fillModelData(domain); err != nil {
+		zap.S().Debug("Record Validation Error: ", err)
+		return err
+	}
+	self.record.Created = time.Now().Unix()
+	self.record.Updated = time.Now().Unix()
+
+	return nil
+}
+
+func (self *RecordValidator) BindUpdate(domain *Domain, record *Record, c *gin.Context) error {
+	err := c.ShouldBind(&self.RecordData)
+	if err != nil {
+		zap.S().Debug("Record Validation Error: ", err)
+		return err
+	}
+	self.record.ID = record.ID
+	if err := self.fillModelData(domain); err != nil {
+		zap.S().Debug("Record Validation Error: ", err)
+		return err
+	}
+	self.record.Created = record.Created
+	self.record.Updated = time.Now().Unix()
+
+	return nil
+}
+
+func NewRecordValidator() RecordValidator {
+	recordValidator := RecordValidator{}
+	return recordValidator
+}
+
+type CommentValidatorData struct {
+	Body   string `json:"body" binding:"required,max=20000"` // markdown
+	Pinned bool   `json:"pinned"`
+}
+type CommentValidator struct {
+	CommentData CommentValidatorData `json:"comment"`
+	comment     Comment              `json:"-"`
+}
+
+// Bind validates a new comment of the domain, written by the given user
+func (self *CommentValidator) Bind(domain *Domain, author *auth.User, c *gin.Context) error {
+	err := c.ShouldBind(&self.CommentData)
+	if err != nil {
+		zap.S().Debug("Comment Validation Error: ", err)
+		return err
+	}
+	if strings.TrimSpace(self.CommentData.Body) == "" {
+		return errors.New("The comment body cannot be empty")
+	}
+	self.comment.DomainId = domain.ID
+	self.comment.AuthorId = author.ID
+	self.comment.Author = author.Email
+	self.comment.Body = self.CommentData.Body
+	self.comment.Pinned = self.CommentData.Pinned
+	self.comment.History = []CommentRevision{}
+	self.comment.Created = time.Now().Unix()
+	self.comment.Updated = time.Now().Unix()
+
+	return nil
+}
+
+// BindUpdate validates the edit of a comment, the previous body is kept in its history
+// Pinning is not changed by edits, see the pin views
+func (self *CommentValidator) BindUpdate(comment *Comment, c *gin.Context) error {
+	err := c.ShouldBind(&self.CommentData)
+	if err != nil {
+		zap.S().Debug("Comment Validation Error: ", err)
+		return err
+	}
+	if strings.TrimSpace(self.CommentData.Body) == "" {
+		return errors.New("The comment body cannot be empty")
+	}
+	self.comment = *comment
+	self.comment.Edit(self.CommentData.Body)
+
+	return nil
+}
+
+func NewCommentValidator() CommentValidator {
+	commentValidator := CommentValidator{}
+	return commentValidator
+}
+
+type TransitionValidatorData struct {
+	Status string `json:"status" binding:"required,oneof=pending active transfer-in transfer-out expired cancelled"`
+	Reason string `json:"reason" binding:"max=1000"`
+}
+type TransitionValidator struct {
+	TransitionData TransitionValidatorData `json:"transition"`
+}
+
+func (self *TransitionValidator) Bind(c *gin.Context) error {
+	err := c.ShouldBind(&self.TransitionData)
+	if err != nil {
+		zap.S().Debug("Transition Validation Error: ", err)
+		return err
+	}
+	self.TransitionData.Reason = strings.TrimSpace(self.TransitionData.Reason)
+
+	return nil
+}
+
+func NewTransitionValidator() TransitionValidator {
+	transitionValidator := TransitionValidator{}
+	return transitionValidator
+}
+
+type BulkSetValidatorData struct {
+	OwnerId      *string  `json:"ownerId" binding:"omitempty,len=24,hexadecimal"`
+	RegistrantId *string  `json:"registrantId" binding:"omitempty,len=24,hexadecimal"`
+	PackageId    *string  `json:"packageId"` // empty to unset
+	ServerId     *string  `json:"serverId"`  // empty to unset
+	Mx           *bool    `json:"mx"`
+	Registrar    *string  `json:"registrar" binding:"omitempty,max=255"`
+	AutoRenew    *bool    `json:"autoRenew"`
+	RenewalCost  *float64 `json:"renewalCost" binding:"omitempty,gte=0"`
+}
+type BulkValidatorData struct {
+	Ids        []string             `json:"ids" binding:"dive,len=24,hexadecimal"`
+	Set        BulkSetValidatorData `json:"set"`
+	AddTags    []string             `json:"addTags" binding:"dive,required,max=64"`
+	RemoveTags []string             `json:"removeTags" binding:"dive,required,max=64"`
+	Delete     bool                 `json:"delete"`
+	DryRun     bool                 `json:"dryRun"`
+}
+type BulkValidator struct {
+	BulkData  BulkValidatorData `json:"bulk"`
+	operation BulkOperation     `json:"-"`
+	policy    FieldPolicy       `json:"-"`
+}
+
+// Bind validates the bulk operation: the changes are read from the body, the selection from the ids
+// and from the query string, which holds the same filters as the domains list
+func (self *BulkValidator) Bind(c *gin.Context) error {
+	err := c.ShouldBindJSON(&self.BulkData)
+	if err != nil {
+		zap.S().Debug("Bulk Validation Error: ", err)
+		return err
+	}
+	self.operation.Filter = NewDomainFilter()
+	if err := self.operation.Filter.Bind(c); err != nil {
+		return err
+	}
+	if len(self.BulkData.Ids) == 0 && self.operation.Filter.empty() {
+		return errors.New("Select the domains by ids or by filter")
+	}
+	for _, id := range self.BulkData.Ids {
+		objectId, _ := primitive.ObjectIDFromHex(id)
+		self.operation.Ids = append(self.operation.Ids, objectId)
+	}
+
+	data := self.BulkData.Set
+	set := map[string]interface{}{}
+	if data.OwnerId != nil {
+		if set["ownerId"], err = contactReference(*data.OwnerId); err != nil {
+			return err
+		}
+	}
+	if data.RegistrantId != nil {
+		if set["registrantId"], err = contactReference(*data.RegistrantId); err != nil {
+			return err
+		}
+	}
+	if data.PackageId != nil {
+		if set["packageId"], err = packageReference(*data.PackageId, primitive.NilObjectID); err != nil {
+			return err
+		}
+	}
+	if data.ServerId != nil {
+		if set["serverId"], err = serverReference(*data.ServerId); err != nil {
+			return err
+		}
+	}
+	if data.Mx != nil {
+		set["mx"] = *data.Mx
+	}
+	if data.Registrar != nil {
+		set["registrar"] = strings.TrimSpace(*data.Registrar)
+	}
+	if data.AutoRenew != nil {
+		set["autoRenew"] = *data.AutoRenew
+	}
+	if data.RenewalCost != nil {
+		set["renewalCost"] = *data.RenewalCost
+	}
+	self.operation.Set = set
+	self.operation.AddTags = normalizeTags(self.BulkData.AddTags)
+	self.operation.RemoveTags = normalizeTags(self.BulkData.RemoveTags)
+	self.operation.Delete = self.BulkData.Delete
+
+	hasTags := len(self.operation.AddTags) > 0 || len(self.operation.RemoveTags) > 0
+	if !self.operation.Delete && len(set) == 0 && !hasTags {
+		return errors.New("The bulk operation has no changes, set fields, add or remove tags or delete the domains")
+	}
+	if self.operation.Delete && (len(set) > 0 || hasTags) {
+		return errors.New("The domains cannot be changed and deleted by the same bulk operation")
+	}
+	for name := range set {
+		if !self.policy.CanWrite(name) {
+			return &FieldForbiddenError{Field: name}
+		}
+	}
+	if hasTags && !self.policy.CanWrite("tags") {
+		return &FieldForbiddenError{Field: "tags"}
+	}
+
+	return nil
+}
+
+// NewBulkValidatorFor returns a bulk validator enforcing the field policy of the user role
+func NewBulkValidatorFor(user *auth.User) BulkValidator {
+	bulkValidator := BulkValidator{policy: fieldPolicyFor(user)}
+	return bulkValidator
+}
+
+type QualityFixValidatorData struct {
+	Findings []string `json:"findings" binding:"required,min=1,max=1000,dive,required"`
+	DryRun   bool     `json:"dryRun"`
+}
+type QualityFixValidator struct {
+	FixData QualityFixValidatorData `json:"fix"`
+}
+
+func (self *QualityFixValidator) Bind(c *gin.Context) error {
+	err := c.ShouldBind(&self.FixData)
+	if err != nil {
+		zap.S().Debug("Quality Fix Validation Error: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func NewQualityFixValidator() QualityFixValidator {
+	qualityFixValidator := QualityFixValidator{}
+	return qualityFixValidator
+}
+
+type ManagerValidatorData struct {
+	UserId string `json:"userId" binding:"required,len=24,hexadecimal"`
+}
+type ManagerValidator struct {
+	ManagerData ManagerValidatorData `json:"manager"`
+	user        *auth.User           `json:"-"`
+}
+
+// Bind validates the user to assign as domain manager, who must exist and have the user role
+func (self *ManagerValidator) Bind(c *gin.Context) error {
+	err := c.ShouldBind(&self.ManagerData)
+	if err != nil {
+		zap.S().Debug("Manager Validation Error: ", err)
+		return err
+	}
+	userService := new(auth.UserService)
+	user, err := userService.GetById(self.ManagerData.UserId)
+	if err != nil {
+		return fmt.Errorf("User %s not found", self.ManagerData.UserId)
+	}
+	if err := checkManager(user); err != nil {
+		return err
+	}
+	self.user = user
+
+	return nil
+}
+
+// checkManager checks that the user can be assigned as domain manager
+// Only the members of the user role are scoped to the domains they manage, admins already access all the domains
+func checkManager(user *auth.User) error {
+	if user.Role != "user" {
+		return fmt.Errorf("User %s has the %s role, only users can be assigned as domain managers", user.Email, user.Role)
+	}
+	return nil
+}
+
+func NewManagerValidator() ManagerValidator {
+	managerValidator := ManagerValidator{}
+	return managerValidator
+}
+
 // You can put the default value of a Validator here
 func NewDomainValidator() DomainValidator {
 	domainValidator := DomainValidator{}
 	return domainValidator
 }
+
+// NewDomainValidatorFor returns a validator rejecting the changes to the fields the user cannot write
+func NewDomainValidatorFor(user *auth.User) DomainValidator {
+	domainValidator := DomainValidator{policy: fieldPolicyFor(user)}
+	return domainValidator
+}
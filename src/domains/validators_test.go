@@ -0,0 +1,15 @@
+package domains
+
+import (
+	"systems-management-api/auth"
+	"testing"
+)
+
+func TestCheckManager(t *testing.T) {
+	for role, valid := range map[string]bool{"user": true, "admin": false, "superadmin": false} {
+		err := checkManager(&auth.User{Email: role + "@example.com", Role: role})
+		if (err == nil) != valid {
+			t.Fatalf("unexpected result for role %s: %v", role, err)
+		}
+	}
+}
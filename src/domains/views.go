@@ -1,28 +1,80 @@
 package domains
 
 import (
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
+	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"systems-management-api/auth"
+	"systems-management-api/contacts"
+	"systems-management-api/core/dnscheck"
+	"systems-management-api/core/export"
+	"systems-management-api/core/ical"
+	"systems-management-api/core/registration"
+	"systems-management-api/core/tlscheck"
 	"systems-management-api/core/utils"
+	"systems-management-api/jobs"
+	"systems-management-api/packages"
+	"systems-management-api/servers"
+	"time"
 )
 
+// validationStatus returns the status of a domain validation error, forbidden when writing a field denied by the field policy
+func validationStatus(err error) int {
+	var forbidden *FieldForbiddenError
+	if errors.As(err, &forbidden) {
+		return http.StatusForbidden
+	}
+	return http.StatusUnprocessableEntity
+}
+
+// duplicateNameResponse error returned when saving a domain whose name is already taken
+func duplicateNameResponse(domain *Domain) utils.ErrorResponse {
+	return utils.ErrorResponse{
+		Message: fmt.Sprintf("A domain named %s already exists (trashed domains included)", domain.Name),
+	}
+}
+
 // Returns all domains, admin or superadmin roles required
 // @Summary Domains list
-// @Description Retrieves all domains
+// @Description Retrieves all domains, optionally filtered by ip address or network, tags and custom fields (field.<name>=value parameters). Members of the user role only get the domains they manage
 // @Security BearerAuth
 // @Tags domains
 // @Accept  json
 // @Produce  json
+// @Param ip query string false "Domains having this ip address"
+// @Param cidr query string false "Domains having an ip address in this network (CIDR notation)"
+// @Param server query string false "Domains hosted on this server (server ID)"
+// @Param owner query string false "Domains owned by this contact (contact ID)"
+// @Param registrant query string false "Domains registered by this contact (contact ID)"
+// @Param contact query string false "Domains owned or registered by this contact (contact ID)"
+// @Param expiringWithin query int false "Domains whose registration expires within this number of days"
+// @Param dnsDrift query bool false "Domains whose DNS records differ from the stored configuration at the last check"
+// @Param lifecycle query string false "Domains in this lifecycle status"
+// @Param tag query string false "Domains having all these tags (comma separated)"
 // @Success 200 {array} DomainData
 // @Failure 403 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /domain/ [get]
 func domainListView(c *gin.Context) {
-	domainService := new(DomainService)
-	domains, err := domainService.all()
+	filter := NewDomainFilter()
+	if err := filter.Bind(c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	domainService := NewDomainService(c.MustGet("user").(*auth.User))
+	domains, err := domainService.list(&filter)
 
 	if err != nil {
 		zap.S().Error("Error while getting all domains, Reason: ", err)
@@ -30,16 +82,98 @@ func domainListView(c *gin.Context) {
 			Message: "Cannot fetch domains",
 		})
 	} else {
-		serializer := NewDomainSerializer()
+		serializer := NewDomainSerializerFor(c.MustGet("user").(*auth.User))
+		c.JSON(http.StatusOK, serializer.SerializeMany(domains))
+	}
+}
+
+var DomainListView = auth.RoleRequired([]string{"admin", "superadmin", "user"}, domainListView)
+
+// Returns all domains hosted on a server, admin or superadmin roles required
+// @Summary Server domains list
+// @Description Retrieves all domains referencing the server, directly or through one of their addresses
+// @Security BearerAuth
+// @Tags servers
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Server ID"
+// @Success 200 {array} DomainData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /server/{id}/domains [get]
+func serverDomainListView(c *gin.Context) {
+	serverService := new(servers.ServerService)
+	server, err := serverService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting server, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Server not found"})
+		return
+	}
+
+	filter := NewDomainFilter()
+	filter.Server = server.ID.Hex()
+	domainService := new(DomainService)
+	domains, err := domainService.list(&filter)
+
+	if err != nil {
+		zap.S().Error("Error while getting server domains, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{
+			Message: "Cannot fetch domains",
+		})
+	} else {
+		serializer := NewDomainSerializerFor(c.MustGet("user").(*auth.User))
+		c.JSON(http.StatusOK, serializer.SerializeMany(domains))
+	}
+}
+
+var ServerDomainListView = auth.RoleRequired([]string{"admin", "superadmin"}, serverDomainListView)
+
+// Returns all domains owned or registered by a contact, admin or superadmin roles required
+// @Summary Contact domains list
+// @Description Retrieves all domains owned or registered by the contact
+// @Security BearerAuth
+// @Tags contacts
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Contact ID"
+// @Success 200 {array} DomainData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /contact/{id}/domains [get]
+func contactDomainListView(c *gin.Context) {
+	contactService := new(contacts.ContactService)
+	contact, err := contactService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting contact, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Contact not found"})
+		return
+	}
+
+	filter := NewDomainFilter()
+	filter.Contact = contact.ID.Hex()
+	domainService := new(DomainService)
+	domains, err := domainService.list(&filter)
+
+	if err != nil {
+		zap.S().Error("Error while getting contact domains, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{
+			Message: "Cannot fetch domains",
+		})
+	} else {
+		serializer := NewDomainSerializerFor(c.MustGet("user").(*auth.User))
 		c.JSON(http.StatusOK, serializer.SerializeMany(domains))
 	}
 }
 
-var DomainListView = auth.RoleRequired([]string{"admin", "superadmin"}, domainListView)
+var ContactDomainListView = auth.RoleRequired([]string{"admin", "superadmin"}, contactDomainListView)
 
 // Returns domain given its id
 // @Summary Domain detail
-// @Description Retrieves one domain given its id
+// @Description Retrieves one domain given its id, members of the user role only get the domains they manage
 // @Security BearerAuth
 // @Tags domains
 // @Accept  json
@@ -50,23 +184,27 @@ var DomainListView = auth.RoleRequired([]string{"admin", "superadmin"}, domainLi
 // @Failure 404 {object} utils.ErrorResponse
 // @Router /domain/{id} [get]
 func domainDetailView(c *gin.Context) {
-	domainService := new(DomainService)
+	domainService := NewDomainService(c.MustGet("user").(*auth.User))
 	domain, err := domainService.GetById(c.Param("id"))
 
 	if err != nil {
 		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
 		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
 	} else {
-		serializer := NewDomainSerializer()
-		c.JSON(http.StatusOK, serializer.Serialize(domain))
+		summaries, err := uptimeSummaries(domain)
+		if err != nil {
+			zap.S().Errorw("Error while computing domain uptime", "id", c.Param("id"), "error", err)
+		}
+		serializer := NewDomainSerializerFor(c.MustGet("user").(*auth.User))
+		c.JSON(http.StatusOK, serializer.SerializeWithUptime(domain, summaries))
 	}
 }
 
-var DomainDetailView = auth.RoleRequired([]string{"admin", "superadmin"}, domainDetailView)
+var DomainDetailView = auth.RoleRequired([]string{"admin", "superadmin", "user"}, domainDetailView)
 
 // Creates a domain
 // @Summary Create domain
-// @Description Creates a domain
+// @Description Creates a domain, setting a field the role cannot write (see the field policy) is forbidden
 // @Security BearerAuth
 // @Tags domains
 // @Accept  json
@@ -74,20 +212,25 @@ var DomainDetailView = auth.RoleRequired([]string{"admin", "superadmin"}, domain
 // @Param domain body DomainValidatorData true "Domain data"
 // @Success 201 {object} DomainData
 // @Failure 403 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
 // @Failure 422 {object} utils.ErrorResponse
 // @Router /domain/ [post]
 func createDomainView(c *gin.Context) {
-	domainValidator := NewDomainValidator()
+	domainValidator := NewDomainValidatorFor(c.MustGet("user").(*auth.User))
 	if err := domainValidator.Bind(c); err != nil {
-		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		c.JSON(validationStatus(err), utils.ErrorResponse{Message: err.Error()})
 		return
 	}
 
 	if _, err := domainValidator.domain.Save(); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, duplicateNameResponse(&domainValidator.domain))
+			return
+		}
 		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Cannot insert user: %v", err)})
 		return
 	}
-	serializer := NewDomainSerializer()
+	serializer := NewDomainSerializerFor(c.MustGet("user").(*auth.User))
 	c.JSON(http.StatusCreated, serializer.Serialize(&domainValidator.domain))
 }
 
@@ -95,7 +238,7 @@ var CreateDomainView = auth.RoleRequired([]string{"admin", "superadmin"}, create
 
 // Updates a domain
 // @Summary Update domain
-// @Description Updates a domain
+// @Description Updates a domain, members of the user role can only update the domains they manage. Changing a field the role cannot write (see the field policy) is forbidden, omitted fields are kept
 // @Security BearerAuth
 // @Tags domains
 // @Accept  json
@@ -105,10 +248,11 @@ var CreateDomainView = auth.RoleRequired([]string{"admin", "superadmin"}, create
 // @Success 200 {object} DomainData
 // @Failure 403 {object} utils.ErrorResponse
 // @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
 // @Failure 422 {object} utils.ErrorResponse
 // @Router /domain/{id} [put]
 func updateDomainView(c *gin.Context) {
-	domainService := new(DomainService)
+	domainService := NewDomainService(c.MustGet("user").(*auth.User))
 	domain, err := domainService.GetById(c.Param("id"))
 
 	if err != nil {
@@ -117,25 +261,159 @@ func updateDomainView(c *gin.Context) {
 		return
 	}
 
-	domainValidator := NewDomainValidator()
+	domainValidator := NewDomainValidatorFor(c.MustGet("user").(*auth.User))
 	if err := domainValidator.BindUpdate(domain, c); err != nil {
-		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		c.JSON(validationStatus(err), utils.ErrorResponse{Message: err.Error()})
 		return
 	}
 
-	if _, err := domainValidator.domain.Save(); err != nil {
+	if _, err := domainService.Save(&domainValidator.domain); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, duplicateNameResponse(&domainValidator.domain))
+			return
+		}
 		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Cannot update domain: %v", err)})
 		return
 	}
-	serializer := NewDomainSerializer()
+	serializer := NewDomainSerializerFor(c.MustGet("user").(*auth.User))
 	c.JSON(http.StatusOK, serializer.Serialize(&domainValidator.domain))
 }
 
-var UpdateDomainView = auth.RoleRequired([]string{"admin", "superadmin"}, updateDomainView)
+var UpdateDomainView = auth.RoleRequired([]string{"admin", "superadmin", "user"}, updateDomainView)
+
+// Assigns a manager to the domain
+// @Summary Add domain manager
+// @Description Assigns an user as manager of the domain: members of the user role can list, see and edit the domains they manage
+// @Security BearerAuth
+// @Tags domains
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Param manager body ManagerValidatorData true "Manager data"
+// @Success 200 {object} DomainData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /domain/{id}/managers [post]
+func addManagerView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	managerValidator := NewManagerValidator()
+	if err := managerValidator.Bind(c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if err := domainService.AddManager(domain, managerValidator.user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot add manager: %v", err)})
+		return
+	}
+	serializer := NewDomainSerializerFor(c.MustGet("user").(*auth.User))
+	c.JSON(http.StatusOK, serializer.Serialize(domain))
+}
+
+var AddManagerView = auth.RoleRequired([]string{"admin", "superadmin"}, addManagerView)
+
+// Unassigns a manager from the domain
+// @Summary Remove domain manager
+// @Description Unassigns an user from the managers of the domain
+// @Security BearerAuth
+// @Tags domains
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Param userId path string true "User ID"
+// @Success 200 {object} DomainData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /domain/{id}/managers/{userId} [delete]
+func removeManagerView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	userId, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil || !domain.HasManager(userId) {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Manager not found"})
+		return
+	}
+
+	if err := domainService.RemoveManager(domain, userId); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot remove manager: %v", err)})
+		return
+	}
+	serializer := NewDomainSerializerFor(c.MustGet("user").(*auth.User))
+	c.JSON(http.StatusOK, serializer.Serialize(domain))
+}
+
+var RemoveManagerView = auth.RoleRequired([]string{"admin", "superadmin"}, removeManagerView)
+
+// Moves the domain to another lifecycle status
+// @Summary Domain lifecycle transition
+// @Description Moves the domain to another lifecycle status (pending, active, transfer-in, transfer-out, expired, cancelled) if the transition is allowed, recording who made it, when and why. Transitions to the statuses listed in domains.lifecycle.notify are notified
+// @Security BearerAuth
+// @Tags domains
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Param transition body TransitionValidatorData true "Transition data"
+// @Success 200 {object} DomainData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /domain/{id}/lifecycle [post]
+func transitionView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	transitionValidator := NewTransitionValidator()
+	if err := transitionValidator.Bind(c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(*auth.User)
+	err = Transition(domain, transitionValidator.TransitionData.Status, transitionValidator.TransitionData.Reason, user.Email)
+	var notAllowed *LifecycleTransitionError
+	switch {
+	case errors.As(err, &notAllowed), errors.Is(err, ErrLifecycleChanged):
+		c.JSON(http.StatusConflict, utils.ErrorResponse{Message: err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot change lifecycle status: %v", err)})
+		return
+	}
+	serializer := NewDomainSerializerFor(user)
+	c.JSON(http.StatusOK, serializer.Serialize(domain))
+}
+
+var TransitionView = auth.RoleRequired([]string{"admin", "superadmin"}, transitionView)
 
-// Deletes a domain
+// Moves a domain to the trash
 // @Summary Delete domain
-// @Description Deletes a domain
+// @Description Moves a domain to the trash, it can be restored until it is purged
 // @Security BearerAuth
 // @Tags domains
 // @Accept  json
@@ -154,11 +432,1628 @@ func deleteDomainView(c *gin.Context) {
 		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
 		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
 	} else {
-		if _, err := domain.Delete(); err != nil {
+		if _, err := domain.Trash(); err != nil {
 			c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: err.Error()})
+			return
 		}
 		c.JSON(http.StatusNoContent, gin.H{})
 	}
 }
 
 var DeleteDomainView = auth.RoleRequired([]string{"admin", "superadmin"}, deleteDomainView)
+
+// Returns all trashed domains, admin or superadmin roles required
+// @Summary Trashed domains list
+// @Description Retrieves all domains in the trash
+// @Security BearerAuth
+// @Tags domains
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} DomainData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /trash/domain [get]
+func trashedDomainListView(c *gin.Context) {
+	domainService := new(DomainService)
+	domains, err := domainService.trashed()
+
+	if err != nil {
+		zap.S().Error("Error while getting trashed domains, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{
+			Message: "Cannot fetch trashed domains",
+		})
+	} else {
+		serializer := NewDomainSerializerFor(c.MustGet("user").(*auth.User))
+		c.JSON(http.StatusOK, serializer.SerializeMany(domains))
+	}
+}
+
+var TrashedDomainListView = auth.RoleRequired([]string{"admin", "superadmin"}, trashedDomainListView)
+
+// Restores a trashed domain
+// @Summary Restore domain
+// @Description Restores a domain from the trash
+// @Security BearerAuth
+// @Tags domains
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Success 200 {object} DomainData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /trash/domain/{id}/restore [post]
+func restoreDomainView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetTrashedById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting trashed domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found in trash"})
+		return
+	}
+
+	if _, err := domain.Restore(); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot restore domain: %v", err)})
+		return
+	}
+	serializer := NewDomainSerializerFor(c.MustGet("user").(*auth.User))
+	c.JSON(http.StatusOK, serializer.Serialize(domain))
+}
+
+var RestoreDomainView = auth.RoleRequired([]string{"admin", "superadmin"}, restoreDomainView)
+
+// Permanently deletes a trashed domain, superadmin role required
+// @Summary Purge domain
+// @Description Permanently deletes a domain from the trash
+// @Security BearerAuth
+// @Tags domains
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Success 204
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /trash/domain/{id} [delete]
+func purgeDomainView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetTrashedById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting trashed domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found in trash"})
+		return
+	}
+
+	if _, err := domain.Delete(); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, gin.H{})
+}
+
+var PurgeDomainView = auth.RoleRequired([]string{"superadmin"}, purgeDomainView)
+
+// Returns the domains count and revenue per hosting package, admin or superadmin roles required
+// @Summary Packages report
+// @Description Retrieves the number of domains and the monthly and yearly revenue of every hosting package, trashed domains excluded
+// @Security BearerAuth
+// @Tags packages
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} PackageReportData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /report/package [get]
+func packageReportView(c *gin.Context) {
+	packageService := new(packages.PackageService)
+	pkgs, err := packageService.All()
+	if err != nil {
+		zap.S().Error("Error while getting all packages, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: "Cannot fetch packages"})
+		return
+	}
+
+	domainService := new(DomainService)
+	counts, err := domainService.countByPackage()
+	if err != nil {
+		zap.S().Error("Error while counting domains per package, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: "Cannot count domains"})
+		return
+	}
+
+	serializer := NewDomainSerializer()
+	c.JSON(http.StatusOK, serializer.SerializePackageReport(pkgs, counts))
+}
+
+var PackageReportView = auth.RoleRequired([]string{"admin", "superadmin"}, packageReportView)
+
+// Refreshes the domain registration data from its registry (RDAP with WHOIS fallback)
+// @Summary Refresh domain registry data
+// @Description Looks up the registrar, statuses, nameservers and dates published by the domain registry. If apply is true, the stored registrar and dates are overwritten with the published ones
+// @Security BearerAuth
+// @Tags domains
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Param apply query bool false "Overwrite stored registration fields with the published ones"
+// @Success 200 {object} DomainData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /domain/{id}/registry/refresh [post]
+func refreshRegistryView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	apply := c.Query("apply") == "true" || c.Query("apply") == "1"
+	if err := RefreshRegistry(domain, registration.NewClient(), apply); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot refresh domain registry data: %v", err)})
+		return
+	}
+	serializer := NewDomainSerializerFor(c.MustGet("user").(*auth.User))
+	c.JSON(http.StatusOK, serializer.Serialize(domain))
+}
+
+var RefreshRegistryView = auth.RoleRequired([]string{"admin", "superadmin"}, refreshRegistryView)
+
+// Returns the domains whose stored registration fields differ from the registry ones, admin or superadmin roles required
+// @Summary Registry discrepancies report
+// @Description Retrieves the domains whose registrar, registration or expiry date differ from the ones published by the registry at the last check
+// @Security BearerAuth
+// @Tags domains
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} RegistryReportData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /report/registry [get]
+func registryReportView(c *gin.Context) {
+	domainService := new(DomainService)
+	domains, err := domainService.withRegistry()
+
+	if err != nil {
+		zap.S().Error("Error while getting domains registry data, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: "Cannot fetch domains"})
+		return
+	}
+	serializer := NewDomainSerializer()
+	c.JSON(http.StatusOK, serializer.SerializeRegistryReport(domains))
+}
+
+var RegistryReportView = auth.RoleRequired([]string{"admin", "superadmin"}, registryReportView)
+
+// Returns the projected invoices of the domain owners for a period, admin or superadmin roles required
+// @Summary Billing report
+// @Description Projects the charges of the domain line items (and of the hosting package and renewal cost when no line of the same kind is set) in the [from, to) period, grouped per owner with totals per currency. The period defaults to the current month
+// @Security BearerAuth
+// @Tags domains
+// @Accept  json
+// @Produce  json
+// @Param from query string false "First day of the period (YYYY-MM-DD)"
+// @Param to query string false "Day following the period (YYYY-MM-DD)"
+// @Param ip query string false "Domains having this ip address"
+// @Param cidr query string false "Domains having an ip address in this network (CIDR notation)"
+// @Param server query string false "Domains hosted on this server (server ID)"
+// @Param owner query string false "Domains owned by this contact (contact ID)"
+// @Param registrant query string false "Domains registered by this contact (contact ID)"
+// @Param contact query string false "Domains owned or registered by this contact (contact ID)"
+// @Param expiringWithin query int false "Domains whose registration expires within this number of days"
+// @Param dnsDrift query bool false "Domains whose DNS records differ from the stored configuration at the last check"
+// @Param lifecycle query string false "Domains in this lifecycle status"
+// @Param tag query string false "Domains having all these tags (comma separated)"
+// @Success 200 {array} BillingInvoiceData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /report/billing [get]
+func billingReportView(c *gin.Context) {
+	from, to, err := parseBillingPeriod(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+	filter := NewDomainFilter()
+	if err := filter.Bind(c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	domainService := new(DomainService)
+	domains, err := domainService.list(&filter)
+	if err != nil {
+		zap.S().Error("Error while getting all domains, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: "Cannot fetch domains"})
+		return
+	}
+	invoices, err := projectInvoices(domains, from, to)
+	if err != nil {
+		zap.S().Error("Error while projecting invoices, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: "Cannot project invoices"})
+		return
+	}
+	serializer := NewDomainSerializer()
+	c.JSON(http.StatusOK, serializer.SerializeInvoices(invoices))
+}
+
+var BillingReportView = auth.RoleRequired([]string{"admin", "superadmin"}, billingReportView)
+
+// Returns the data quality report of the domains, admin or superadmin roles required
+// @Summary Data quality report
+// @Description Runs the data quality checks over the domains which are not trashed (domains without owner or registrant, addresses whose ip no server claims, near-duplicate owners, active domains already expired) and returns the findings sorted by severity, with the suggested fixes. Findings having an automatic fix can be fixed with POST /report/quality/fix
+// @Security BearerAuth
+// @Tags domains
+// @Accept  json
+// @Produce  json
+// @Param check query string false "Comma separated names of the checks to run, all by default"
+// @Param severity query string false "Return only the findings of this severity: error, warning or info"
+// @Success 200 {object} QualityReportData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /report/quality [get]
+func qualityReportView(c *gin.Context) {
+	names := []string{}
+	if c.Query("check") != "" {
+		names = strings.Split(c.Query("check"), ",")
+	}
+	severity := c.Query("severity")
+	if severity != "" && !utils.Contains(Severities, severity) {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Unknown severity %s, expected one of %s", severity, strings.Join(Severities, ", "))})
+		return
+	}
+
+	findings, err := runQualityChecks(names)
+	if err != nil {
+		var unknown *UnknownCheckError
+		if errors.As(err, &unknown) {
+			c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+			return
+		}
+		zap.S().Error("Error while running quality checks, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot run quality checks: %v", err)})
+		return
+	}
+	if severity != "" {
+		filtered := []Finding{}
+		for _, finding := range findings {
+			if finding.Severity == severity {
+				filtered = append(filtered, finding)
+			}
+		}
+		findings = filtered
+	}
+	serializer := NewDomainSerializer()
+	c.JSON(http.StatusOK, serializer.SerializeQualityReport(QualityChecks(), findings))
+}
+
+var QualityReportView = auth.RoleRequired([]string{"admin", "superadmin"}, qualityReportView)
+
+// Applies the automatic fixes of the selected data quality findings, admin or superadmin roles required
+// @Summary Apply data quality fixes
+// @Description Runs the checks again and applies the automatic fixes of the selected findings (by ID, as returned by the quality report). Findings solved meanwhile, without automatic fix or whose fix fails are reported with an error. With dryRun the fixes are only reported
+// @Security BearerAuth
+// @Tags domains
+// @Accept  json
+// @Produce  json
+// @Param fix body QualityFixValidatorData true "Findings to fix"
+// @Success 200 {array} FixResultData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /report/quality/fix [post]
+func qualityFixView(c *gin.Context) {
+	qualityFixValidator := NewQualityFixValidator()
+	if err := qualityFixValidator.Bind(c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(*auth.User)
+	results, err := applyQualityFixes(qualityFixValidator.FixData.Findings, qualityFixValidator.FixData.DryRun, user.Email)
+	if err != nil {
+		zap.S().Error("Error while applying quality fixes, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot apply fixes: %v", err)})
+		return
+	}
+	serializer := NewDomainSerializer()
+	c.JSON(http.StatusOK, serializer.SerializeFixResults(results))
+}
+
+var QualityFixView = auth.RoleRequired([]string{"admin", "superadmin"}, qualityFixView)
+
+// Checks the domain DNS records against the stored configuration
+// @Summary Check domain DNS
+// @Description Resolves the domain A, AAAA, MX and NS records, compares them with the stored addresses, mx flag and registry nameservers and stores the result
+// @Security BearerAuth
+// @Tags domains
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Success 200 {object} DomainData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /domain/{id}/dns/check [post]
+func checkDnsView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	if err := CheckDns(domain, dnscheck.NewChecker()); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot check domain DNS: %v", err)})
+		return
+	}
+	serializer := NewDomainSerializerFor(c.MustGet("user").(*auth.User))
+	c.JSON(http.StatusOK, serializer.Serialize(domain))
+}
+
+var CheckDnsView = auth.RoleRequired([]string{"admin", "superadmin"}, checkDnsView)
+
+// Checks the certificate presented by the domain HTTPS endpoint
+// @Summary Check domain certificate
+// @Description Connects to the domain HTTPS endpoint (using the domain tls settings) and stores issuer, SANs, validity dates and chain errors of the presented certificate
+// @Security BearerAuth
+// @Tags domains
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Success 200 {object} DomainData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /domain/{id}/certificate/check [post]
+func checkCertificateView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	checker, err := tlscheck.NewChecker()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot create TLS checker: %v", err)})
+		return
+	}
+	if err := CheckCertificate(domain, checker); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot check domain certificate: %v", err)})
+		return
+	}
+	serializer := NewDomainSerializerFor(c.MustGet("user").(*auth.User))
+	c.JSON(http.StatusOK, serializer.Serialize(domain))
+}
+
+var CheckCertificateView = auth.RoleRequired([]string{"admin", "superadmin"}, checkCertificateView)
+
+// Runs the health check of the domain
+// @Summary Check domain health
+// @Description Requests the domain health check url, stores the result and updates the domain health state
+// @Security BearerAuth
+// @Tags domains
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Success 200 {object} DomainData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /domain/{id}/health/check [post]
+func checkHealthView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	if err := CheckHealth(domain); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot check domain health: %v", err)})
+		return
+	}
+	serializer := NewDomainSerializerFor(c.MustGet("user").(*auth.User))
+	c.JSON(http.StatusOK, serializer.Serialize(domain))
+}
+
+var CheckHealthView = auth.RoleRequired([]string{"admin", "superadmin"}, checkHealthView)
+
+// Lists the health check results of the domain
+// @Summary Domain health history
+// @Description Lists the health check results of the domain in the given number of hours (default 24), most recent first
+// @Security BearerAuth
+// @Tags domains
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Param hours query int false "Hours of history"
+// @Success 200 {array} HealthResultData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /domain/{id}/health/history [get]
+func healthHistoryView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	hours := 24
+	if value := c.Query("hours"); value != "" {
+		hours, err = strconv.Atoi(value)
+		if err != nil || hours <= 0 {
+			c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: "Invalid hours parameter"})
+			return
+		}
+	}
+	results, err := domainService.healthResults(domain, time.Now().Add(-time.Duration(hours)*time.Hour))
+	if err != nil {
+		zap.S().Error("Error while getting domain health history, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: "Cannot get domain health history"})
+		return
+	}
+	serializer := NewDomainSerializer()
+	c.JSON(http.StatusOK, serializer.SerializeHealthResults(results))
+}
+
+var HealthHistoryView = auth.RoleRequired([]string{"admin", "superadmin"}, healthHistoryView)
+
+// Lists the DNS records of the domain
+// @Summary Domain DNS records
+// @Description Retrieves the DNS records of the domain, sorted by name and type
+// @Security BearerAuth
+// @Tags records
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Success 200 {array} RecordData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /domain/{id}/records [get]
+func recordListView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	recordService := new(RecordService)
+	records, err := recordService.all(domain)
+	if err != nil {
+		zap.S().Error("Error while getting domain records, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: "Cannot fetch records"})
+		return
+	}
+	serializer := NewDomainSerializer()
+	c.JSON(http.StatusOK, serializer.SerializeRecords(records))
+}
+
+var RecordListView = auth.RoleRequired([]string{"admin", "superadmin"}, recordListView)
+
+// Returns a DNS record of the domain given its id
+// @Summary Domain DNS record detail
+// @Description Retrieves one DNS record of the domain given its id
+// @Security BearerAuth
+// @Tags records
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Param recordId path string true "Record ID"
+// @Success 200 {object} RecordData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /domain/{id}/records/{recordId} [get]
+func recordDetailView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	recordService := new(RecordService)
+	record, err := recordService.GetById(domain, c.Param("recordId"))
+	if err != nil {
+		zap.S().Errorw("Error while getting record, Reason: ", "id", c.Param("recordId"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Record not found"})
+		return
+	}
+	serializer := NewDomainSerializer()
+	c.JSON(http.StatusOK, serializer.SerializeRecord(record))
+}
+
+var RecordDetailView = auth.RoleRequired([]string{"admin", "superadmin"}, recordDetailView)
+
+// Creates a DNS record of the domain
+// @Summary Create domain DNS record
+// @Description Creates a DNS record of the domain (A, AAAA, CNAME, MX, TXT, SRV or CAA) and increments the zone serial
+// @Security BearerAuth
+// @Tags records
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Param record body RecordValidatorData true "Record data"
+// @Success 201 {object} RecordData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /domain/{id}/records [post]
+func createRecordView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	recordValidator := NewRecordValidator()
+	if err := recordValidator.Bind(domain, c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	recordService := new(RecordService)
+	if _, err := recordService.Save(domain, &recordValidator.record); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Cannot create record: %v", err)})
+		return
+	}
+	serializer := NewDomainSerializer()
+	c.JSON(http.StatusCreated, serializer.SerializeRecord(&recordValidator.record))
+}
+
+var CreateRecordView = auth.RoleRequired([]string{"admin", "superadmin"}, createRecordView)
+
+// Updates a DNS record of the domain
+// @Summary Update domain DNS record
+// @Description Updates a DNS record of the domain and increments the zone serial
+// @Security BearerAuth
+// @Tags records
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Param recordId path string true "Record ID"
+// @Param record body RecordValidatorData true "Record data"
+// @Success 200 {object} RecordData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /domain/{id}/records/{recordId} [put]
+func updateRecordView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	recordService := new(RecordService)
+	record, err := recordService.GetById(domain, c.Param("recordId"))
+	if err != nil {
+		zap.S().Errorw("Error while getting record, Reason: ", "id", c.Param("recordId"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Record not found"})
+		return
+	}
+
+	recordValidator := NewRecordValidator()
+	if err := recordValidator.BindUpdate(domain, record, c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if _, err := recordService.Save(domain, &recordValidator.record); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Cannot update record: %v", err)})
+		return
+	}
+	serializer := NewDomainSerializer()
+	c.JSON(http.StatusOK, serializer.SerializeRecord(&recordValidator.record))
+}
+
+var UpdateRecordView = auth.RoleRequired([]string{"admin", "superadmin"}, updateRecordView)
+
+// Deletes a DNS record of the domain
+// @Summary Delete domain DNS record
+// @Description Deletes a DNS record of the domain and increments the zone serial
+// @Security BearerAuth
+// @Tags records
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Param recordId path string true "Record ID"
+// @Success 204
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /domain/{id}/records/{recordId} [delete]
+func deleteRecordView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	recordService := new(RecordService)
+	record, err := recordService.GetById(domain, c.Param("recordId"))
+	if err != nil {
+		zap.S().Errorw("Error while getting record, Reason: ", "id", c.Param("recordId"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Record not found"})
+		return
+	}
+
+	if _, err := recordService.Delete(domain, record); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot delete record: %v", err)})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+var DeleteRecordView = auth.RoleRequired([]string{"admin", "superadmin"}, deleteRecordView)
+
+// Lists the comments of the domain
+// @Summary Domain comments
+// @Description Retrieves the comments timeline of the domain, pinned comments first then from the oldest
+// @Security BearerAuth
+// @Tags comments
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Success 200 {array} CommentData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /domain/{id}/comments [get]
+func commentListView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	commentService := new(CommentService)
+	comments, err := commentService.all(domain)
+	if err != nil {
+		zap.S().Error("Error while getting domain comments, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: "Cannot fetch comments"})
+		return
+	}
+	serializer := NewDomainSerializer()
+	c.JSON(http.StatusOK, serializer.SerializeComments(comments))
+}
+
+var CommentListView = auth.RoleRequired([]string{"admin", "superadmin"}, commentListView)
+
+// Adds a comment to the domain
+// @Summary Create domain comment
+// @Description Adds a comment (markdown body) to the domain timeline, authored by the current user
+// @Security BearerAuth
+// @Tags comments
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Param comment body CommentValidatorData true "Comment data"
+// @Success 201 {object} CommentData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /domain/{id}/comments [post]
+func createCommentView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	commentValidator := NewCommentValidator()
+	if err := commentValidator.Bind(domain, c.MustGet("user").(*auth.User), c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	commentService := new(CommentService)
+	if _, err := commentService.Save(&commentValidator.comment); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Cannot create comment: %v", err)})
+		return
+	}
+	serializer := NewDomainSerializer()
+	c.JSON(http.StatusCreated, serializer.SerializeComment(&commentValidator.comment))
+}
+
+var CreateCommentView = auth.RoleRequired([]string{"admin", "superadmin"}, createCommentView)
+
+// Edits a comment of the domain
+// @Summary Update domain comment
+// @Description Edits the body of a comment, the previous body is kept in the comment history. Only the author can edit a comment
+// @Security BearerAuth
+// @Tags comments
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Param commentId path string true "Comment ID"
+// @Param comment body CommentValidatorData true "Comment data"
+// @Success 200 {object} CommentData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /domain/{id}/comments/{commentId} [put]
+func updateCommentView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	commentService := new(CommentService)
+	comment, err := commentService.GetById(domain, c.Param("commentId"))
+	if err != nil {
+		zap.S().Errorw("Error while getting comment, Reason: ", "id", c.Param("commentId"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Comment not found"})
+		return
+	}
+
+	if !comment.IsAuthor(c.MustGet("user").(*auth.User).ID) {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse{Message: "Only the author can edit a comment"})
+		return
+	}
+
+	commentValidator := NewCommentValidator()
+	if err := commentValidator.BindUpdate(comment, c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if _, err := commentService.Save(&commentValidator.comment); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Cannot update comment: %v", err)})
+		return
+	}
+	serializer := NewDomainSerializer()
+	c.JSON(http.StatusOK, serializer.SerializeComment(&commentValidator.comment))
+}
+
+var UpdateCommentView = auth.RoleRequired([]string{"admin", "superadmin"}, updateCommentView)
+
+// Deletes a comment of the domain
+// @Summary Delete domain comment
+// @Description Deletes a comment of the domain. Only the author can delete a comment
+// @Security BearerAuth
+// @Tags comments
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Param commentId path string true "Comment ID"
+// @Success 204
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /domain/{id}/comments/{commentId} [delete]
+func deleteCommentView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	commentService := new(CommentService)
+	comment, err := commentService.GetById(domain, c.Param("commentId"))
+	if err != nil {
+		zap.S().Errorw("Error while getting comment, Reason: ", "id", c.Param("commentId"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Comment not found"})
+		return
+	}
+
+	if !comment.IsAuthor(c.MustGet("user").(*auth.User).ID) {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse{Message: "Only the author can delete a comment"})
+		return
+	}
+
+	if _, err := commentService.Delete(comment); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot delete comment: %v", err)})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+var DeleteCommentView = auth.RoleRequired([]string{"admin", "superadmin"}, deleteCommentView)
+
+// Pins a comment of the domain
+// @Summary Pin domain comment
+// @Description Pins a comment at the top of the domain timeline
+// @Security BearerAuth
+// @Tags comments
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Param commentId path string true "Comment ID"
+// @Success 200 {object} CommentData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /domain/{id}/comments/{commentId}/pin [post]
+func pinCommentView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	commentService := new(CommentService)
+	comment, err := commentService.GetById(domain, c.Param("commentId"))
+	if err != nil {
+		zap.S().Errorw("Error while getting comment, Reason: ", "id", c.Param("commentId"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Comment not found"})
+		return
+	}
+
+	comment.Pinned = true
+	if _, err := commentService.Save(comment); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot pin comment: %v", err)})
+		return
+	}
+	serializer := NewDomainSerializer()
+	c.JSON(http.StatusOK, serializer.SerializeComment(comment))
+}
+
+var PinCommentView = auth.RoleRequired([]string{"admin", "superadmin"}, pinCommentView)
+
+// Unpins a comment of the domain
+// @Summary Unpin domain comment
+// @Description Unpins a comment of the domain timeline
+// @Security BearerAuth
+// @Tags comments
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Param commentId path string true "Comment ID"
+// @Success 200 {object} CommentData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /domain/{id}/comments/{commentId}/pin [delete]
+func unpinCommentView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	commentService := new(CommentService)
+	comment, err := commentService.GetById(domain, c.Param("commentId"))
+	if err != nil {
+		zap.S().Errorw("Error while getting comment, Reason: ", "id", c.Param("commentId"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Comment not found"})
+		return
+	}
+
+	comment.Pinned = false
+	if _, err := commentService.Save(comment); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot unpin comment: %v", err)})
+		return
+	}
+	serializer := NewDomainSerializer()
+	c.JSON(http.StatusOK, serializer.SerializeComment(comment))
+}
+
+var UnpinCommentView = auth.RoleRequired([]string{"admin", "superadmin"}, unpinCommentView)
+
+// Lists the attachments of the domain
+// @Summary Domain attachments
+// @Description Retrieves the files attached to the domain, sorted by upload date
+// @Security BearerAuth
+// @Tags attachments
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Success 200 {array} AttachmentData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /domain/{id}/attachments [get]
+func attachmentListView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	attachmentService := new(AttachmentService)
+	attachments, err := attachmentService.all(domain)
+	if err != nil {
+		zap.S().Error("Error while getting domain attachments, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: "Cannot fetch attachments"})
+		return
+	}
+	serializer := NewDomainSerializer()
+	c.JSON(http.StatusOK, serializer.SerializeAttachments(attachments))
+}
+
+var AttachmentListView = auth.RoleRequired([]string{"admin", "superadmin"}, attachmentListView)
+
+// Attaches a file to the domain
+// @Summary Upload domain attachment
+// @Description Uploads a file (multipart file field) attached to the domain. The file is streamed to the database, its type is detected from its content and must be one of the allowed types, its size is limited by the domains.attachments.maxSizeMB setting
+// @Security BearerAuth
+// @Tags attachments
+// @Accept  mpfd
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Param file formData file true "Attached file"
+// @Success 201 {object} AttachmentData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 413 {object} utils.ErrorResponse
+// @Failure 415 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /domain/{id}/attachments [post]
+func uploadAttachmentView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	// the multipart body is read part by part, so that the file is never buffered
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: "A multipart form is expected"})
+		return
+	}
+	var part *multipart.Part
+	for {
+		part, err = reader.NextPart()
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: "Missing file"})
+			return
+		}
+		if part.FormName() == "file" && part.FileName() != "" {
+			break
+		}
+	}
+	defer part.Close()
+
+	attachmentService := new(AttachmentService)
+	user := c.MustGet("user").(*auth.User)
+	attachment, err := attachmentService.Upload(domain, filepath.Base(part.FileName()), part, user.Email)
+	switch {
+	case errors.Is(err, ErrAttachmentTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, utils.ErrorResponse{Message: fmt.Sprintf("%v, the maximum size is %d MB", err, maxAttachmentSize()>>20)})
+		return
+	case errors.Is(err, ErrAttachmentType):
+		c.JSON(http.StatusUnsupportedMediaType, utils.ErrorResponse{Message: err.Error()})
+		return
+	case errors.Is(err, ErrAttachmentEmpty):
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot upload attachment: %v", err)})
+		return
+	}
+	serializer := NewDomainSerializer()
+	c.JSON(http.StatusCreated, serializer.SerializeAttachment(attachment))
+}
+
+var UploadAttachmentView = auth.RoleRequired([]string{"admin", "superadmin"}, uploadAttachmentView)
+
+// Downloads an attachment of the domain
+// @Summary Download domain attachment
+// @Description Streams the content of a file attached to the domain, its SHA-256 checksum is sent in the X-Checksum-Sha256 header
+// @Security BearerAuth
+// @Tags attachments
+// @Produce  octet-stream
+// @Param id path string true "Domain ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 200 {file} binary
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /domain/{id}/attachments/{attachmentId} [get]
+func downloadAttachmentView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	attachmentService := new(AttachmentService)
+	attachment, err := attachmentService.GetById(domain, c.Param("attachmentId"))
+	if err != nil {
+		zap.S().Errorw("Error while getting attachment, Reason: ", "id", c.Param("attachmentId"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Attachment not found"})
+		return
+	}
+
+	stream, err := attachmentService.Open(attachment)
+	if err != nil {
+		zap.S().Error("Error while opening attachment, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: "Cannot read attachment"})
+		return
+	}
+	defer stream.Close()
+
+	c.Header("Content-Type", attachment.Metadata.ContentType)
+	c.Header("Content-Length", strconv.FormatInt(attachment.Length, 10))
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	c.Header("X-Checksum-Sha256", attachment.Metadata.Sha256)
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, stream); err != nil {
+		zap.S().Error("Error while streaming attachment, Reason: ", err)
+	}
+}
+
+var DownloadAttachmentView = auth.RoleRequired([]string{"admin", "superadmin"}, downloadAttachmentView)
+
+// Deletes an attachment of the domain
+// @Summary Delete domain attachment
+// @Description Deletes a file attached to the domain
+// @Security BearerAuth
+// @Tags attachments
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 204
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /domain/{id}/attachments/{attachmentId} [delete]
+func deleteAttachmentView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	attachmentService := new(AttachmentService)
+	attachment, err := attachmentService.GetById(domain, c.Param("attachmentId"))
+	if err != nil {
+		zap.S().Errorw("Error while getting attachment, Reason: ", "id", c.Param("attachmentId"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Attachment not found"})
+		return
+	}
+
+	if _, err := attachmentService.Delete(attachment); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot delete attachment: %v", err)})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+var DeleteAttachmentView = auth.RoleRequired([]string{"admin", "superadmin"}, deleteAttachmentView)
+
+// Exports the DNS records of the domain as a BIND zone file
+// @Summary Export domain zone
+// @Description Exports the DNS records of the domain as a BIND zone file, including the SOA record (with the current serial) and the NS records
+// @Security BearerAuth
+// @Tags records
+// @Produce  plain
+// @Param id path string true "Domain ID"
+// @Success 200 {string} string "Zone file"
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /domain/{id}/zone [get]
+func exportZoneView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	recordService := new(RecordService)
+	records, err := recordService.all(domain)
+	if err != nil {
+		zap.S().Error("Error while getting domain records, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: "Cannot fetch records"})
+		return
+	}
+	c.Header("Content-Type", "text/dns; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", domain.Name+".zone"))
+	c.Status(http.StatusOK)
+	if err := FormatZone(c.Writer, domain, records); err != nil {
+		zap.S().Error("Error while writing domain zone, Reason: ", err)
+	}
+}
+
+var ExportZoneView = auth.RoleRequired([]string{"admin", "superadmin"}, exportZoneView)
+
+// Imports the DNS records of the domain from a BIND zone file
+// @Summary Import domain zone
+// @Description Imports the A, AAAA, CNAME, MX, TXT, SRV and CAA records of a BIND zone file sent as request body, other records are skipped and reported. The existing records are kept unless replace is set.
+// @Security BearerAuth
+// @Tags records
+// @Accept  plain
+// @Produce  json
+// @Param id path string true "Domain ID"
+// @Param replace query bool false "Replace the existing records"
+// @Success 200 {object} ZoneImportData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /domain/{id}/zone [post]
+func importZoneView(c *gin.Context) {
+	domainService := new(DomainService)
+	domain, err := domainService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting domain, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Domain not found"})
+		return
+	}
+
+	replace, _ := strconv.ParseBool(c.Query("replace"))
+	zone, err := ParseZone(http.MaxBytesReader(c.Writer, c.Request.Body, maxZoneSize), domain)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Invalid zone file: %v", err)})
+		return
+	}
+
+	recordService := new(RecordService)
+	if !replace {
+		existing, err := recordService.all(domain)
+		if err != nil {
+			zap.S().Error("Error while getting domain records, Reason: ", err)
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: "Cannot fetch records"})
+			return
+		}
+		// records already in the zone are not imported again
+		records, duplicates := newRecords(zone.Records, *existing)
+		for _, record := range duplicates {
+			zone.Skipped = append(zone.Skipped, fmt.Sprintf("%s %s record %s, already exists", record.Name, record.Type, record.Value))
+		}
+		zone.Records = records
+		for _, record := range zone.Records {
+			if err := cnameConflict(&record, *existing); err != nil {
+				c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+				return
+			}
+		}
+	}
+	if err := recordService.Import(domain, zone.Records, replace, zone.Serial); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot import records: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, ZoneImportData{Imported: len(zone.Records), Serial: domain.Zone.Serial, Skipped: zone.Skipped})
+}
+
+var ImportZoneView = auth.RoleRequired([]string{"admin", "superadmin"}, importZoneView)
+
+// Imports domains from a CSV file or a json array
+// @Summary Import domains
+// @Description Creates (or, with upsert, updates) domains from a CSV file (columns: name, ownerId, registrantId, loginInfo, packageId, mx, ip, addresses, serverId, registrar, registeredAt, expiresAt, autoRenew, renewalCost, tags, lifecycle, field.<name>) or a json array of domains, validating each row as the create domain request does. The file is sent as request body (text/csv or application/json) or as the multipart file field. Imports larger than the async threshold run in background, their report is the result of the returned job.
+// @Security BearerAuth
+// @Tags domains
+// @Accept  json
+// @Accept  plain
+// @Accept  mpfd
+// @Produce  json
+// @Param dryRun query bool false "Validate only, nothing is saved"
+// @Param upsert query bool false "Update the domains which already exist"
+// @Param format query string false "File format (csv or json), inferred from the content type when missing"
+// @Success 200 {object} ImportReportData
+// @Success 202 {object} jobs.JobData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /import/domain [post]
+func importDomainsView(c *gin.Context) {
+	dryRun, _ := strconv.ParseBool(c.Query("dryRun"))
+	upsert, _ := strconv.ParseBool(c.Query("upsert"))
+
+	// the multipart form is parsed from the request body, so the limit applies to uploaded files too
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	body := c.Request.Body
+	format := c.Query("format")
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err == http.ErrMissingFile {
+			c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: "Missing file"})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Cannot read file: %v", err)})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Cannot read file: %v", err)})
+			return
+		}
+		defer file.Close()
+		body = file
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+		}
+	}
+	if format == "" && strings.Contains(c.ContentType(), "json") {
+		format = "json"
+	} else if format == "" {
+		format = "csv"
+	}
+
+	var items []importItem
+	var err error
+	switch format {
+	case "json":
+		items, err = parseImportJSON(body)
+	case "csv":
+		items, err = parseImportCSV(body)
+	default:
+		err = fmt.Errorf("Unsupported format %s, expected csv or json", format)
+	}
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(*auth.User)
+	policy := fieldPolicyFor(user)
+	serializer := NewDomainSerializer()
+	if len(items) <= importAsyncThreshold() {
+		report := runImport(items, dryRun, upsert, policy, func(int) {})
+		c.JSON(http.StatusOK, serializer.SerializeImportReport(&report))
+		return
+	}
+
+	job, err := jobs.Start("domain.import", user.Email, len(items), func(progress func(int)) (interface{}, error) {
+		report := runImport(items, dryRun, upsert, policy, progress)
+		return serializer.SerializeImportReport(&report), nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot start import: %v", err)})
+		return
+	}
+	c.JSON(http.StatusAccepted, jobs.NewJobSerializer().Serialize(job))
+}
+
+var ImportDomainsView = auth.RoleRequired([]string{"admin", "superadmin"}, importDomainsView)
+
+// Applies the same changes to a selection of domains, admin or superadmin roles required
+// @Summary Bulk domains update
+// @Description Sets fields (owner, registrant, package, server, mx, registrar, auto renew, renewal cost), adds or removes tags, or moves to the trash the domains selected by ids and/or by the list filters of the query string, with a single bulk write. With dryRun nothing is changed and the report tells what would be. The report lists the outcome of each domain: updated, deleted, unchanged, not-found or failed
+// @Security BearerAuth
+// @Tags domains
+// @Accept  json
+// @Produce  json
+// @Param bulk body BulkValidatorData true "Selected ids and changes"
+// @Param ip query string false "Domains having this ip address"
+// @Param cidr query string false "Domains having an ip address in this network (CIDR notation)"
+// @Param server query string false "Domains hosted on this server (server ID)"
+// @Param owner query string false "Domains owned by this contact (contact ID)"
+// @Param registrant query string false "Domains registered by this contact (contact ID)"
+// @Param contact query string false "Domains owned or registered by this contact (contact ID)"
+// @Param expiringWithin query int false "Domains whose registration expires within this number of days"
+// @Param dnsDrift query bool false "Domains whose DNS records differ from the stored configuration at the last check"
+// @Param lifecycle query string false "Domains in this lifecycle status"
+// @Param tag query string false "Domains having all these tags (comma separated)"
+// @Success 200 {object} BulkReportData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /bulk/domain [post]
+func bulkDomainsView(c *gin.Context) {
+	bulkValidator := NewBulkValidatorFor(c.MustGet("user").(*auth.User))
+	if err := bulkValidator.Bind(c); err != nil {
+		c.JSON(validationStatus(err), utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	report, err := RunBulk(&bulkValidator.operation, bulkValidator.BulkData.DryRun)
+	if err != nil {
+		var tooLarge *BulkTooLargeError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+			return
+		}
+		zap.S().Error("Error while applying bulk operation, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: fmt.Sprintf("Cannot apply bulk operation: %v", err)})
+		return
+	}
+	serializer := NewDomainSerializer()
+	c.JSON(http.StatusOK, serializer.SerializeBulkReport(report))
+}
+
+var BulkDomainsView = auth.RoleRequired([]string{"admin", "superadmin"}, bulkDomainsView)
+
+// Exports the domains as CSV, XLSX or NDJSON, optionally filtered as the domains list
+// @Summary Export domains
+// @Description Streams the domains matching the list filters (custom fields included) as a CSV, XLSX or NDJSON file. Tags and custom fields are exported as the tags and field.<name> columns. The login info column is exported only with the privileged flag, which requires the superadmin role.
+// @Security BearerAuth
+// @Tags domains
+// @Produce  plain
+// @Param format query string false "Export format: csv (default), xlsx or ndjson"
+// @Param columns query string false "Comma separated list of the exported columns, all by default"
+// @Param privileged query bool false "Export the privileged columns (login info), superadmin role required"
+// @Param ip query string false "Domains having this ip address"
+// @Param cidr query string false "Domains having an ip address in this network (CIDR notation)"
+// @Param server query string false "Domains hosted on this server (server ID)"
+// @Param owner query string false "Domains owned by this contact (contact ID)"
+// @Param registrant query string false "Domains registered by this contact (contact ID)"
+// @Param contact query string false "Domains owned or registered by this contact (contact ID)"
+// @Param expiringWithin query int false "Domains whose registration expires within this number of days"
+// @Param dnsDrift query bool false "Domains whose DNS records differ from the stored configuration at the last check"
+// @Param lifecycle query string false "Domains in this lifecycle status"
+// @Param tag query string false "Domains having all these tags (comma separated)"
+// @Success 200 {file} file
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /export/domain [get]
+func exportDomainsView(c *gin.Context) {
+	filter := NewDomainFilter()
+	if err := filter.Bind(c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	format := c.DefaultQuery("format", export.CSV)
+	if !utils.Contains(export.Formats, format) {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Unsupported format %s", format)})
+		return
+	}
+	privileged, _ := strconv.ParseBool(c.Query("privileged"))
+	if privileged && c.MustGet("user").(*auth.User).Role != "superadmin" {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse{Message: "The privileged export requires the superadmin role"})
+		return
+	}
+	available, err := domainExportColumns(fieldPolicyFor(c.MustGet("user").(*auth.User)))
+	if err != nil {
+		zap.S().Error("Error while getting custom fields, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: "Cannot fetch custom fields"})
+		return
+	}
+	columns, err := export.SelectColumns(available, c.Query("columns"), privileged)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("domains-%s.%s", time.Now().Format("20060102"), format)))
+	c.Status(http.StatusOK)
+	writer, err := export.NewWriter(format, c.Writer)
+	if err == nil {
+		err = writer.Header(columns)
+	}
+	if err != nil {
+		zap.S().Error("Error while exporting domains, Reason: ", err)
+		return
+	}
+
+	names := newExportNames()
+	domainService := new(DomainService)
+	err = domainService.each(&filter, func(domain *Domain) error {
+		values := make([]interface{}, len(columns))
+		for i, column := range columns {
+			values[i] = exportValue(domain, column, names)
+		}
+		return writer.Row(values)
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		// the response is already being streamed, the status cannot be changed anymore
+		zap.S().Error("Error while exporting domains, Reason: ", err)
+	}
+}
+
+var ExportDomainsView = auth.RoleRequired([]string{"admin", "superadmin"}, exportDomainsView)
+
+// billingExportColumns the columns of the billing summary export
+var billingExportColumns = []string{"owner", "domain", "kind", "description", "period", "currency", "charges", "unitCost", "unitPrice", "cost", "price", "margin"}
+
+// Exports the billing summary (projected invoice lines) as CSV, XLSX or NDJSON
+// @Summary Export billing summary
+// @Description Streams the lines of the projected invoices of the billing report, one row per domain line item, as a CSV, XLSX or NDJSON file
+// @Security BearerAuth
+// @Tags domains
+// @Produce  plain
+// @Param format query string false "Export format: csv (default), xlsx or ndjson"
+// @Param from query string false "First day of the period (YYYY-MM-DD)"
+// @Param to query string false "Day following the period (YYYY-MM-DD)"
+// @Param ip query string false "Domains having this ip address"
+// @Param cidr query string false "Domains having an ip address in this network (CIDR notation)"
+// @Param server query string false "Domains hosted on this server (server ID)"
+// @Param owner query string false "Domains owned by this contact (contact ID)"
+// @Param registrant query string false "Domains registered by this contact (contact ID)"
+// @Param contact query string false "Domains owned or registered by this contact (contact ID)"
+// @Param expiringWithin query int false "Domains whose registration expires within this number of days"
+// @Param dnsDrift query bool false "Domains whose DNS records differ from the stored configuration at the last check"
+// @Param lifecycle query string false "Domains in this lifecycle status"
+// @Param tag query string false "Domains having all these tags (comma separated)"
+// @Success 200 {file} file
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /export/billing [get]
+func exportBillingView(c *gin.Context) {
+	from, to, err := parseBillingPeriod(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+	filter := NewDomainFilter()
+	if err := filter.Bind(c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+	format := c.DefaultQuery("format", export.CSV)
+	if !utils.Contains(export.Formats, format) {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Unsupported format %s", format)})
+		return
+	}
+
+	domainService := new(DomainService)
+	domains, err := domainService.list(&filter)
+	if err != nil {
+		zap.S().Error("Error while getting all domains, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: "Cannot fetch domains"})
+		return
+	}
+	invoices, err := projectInvoices(domains, from, to)
+	if err != nil {
+		zap.S().Error("Error while projecting invoices, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: "Cannot project invoices"})
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("billing-%s-%s.%s", from.Format("20060102"), to.Format("20060102"), format)))
+	c.Status(http.StatusOK)
+	writer, err := export.NewWriter(format, c.Writer)
+	if err == nil {
+		err = writer.Header(billingExportColumns)
+	}
+	for _, invoice := range NewDomainSerializer().SerializeInvoices(invoices) {
+		for _, line := range invoice.Lines {
+			if err != nil {
+				break
+			}
+			err = writer.Row([]interface{}{
+				invoice.Owner, line.Domain, line.Kind, line.Description, line.Period, line.Currency,
+				line.Charges, line.UnitCost, line.UnitPrice, line.Cost, line.Price, line.Margin,
+			})
+		}
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		// the response is already being streamed, the status cannot be changed anymore
+		zap.S().Error("Error while exporting billing summary, Reason: ", err)
+	}
+}
+
+var ExportBillingView = auth.RoleRequired([]string{"admin", "superadmin"}, exportBillingView)
+
+// Returns the calendar feed of the domains expirations, renewal deadlines and certificate expirations
+// @Summary Domains calendar feed
+// @Description Returns an iCalendar feed of the upcoming domain expiry dates, renewal deadlines and certificate expirations, to be subscribed to in calendar clients. The feed is authenticated by the calendar token of the user (POST /auth/calendar/token) and lists the domains the user can see, cancelled domains excluded
+// @Tags domains
+// @Produce  plain
+// @Param token query string true "Calendar token of the user"
+// @Param events query string false "Comma separated event kinds: expiry, renewal, certificate (all by default)"
+// @Param days query int false "Number of days covered by the feed, starting today"
+// @Param owner query string false "Domains owned by this contact (contact ID)"
+// @Param tag query string false "Domains having all these tags (comma separated)"
+// @Param lifecycle query string false "Domains in this lifecycle status"
+// @Success 200 {file} file
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /calendar/domain.ics [get]
+func CalendarFeedView(c *gin.Context) {
+	userService := new(auth.UserService)
+	user, err := userService.GetByCalendarToken(c.Query("token"))
+	if err != nil || !utils.Contains([]string{"admin", "superadmin", "user"}, user.Role) {
+		zap.S().Debug("CalendarFeedView: access with invalid calendar token")
+		c.JSON(http.StatusForbidden, utils.ErrorResponse{Message: "You don't have the rights to see the requested content"})
+		return
+	}
+
+	filter := NewDomainFilter()
+	if err := filter.Bind(c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+	kinds := CalendarEvents
+	if c.Query("events") != "" {
+		kinds = strings.Split(c.Query("events"), ",")
+		for _, kind := range kinds {
+			if !utils.Contains(CalendarEvents, kind) {
+				c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Unknown event %s, expected one of %s", kind, strings.Join(CalendarEvents, ", "))})
+				return
+			}
+		}
+	}
+	days := calendarDays()
+	if c.Query("days") != "" {
+		if days, err = strconv.Atoi(c.Query("days")); err != nil || days < 1 || days > 1830 {
+			c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: "The days must be a number between 1 and 1830"})
+			return
+		}
+	}
+
+	domainService := NewDomainService(user)
+	domains, err := domainService.list(&filter)
+	if err != nil {
+		zap.S().Error("Error while getting all domains, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: "Cannot fetch domains"})
+		return
+	}
+	from := calendarDate(time.Now().Unix())
+	calendar := ical.Calendar{
+		Name:   "Domains",
+		Events: calendarEvents(domains, kinds, fieldPolicyFor(user), from, from.AddDate(0, 0, days)),
+	}
+
+	c.Header("Content-Type", ical.ContentType)
+	c.Header("Content-Disposition", `inline; filename="domains.ics"`)
+	c.Status(http.StatusOK)
+	if err := calendar.Write(c.Writer); err != nil {
+		// the response is already being streamed, the status cannot be changed anymore
+		zap.S().Error("Error while writing the calendar feed, Reason: ", err)
+	}
+}
@@ -0,0 +1,423 @@
+package domains
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/spf13/viper"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxZoneSize the maximum size of an imported zone file
+const maxZoneSize = 1 << 20
+
+// ZoneImport the records parsed from a BIND zone file
+// SOA and NS records are not managed, they are skipped and reported along with the unsupported records
+type ZoneImport struct {
+	Records []Record
+	Serial  uint32
+	Skipped []string
+}
+
+// zoneLine a logical line of a zone file, entries split on several lines by parentheses are joined
+type zoneLine struct {
+	number    int
+	continued bool // starts with a blank, i.e. the owner is the previous one
+	tokens    []zoneToken
+}
+
+// zoneToken a field of a zone file entry, quoted strings are kept together and unescaped
+type zoneToken struct {
+	text string
+}
+
+// splitZone splits a zone file in logical lines, removing comments
+func splitZone(r io.Reader) ([]zoneLine, error) {
+	lines := []zoneLine{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var current *zoneLine
+	depth := 0
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := scanner.Text()
+		if current == nil {
+			current = &zoneLine{number: number, continued: len(text) > 0 && (text[0] == ' ' || text[0] == '\t')}
+		}
+
+		var token strings.Builder
+		inToken, quoted := false, false
+		flush := func() {
+			if inToken {
+				current.tokens = append(current.tokens, zoneToken{text: token.String()})
+			}
+			token.Reset()
+			inToken, quoted = false, false
+		}
+		for i := 0; i < len(text); i++ {
+			c := text[i]
+			switch {
+			case quoted && c == '\\' && i+3 < len(text) && isDigits(text[i+1:i+4]):
+				code, _ := strconv.Atoi(text[i+1 : i+4])
+				token.WriteByte(byte(code))
+				i += 3
+			case quoted && c == '\\' && i+1 < len(text):
+				token.WriteByte(text[i+1])
+				i++
+			case quoted && c == '"':
+				current.tokens = append(current.tokens, zoneToken{text: token.String()})
+				token.Reset()
+				inToken, quoted = false, false
+			case quoted:
+				token.WriteByte(c)
+			case c == '"':
+				flush()
+				inToken, quoted = true, true
+			case c == ';':
+				i = len(text)
+			case c == '(':
+				flush()
+				depth++
+			case c == ')':
+				flush()
+				if depth == 0 {
+					return nil, fmt.Errorf("line %d: unbalanced parentheses", number)
+				}
+				depth--
+			case c == ' ' || c == '\t':
+				flush()
+			default:
+				inToken = true
+				token.WriteByte(c)
+			}
+		}
+		if quoted {
+			return nil, fmt.Errorf("line %d: unterminated quoted string", number)
+		}
+		flush()
+
+		if depth == 0 {
+			if len(current.tokens) > 0 {
+				lines = append(lines, *current)
+			}
+			current = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("line %d: unbalanced parentheses", number)
+	}
+	return lines, nil
+}
+
+// isDigits reports whether the string contains decimal digits only
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// parseTtl parses a TTL in seconds, or using the BIND units (i.e. 1h30m, 1d, 2w)
+func parseTtl(value string) (uint32, bool) {
+	if isDigits(value) {
+		ttl, err := strconv.ParseUint(value, 10, 32)
+		return uint32(ttl), err == nil
+	}
+	units := map[byte]uint64{'s': 1, 'm': 60, 'h': 3600, 'd': 86400, 'w': 604800}
+	total, number := uint64(0), ""
+	for i := 0; i < len(value); i++ {
+		c := value[i] | 0x20
+		if c >= '0' && c <= '9' {
+			number += string(c)
+			continue
+		}
+		unit, ok := units[c]
+		if !ok || number == "" {
+			return 0, false
+		}
+		n, _ := strconv.ParseUint(number, 10, 32)
+		total += n * unit
+		number = ""
+	}
+	if number != "" || total > 1<<31 {
+		return 0, false
+	}
+	return uint32(total), true
+}
+
+// absoluteName returns the fully qualified name (with trailing dot) of a name relative to the origin
+func absoluteName(name string, origin string) string {
+	if name == "@" {
+		return origin
+	}
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "." + origin
+}
+
+// ParseZone parses a BIND zone file of the domain
+// The zone origin defaults to the domain name and $ORIGIN and $TTL directives are supported
+func ParseZone(r io.Reader, domain *Domain) (*ZoneImport, error) {
+	lines, err := splitZone(r)
+	if err != nil {
+		return nil, err
+	}
+
+	res := ZoneImport{Records: []Record{}, Skipped: []string{}}
+	origin := domain.Name + "."
+	defaultTtl := uint32(0)
+	owner := ""
+	for _, line := range lines {
+		fields := line.tokens
+		fail := func(format string, args ...interface{}) error {
+			return fmt.Errorf("line %d: %s", line.number, fmt.Sprintf(format, args...))
+		}
+
+		switch strings.ToUpper(fields[0].text) {
+		case "$ORIGIN":
+			if len(fields) != 2 {
+				return nil, fail("invalid $ORIGIN directive")
+			}
+			origin = absoluteName(strings.ToLower(fields[1].text), origin)
+			continue
+		case "$TTL":
+			ttl, ok := uint32(0), len(fields) == 2
+			if ok {
+				ttl, ok = parseTtl(fields[1].text)
+			}
+			if !ok {
+				return nil, fail("invalid $TTL directive")
+			}
+			defaultTtl = ttl
+			continue
+		case "$INCLUDE", "$GENERATE":
+			return nil, fail("%s directive is not supported", fields[0].text)
+		}
+
+		if !line.continued {
+			owner = absoluteName(strings.ToLower(fields[0].text), origin)
+			fields = fields[1:]
+		} else if owner == "" {
+			return nil, fail("missing record name")
+		}
+
+		// TTL and class are optional, in any order
+		ttl := defaultTtl
+		for len(fields) > 0 {
+			if value, ok := parseTtl(fields[0].text); ok {
+				ttl = value
+			} else if class := strings.ToUpper(fields[0].text); class == "CH" || class == "HS" {
+				return nil, fail("class %s is not supported", fields[0].text)
+			} else if class != "IN" {
+				break
+			}
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			return nil, fail("missing record type")
+		}
+		recordType := strings.ToUpper(fields[0].text)
+		data := fields[1:]
+
+		switch recordType {
+		case "SOA":
+			if len(data) != 7 {
+				return nil, fail("invalid SOA record")
+			}
+			serial, err := strconv.ParseUint(data[2].text, 10, 32)
+			if err != nil {
+				return nil, fail("invalid SOA serial %s", data[2].text)
+			}
+			res.Serial = uint32(serial)
+			res.Skipped = append(res.Skipped, fmt.Sprintf("line %d: SOA record, managed by the zone export", line.number))
+			continue
+		case "NS":
+			res.Skipped = append(res.Skipped, fmt.Sprintf("line %d: NS record, nameservers are managed by the registry data", line.number))
+			continue
+		}
+
+		record := Record{DomainId: domain.ID, Name: owner, Type: recordType, Ttl: ttl}
+		switch recordType {
+		case "A", "AAAA", "CNAME":
+			if len(data) != 1 {
+				return nil, fail("invalid %s record", recordType)
+			}
+			record.Value = data[0].text
+			if recordType == "CNAME" {
+				record.Value = absoluteName(strings.ToLower(data[0].text), origin)
+			}
+		case "MX":
+			if len(data) != 2 {
+				return nil, fail("invalid MX record")
+			}
+			priority, err := strconv.ParseUint(data[0].text, 10, 16)
+			if err != nil {
+				return nil, fail("invalid MX priority %s", data[0].text)
+			}
+			record.Priority = uint16(priority)
+			record.Value = absoluteName(strings.ToLower(data[1].text), origin)
+		case "TXT":
+			if len(data) == 0 {
+				return nil, fail("invalid TXT record")
+			}
+			var text strings.Builder
+			for _, field := range data {
+				text.WriteString(field.text)
+			}
+			record.Value = text.String()
+		case "SRV":
+			if len(data) != 4 {
+				return nil, fail("invalid SRV record")
+			}
+			values := [3]uint64{}
+			for i := range values {
+				if values[i], err = strconv.ParseUint(data[i].text, 10, 16); err != nil {
+					return nil, fail("invalid SRV record value %s", data[i].text)
+				}
+			}
+			record.Priority, record.Weight, record.Port = uint16(values[0]), uint16(values[1]), uint16(values[2])
+			record.Value = absoluteName(strings.ToLower(data[3].text), origin)
+		case "CAA":
+			if len(data) != 3 {
+				return nil, fail("invalid CAA record")
+			}
+			flags, err := strconv.ParseUint(data[0].text, 10, 8)
+			if err != nil {
+				return nil, fail("invalid CAA flags %s", data[0].text)
+			}
+			record.Flags = uint8(flags)
+			record.Tag = data[1].text
+			record.Value = data[2].text
+		default:
+			res.Skipped = append(res.Skipped, fmt.Sprintf("line %d: %s record, type not supported", line.number, recordType))
+			continue
+		}
+
+		if err := record.Normalize(domain.Name); err != nil {
+			return nil, fail("%v", err)
+		}
+		if err := cnameConflict(&record, res.Records); err != nil {
+			return nil, fail("%v", err)
+		}
+		res.Records = append(res.Records, record)
+	}
+	return &res, nil
+}
+
+// zoneNameservers returns the nameservers published in the zone, the ones known by the registry or the configured ones
+func zoneNameservers(domain *Domain) []string {
+	if len(domain.Registry.Nameservers) > 0 {
+		return domain.Registry.Nameservers
+	}
+	return viper.GetStringSlice("dns.zone.nameservers")
+}
+
+// zoneSetting returns a SOA timer from the settings, or its default value
+func zoneSetting(key string, value int) int {
+	if setting := viper.GetInt("dns.zone." + key); setting > 0 {
+		return setting
+	}
+	return value
+}
+
+// quoteZone returns the value as a quoted zone file string, quotes and backslashes are escaped
+// and the bytes which are not printable ASCII are written as \DDD
+func quoteZone(value string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case c == '\\' || c == '"':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c > 0x7e:
+			fmt.Fprintf(&b, `\%03d`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// quoteTxt returns the TXT record value as quoted strings of at most 255 characters
+func quoteTxt(value string) string {
+	chunks := []string{}
+	for len(value) > 0 {
+		n := len(value)
+		if n > 255 {
+			n = 255
+		}
+		chunks = append(chunks, quoteZone(value[:n]))
+		value = value[n:]
+	}
+	return strings.Join(chunks, " ")
+}
+
+// fqdn returns the name with the trailing dot, as written in zone files
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// FormatZone writes the domain records as a BIND zone file, including SOA and NS records
+func FormatZone(w io.Writer, domain *Domain, records *[]Record) error {
+	nameservers := zoneNameservers(domain)
+	primary := viper.GetString("dns.zone.primary")
+	if primary == "" && len(nameservers) > 0 {
+		primary = nameservers[0]
+	}
+	if primary == "" {
+		primary = "ns1." + domain.Name
+	}
+	hostmaster := viper.GetString("dns.zone.hostmaster")
+	if hostmaster == "" {
+		hostmaster = "hostmaster." + domain.Name
+	}
+	serial := domain.Zone.Serial
+	if serial == 0 {
+		serial = nextSerial(0, time.Now())
+	}
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "; zone %s, exported on %s\n", domain.Name, time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(b, "$ORIGIN %s.\n", domain.Name)
+	fmt.Fprintf(b, "$TTL %d\n", defaultRecordTtl())
+	fmt.Fprintf(b, "@\tIN\tSOA\t%s %s (\n", fqdn(primary), fqdn(hostmaster))
+	fmt.Fprintf(b, "\t\t%d ; serial\n", serial)
+	fmt.Fprintf(b, "\t\t%d ; refresh\n", zoneSetting("refresh", 3600))
+	fmt.Fprintf(b, "\t\t%d ; retry\n", zoneSetting("retry", 900))
+	fmt.Fprintf(b, "\t\t%d ; expire\n", zoneSetting("expire", 1209600))
+	fmt.Fprintf(b, "\t\t%d ; minimum\n", zoneSetting("minimum", 300))
+	fmt.Fprintf(b, "\t)\n")
+	for _, nameserver := range nameservers {
+		fmt.Fprintf(b, "@\tIN\tNS\t%s\n", fqdn(strings.ToLower(nameserver)))
+	}
+
+	for _, record := range *records {
+		var data string
+		switch record.Type {
+		case "CNAME":
+			data = fqdn(record.Value)
+		case "MX":
+			data = fmt.Sprintf("%d %s", record.Priority, fqdn(record.Value))
+		case "TXT":
+			data = quoteTxt(record.Value)
+		case "SRV":
+			data = fmt.Sprintf("%d %d %d %s", record.Priority, record.Weight, record.Port, fqdn(record.Value))
+		case "CAA":
+			data = fmt.Sprintf("%d %s %s", record.Flags, record.Tag, quoteZone(record.Value))
+		default:
+			data = record.Value
+		}
+		fmt.Fprintf(b, "%s\t%d\tIN\t%s\t%s\n", record.Name, record.Ttl, record.Type, data)
+	}
+	return b.Flush()
+}
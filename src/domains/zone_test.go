@@ -0,0 +1,163 @@
+package domains
+
+import (
+	"bytes"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"testing"
+)
+
+// zoneRecord the fields of a record compared by the zone tests
+type zoneRecord struct {
+	Name     string
+	Type     string
+	Ttl      uint32
+	Value    string
+	Priority uint16
+	Flags    uint8
+	Tag      string
+}
+
+func zoneRecords(records []Record) []zoneRecord {
+	res := []zoneRecord{}
+	for _, record := range records {
+		res = append(res, zoneRecord{record.Name, record.Type, record.Ttl, record.Value, record.Priority, record.Flags, record.Tag})
+	}
+	return res
+}
+
+func TestParseZone(t *testing.T) {
+	tests := []struct {
+		name    string
+		zone    string
+		records []zoneRecord
+		serial  uint32
+		skipped int
+		err     string
+	}{
+		{
+			name: "parentheses continuation",
+			zone: "@ IN SOA ns1.example.com. hostmaster.example.com. (\n" +
+				"\t2024010101 ; serial\n\t3600 900 1209600 300 )\n" +
+				"txt IN TXT ( \"first\" ; comment\n  \"second\" )\n",
+			records: []zoneRecord{{"txt", "TXT", 3600, "firstsecond", 0, 0, ""}},
+			serial:  2024010101,
+			skipped: 1,
+		},
+		{
+			name:    "quoted TXT with escapes and multiple strings",
+			zone:    "@ 300 TXT \"v=spf1\\032-all\" \"quote\\\"d\" \"back\\\\slash\"\n",
+			records: []zoneRecord{{"@", "TXT", 300, `v=spf1 -allquote"dback\slash`, 0, 0, ""}},
+		},
+		{
+			name: "origin and default ttl",
+			zone: "$TTL 1h30m\nwww A 192.0.2.1\n$ORIGIN sub.example.com.\nhost A 192.0.2.2\n@ MX 10 mail\n",
+			records: []zoneRecord{
+				{"www", "A", 5400, "192.0.2.1", 0, 0, ""},
+				{"host.sub", "A", 5400, "192.0.2.2", 0, 0, ""},
+				{"sub", "MX", 5400, "mail.sub.example.com", 10, 0, ""},
+			},
+		},
+		{
+			name: "owner inheritance",
+			zone: "mail 300 A 192.0.2.25\n\tAAAA 2001:db8::25\n  MX 10 mail.example.com.\n",
+			records: []zoneRecord{
+				{"mail", "A", 300, "192.0.2.25", 0, 0, ""},
+				{"mail", "AAAA", 3600, "2001:db8::25", 0, 0, ""},
+				{"mail", "MX", 3600, "mail.example.com", 10, 0, ""},
+			},
+		},
+		{
+			name: "ttl and class in any order",
+			zone: "a 300 IN A 192.0.2.1\nb IN 600 A 192.0.2.2\nc in a 192.0.2.3\n",
+			records: []zoneRecord{
+				{"a", "A", 300, "192.0.2.1", 0, 0, ""},
+				{"b", "A", 600, "192.0.2.2", 0, 0, ""},
+				{"c", "A", 3600, "192.0.2.3", 0, 0, ""},
+			},
+		},
+		{
+			name:    "skipped records",
+			zone:    "@ NS ns1.example.com.\n@ HINFO cpu os\n",
+			records: []zoneRecord{},
+			skipped: 2,
+		},
+		{name: "cname conflict", zone: "www CNAME example.com.\nwww A 192.0.2.1\n", err: "line 2: A CNAME record cannot coexist"},
+		{name: "cname conflict on inherited owner", zone: "www A 192.0.2.1\n CNAME example.com.\n", err: "line 2: A CNAME record cannot coexist"},
+		{name: "unbalanced parentheses", zone: "txt TXT ( \"a\"\n", err: "unbalanced parentheses"},
+		{name: "unterminated string", zone: "txt TXT \"a\n", err: "unterminated quoted string"},
+		{name: "record outside of the zone", zone: "www.other.com. A 192.0.2.1\n", err: "outside of the zone"},
+		{name: "unsupported class", zone: "www CH A 192.0.2.1\n", err: "class CH is not supported"},
+	}
+
+	domain := Domain{ID: primitive.NewObjectID(), Name: "example.com"}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			zone, err := ParseZone(strings.NewReader(test.zone), &domain)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected error %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			records := zoneRecords(zone.Records)
+			if len(records) != len(test.records) {
+				t.Fatalf("expected %v, got %v", test.records, records)
+			}
+			for i := range records {
+				if records[i] != test.records[i] {
+					t.Fatalf("expected %v, got %v", test.records[i], records[i])
+				}
+			}
+			if zone.Serial != test.serial || len(zone.Skipped) != test.skipped {
+				t.Fatalf("unexpected serial %d or skipped %v", zone.Serial, zone.Skipped)
+			}
+		})
+	}
+}
+
+func TestZoneRoundTrip(t *testing.T) {
+	domain := Domain{ID: primitive.NewObjectID(), Name: "example.com", Zone: ZoneInfo{Serial: 2024010105}}
+	records := []Record{
+		{Name: "@", Type: "A", Ttl: 300, Value: "192.0.2.1"},
+		{Name: "www", Type: "CNAME", Ttl: 3600, Value: "example.com"},
+		{Name: "@", Type: "MX", Ttl: 3600, Value: "mail.example.com", Priority: 10},
+		{Name: "@", Type: "TXT", Ttl: 3600, Value: `v=spf1 include:"spf.example.net" \ -all`},
+		{Name: "long", Type: "TXT", Ttl: 3600, Value: strings.Repeat("0123456789", 30)},
+		{Name: "_sip._tcp", Type: "SRV", Ttl: 3600, Value: "sip.example.com", Priority: 10, Weight: 5, Port: 5060},
+		{Name: "@", Type: "CAA", Ttl: 3600, Value: "ca.example; account=é\t1", Flags: 128, Tag: "issue"},
+	}
+	for i := range records {
+		records[i].DomainId = domain.ID
+	}
+
+	var buf bytes.Buffer
+	if err := FormatZone(&buf, &domain, &records); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), `\u`) || strings.Contains(buf.String(), `\x`) || strings.Contains(buf.String(), `\t`) {
+		t.Fatalf("the zone contains escapes which are not zone file syntax:\n%s", buf.String())
+	}
+	zone, err := ParseZone(&buf, &domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zone.Serial != domain.Zone.Serial {
+		t.Fatalf("expected serial %d, got %d", domain.Zone.Serial, zone.Serial)
+	}
+	expected, parsed := zoneRecords(records), zoneRecords(zone.Records)
+	if len(parsed) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, parsed)
+	}
+	for i := range expected {
+		if parsed[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected[i], parsed[i])
+		}
+	}
+	if zone.Records[5].Weight != 5 || zone.Records[5].Port != 5060 {
+		t.Fatalf("unexpected SRV record %+v", zone.Records[5])
+	}
+}
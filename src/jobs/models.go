@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive" // for BSON ObjectID
+	"time"
+)
+
+// Job statuses
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// Job a long running task (i.e. a bulk import) run in background, whose progress and result can be polled
+// The result is stored json encoded, as produced by the task
+type Job struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Kind      string             `json:"kind"`
+	Status    string             `json:"status"`
+	Total     int                `json:"total"`
+	Processed int                `json:"processed"`
+	Result    string             `json:"result"`
+	Error     string             `json:"error"`
+	CreatedBy string             `json:"createdBy"`
+	Created   int64              `json:"created"`
+	Updated   int64              `json:"updated"`
+	ExpireAt  time.Time          `json:"-"`
+}
+
+func (self *Job) Save() (bool, error) {
+	jobService := new(JobService) // @TODO factory method
+	result, err := jobService.Save(self)
+	return result, err
+}
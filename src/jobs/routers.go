@@ -0,0 +1,10 @@
+package jobs
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RoutesRegister attaches routes (path + view) to the given gin router group (paths namespace)
+func RoutesRegister(router *gin.RouterGroup) {
+	router.GET("/:id", JobDetailView)
+}
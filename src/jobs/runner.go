@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+)
+
+// progressInterval the minimum interval between two progress updates stored in database
+const progressInterval = time.Second
+
+// Task the work done by a job, it reports the number of processed items through the progress function
+// and returns the job result, which is stored json encoded
+type Task func(progress func(processed int)) (interface{}, error)
+
+// Start creates a job of the given kind and runs the task in background
+// Returns the created job, whose status can then be polled
+func Start(kind string, createdBy string, total int, task Task) (*Job, error) {
+	now := time.Now()
+	job := Job{
+		Kind:      kind,
+		Status:    StatusPending,
+		Total:     total,
+		CreatedBy: createdBy,
+		Created:   now.Unix(),
+		ExpireAt:  now.Add(retention()),
+	}
+	if _, err := job.Save(); err != nil {
+		return nil, err
+	}
+
+	started := job
+	go run(&started, task)
+	return &job, nil
+}
+
+// run runs the task updating the job status, progress and result
+func run(job *Job, task Task) {
+	jobService := new(JobService)
+	job.Status = StatusRunning
+	job.Save()
+
+	lastUpdate := time.Now()
+	progress := func(processed int) {
+		if processed < job.Total && time.Since(lastUpdate) < progressInterval {
+			job.Processed = processed
+			return
+		}
+		lastUpdate = time.Now()
+		jobService.SetProgress(job, processed)
+	}
+
+	result, err := safeRun(task, progress)
+	if err == nil {
+		var encoded []byte
+		if encoded, err = json.Marshal(result); err == nil {
+			job.Result = string(encoded)
+		}
+	}
+	if err != nil {
+		zap.S().Errorw("Job failed", "id", job.ID.Hex(), "kind", job.Kind, "error", err)
+		job.Status = StatusFailed
+		job.Error = err.Error()
+	} else {
+		zap.S().Infow("Job completed", "id", job.ID.Hex(), "kind", job.Kind)
+		job.Status = StatusDone
+		job.Processed = job.Total
+	}
+	job.Save()
+}
+
+// safeRun runs the task turning a panic into an error, so that the job does not stay running forever
+func safeRun(task Task, progress func(processed int)) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return task(progress)
+}
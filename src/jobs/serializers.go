@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"encoding/json"
+)
+
+type jobSerializer struct{}
+
+type JobData struct {
+	ID        string          `json:"id"`
+	Kind      string          `json:"kind"`
+	Status    string          `json:"status"`
+	Total     int             `json:"total"`
+	Processed int             `json:"processed"`
+	Result    json.RawMessage `json:"result,omitempty" swaggertype:"object"`
+	Error     string          `json:"error,omitempty"`
+	CreatedBy string          `json:"createdBy"`
+	Created   int64           `json:"created"`
+	Updated   int64           `json:"updated"`
+}
+
+func NewJobSerializer() *jobSerializer {
+	return &jobSerializer{}
+}
+
+func (self *jobSerializer) Serialize(job *Job) JobData {
+	jobData := JobData{
+		ID:        job.ID.Hex(),
+		Kind:      job.Kind,
+		Status:    job.Status,
+		Total:     job.Total,
+		Processed: job.Processed,
+		Error:     job.Error,
+		CreatedBy: job.CreatedBy,
+		Created:   job.Created,
+		Updated:   job.Updated,
+	}
+	if job.Result != "" {
+		jobData.Result = json.RawMessage(job.Result)
+	}
+	return jobData
+}
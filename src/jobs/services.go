@@ -0,0 +1,86 @@
+package jobs
+
+import (
+	"context"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	database "systems-management-api/core/database"
+	"time"
+)
+
+// retention returns how long jobs are kept after their creation
+func retention() time.Duration {
+	days := viper.GetInt("jobs.retentionDays")
+	if days <= 0 {
+		days = 7
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// EnsureIndexes creates the job collection indexes
+// Jobs expire after the retention period
+func EnsureIndexes() {
+	db := database.DB()
+	err := db.EnsureIndexes("job", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "expireat", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0).SetName("ttl_expireat")},
+	})
+	if err != nil {
+		zap.S().Error("Error creating job indexes: ", err)
+	}
+}
+
+// JobService service which provides methods to access and modify database data
+type JobService struct{}
+
+// Retrieves a job instance given its ID
+func (service *JobService) GetById(id string) (*Job, error) {
+	db := database.DB()
+	job := Job{}
+
+	if err := db.GetById("job", id, &job); err != nil {
+		return nil, err
+	} else {
+		return &job, nil
+	}
+}
+
+// Saves the job model to database
+// Returns boolean result and error
+func (service *JobService) Save(job *Job) (bool, error) {
+	db := database.DB()
+	collection := db.D.Collection("job")
+
+	job.Updated = time.Now().Unix()
+	if job.ID.IsZero() {
+		res, err := collection.InsertOne(context.TODO(), job)
+		if err != nil {
+			zap.S().Error("Error inserting job: ", err)
+			return false, err
+		}
+		job.ID = res.InsertedID.(primitive.ObjectID)
+		return true, nil
+	}
+	if _, err := collection.ReplaceOne(context.TODO(), bson.M{"_id": job.ID}, job); err != nil {
+		zap.S().Error("Error updating job: ", err)
+		return false, err
+	}
+	return true, nil
+}
+
+// Stores the number of processed items of the job
+func (service *JobService) SetProgress(job *Job, processed int) error {
+	db := database.DB()
+	collection := db.D.Collection("job")
+
+	job.Processed = processed
+	job.Updated = time.Now().Unix()
+	_, err := collection.UpdateOne(context.TODO(), bson.M{"_id": job.ID}, bson.M{"$set": bson.M{"processed": job.Processed, "updated": job.Updated}})
+	if err != nil {
+		zap.S().Error("Error updating job progress: ", err)
+	}
+	return err
+}
@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"systems-management-api/auth"
+	"systems-management-api/core/utils"
+)
+
+// Returns job given its id
+// @Summary Job status
+// @Description Retrieves the status, progress and (when completed) the result of a background job
+// @Security BearerAuth
+// @Tags jobs
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Job ID"
+// @Success 200 {object} JobData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /job/{id} [get]
+func jobDetailView(c *gin.Context) {
+	jobService := new(JobService)
+	job, err := jobService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting job, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Job not found"})
+	} else {
+		serializer := NewJobSerializer()
+		c.JSON(http.StatusOK, serializer.Serialize(job))
+	}
+}
+
+var JobDetailView = auth.RoleRequired([]string{"admin", "superadmin"}, jobDetailView)
@@ -9,10 +9,16 @@ import (
 	"go.uber.org/zap"
 	"os"
 	"systems-management-api/auth"
+	"systems-management-api/contacts"
 	_ "systems-management-api/core/logger"
 	m "systems-management-api/core/middlewares"
+	"systems-management-api/customfields"
 	_ "systems-management-api/docs"
 	"systems-management-api/domains"
+	"systems-management-api/jobs"
+	"systems-management-api/packages"
+	"systems-management-api/servers"
+	"systems-management-api/stats"
 )
 
 func init() {
@@ -58,5 +64,33 @@ func main() {
 	api := r.Group("/api")
 	auth.RoutesRegister(api.Group("/auth"))
 	domains.RoutesRegister(api.Group("/domain"))
+	domains.TrashRoutesRegister(api.Group("/trash/domain"))
+	servers.RoutesRegister(api.Group("/server"))
+	domains.ServerRoutesRegister(api.Group("/server"))
+	packages.RoutesRegister(api.Group("/package"))
+	contacts.RoutesRegister(api.Group("/contact"))
+	domains.ContactRoutesRegister(api.Group("/contact"))
+	domains.ReportRoutesRegister(api.Group("/report"))
+	contacts.ReportRoutesRegister(api.Group("/report"))
+	domains.ImportRoutesRegister(api.Group("/import/domain"))
+	domains.BulkRoutesRegister(api.Group("/bulk/domain"))
+	jobs.RoutesRegister(api.Group("/job"))
+	customfields.RoutesRegister(api.Group("/customfield"))
+	domains.ExportRoutesRegister(api.Group("/export"))
+	auth.ExportRoutesRegister(api.Group("/export"))
+	domains.CalendarRoutesRegister(api.Group("/calendar"))
+	stats.RoutesRegister(api.Group("/stats"))
+
+	// database schema and background jobs
+	auth.EnsureIndexes()
+	servers.EnsureIndexes()
+	packages.EnsureIndexes()
+	contacts.EnsureIndexes()
+	jobs.EnsureIndexes()
+	customfields.EnsureIndexes()
+	domains.Migrate()
+	domains.EnsureIndexes()
+	domains.StartJobs()
+
 	r.Run()
 }
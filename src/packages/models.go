@@ -0,0 +1,31 @@
+package packages
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive" // for BSON ObjectID
+)
+
+// Package the hosting package model
+type Package struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	Name           string             `json:"name"`
+	Description    string             `json:"description"`
+	DiskQuota      int64              `json:"diskQuota"`
+	MailboxesQuota int64              `json:"mailboxesQuota"`
+	MonthlyPrice   float64            `json:"monthlyPrice"`
+	YearlyPrice    float64            `json:"yearlyPrice"`
+	Active         bool               `json:"active"`
+	Created        int64              `json:"created"`
+	Updated        int64              `json:"updated"`
+}
+
+func (self *Package) Save() (bool, error) {
+	packageService := new(PackageService) // @TODO factory method
+	result, err := packageService.Save(self)
+	return result, err
+}
+
+func (self *Package) Delete() (bool, error) {
+	packageService := new(PackageService) // @TODO factory method
+	result, err := packageService.Delete(self)
+	return result, err
+}
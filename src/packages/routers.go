@@ -0,0 +1,14 @@
+package packages
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RoutesRegister attaches routes (path + view) to the given gin router group (paths namespace)
+func RoutesRegister(router *gin.RouterGroup) {
+	router.GET("", PackageListView)
+	router.GET("/:id", PackageDetailView)
+	router.POST("", CreatePackageView)
+	router.PUT("/:id", UpdatePackageView)
+	router.DELETE("/:id", DeletePackageView)
+}
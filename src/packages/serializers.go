@@ -0,0 +1,45 @@
+package packages
+
+type packageSerializer struct{}
+
+type PackageData struct {
+	ID             string  `json:"id"`
+	Name           string  `json:"name"`
+	Description    string  `json:"description"`
+	DiskQuota      int64   `json:"diskQuota"`
+	MailboxesQuota int64   `json:"mailboxesQuota"`
+	MonthlyPrice   float64 `json:"monthlyPrice"`
+	YearlyPrice    float64 `json:"yearlyPrice"`
+	Active         bool    `json:"active"`
+	Created        int64   `json:"created"`
+	Updated        int64   `json:"updated"`
+}
+
+func NewPackageSerializer() *packageSerializer {
+	return &packageSerializer{}
+}
+
+func (self *packageSerializer) Serialize(pkg *Package) PackageData {
+	packageData := PackageData{
+		ID:             pkg.ID.Hex(),
+		Name:           pkg.Name,
+		Description:    pkg.Description,
+		DiskQuota:      pkg.DiskQuota,
+		MailboxesQuota: pkg.MailboxesQuota,
+		MonthlyPrice:   pkg.MonthlyPrice,
+		YearlyPrice:    pkg.YearlyPrice,
+		Active:         pkg.Active,
+		Created:        pkg.Created,
+		Updated:        pkg.Updated,
+	}
+	return packageData
+}
+
+func (self *packageSerializer) SerializeMany(pkgs *[]Package) []PackageData {
+	var res []PackageData
+	res = make([]PackageData, 0)
+	for _, pkg := range *pkgs {
+		res = append(res, self.Serialize(&pkg))
+	}
+	return res
+}
@@ -0,0 +1,127 @@
+package packages
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	database "systems-management-api/core/database"
+	"systems-management-api/core/references"
+)
+
+// EnsureIndexes creates the package collection indexes
+func EnsureIndexes() {
+	db := database.DB()
+	err := db.EnsureIndexes("package", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetUnique(true).SetName("unique_name")},
+	})
+	if err != nil {
+		zap.S().Error("Error creating package indexes: ", err)
+	}
+}
+
+// PackageService service which provides methods to access and modify database data
+type PackageService struct{}
+
+// Retrieves all packages instances, sorted by name
+func (service *PackageService) All() (*[]Package, error) {
+	db := database.DB()
+	collection := db.D.Collection("package")
+	cursor, err := collection.Find(context.TODO(), bson.D{{}}, options.Find().SetSort(bson.M{"name": 1}))
+
+	if err != nil {
+		return nil, err
+	} else {
+		pkgs := []Package{}
+		for cursor.Next(context.TODO()) {
+			var pkg Package
+			cursor.Decode(&pkg)
+			pkgs = append(pkgs, pkg)
+		}
+		return &pkgs, nil
+	}
+}
+
+// Retrieves a package instance given its ID
+func (service *PackageService) GetById(id string) (*Package, error) {
+	db := database.DB()
+	pkg := Package{}
+
+	if err := db.GetById("package", id, &pkg); err != nil {
+		return nil, err
+	} else {
+		return &pkg, nil
+	}
+}
+
+// Retrieves a package instance given its name
+func (service *PackageService) GetByName(name string) (*Package, error) {
+	db := database.DB()
+	collection := db.D.Collection("package")
+	pkg := Package{}
+
+	if err := collection.FindOne(context.TODO(), bson.M{"name": name}).Decode(&pkg); err != nil {
+		return nil, err
+	} else {
+		return &pkg, nil
+	}
+}
+
+// Returns the number of documents referencing the package, grouped by collection
+func (service *PackageService) References(pkg *Package) (map[string]int64, error) {
+	return references.Count("package", pkg.ID)
+}
+
+// Saves the package model to database
+// Returns boolean result and error
+func (service *PackageService) Save(pkg *Package) (bool, error) {
+	db := database.DB()
+	collection := db.D.Collection("package")
+
+	if pkg.ID.IsZero() {
+		// insert
+		res, err := collection.InsertOne(context.TODO(), pkg)
+
+		if err != nil {
+			zap.S().Error("Error inserting package: ", err)
+			return false, err
+		} else {
+			zap.S().Info(fmt.Sprintf("Package %s inserted succesfully", pkg.Name))
+			// update package ID
+			pkg.ID = res.InsertedID.(primitive.ObjectID)
+			return true, nil
+		}
+	} else {
+		// update
+		filter := bson.M{"_id": pkg.ID}
+		_, err := collection.ReplaceOne(context.TODO(), filter, pkg)
+
+		if err != nil {
+			zap.S().Error("Error updating package: ", err)
+			return false, err
+		} else {
+			zap.S().Info(fmt.Sprintf("Package %s updated succesfully", pkg.Name))
+			return true, nil
+		}
+	}
+}
+
+// Deletes the package model from databse
+// Returns boolean result and error
+func (service *PackageService) Delete(pkg *Package) (bool, error) {
+	db := database.DB()
+	collection := db.D.Collection("package")
+
+	_, err := collection.DeleteOne(context.TODO(), bson.M{"_id": pkg.ID})
+
+	if err != nil {
+		zap.S().Error("Error deleting package: ", err)
+		return false, err
+	} else {
+		zap.S().Info(fmt.Sprintf("Package %s deleted succesfully", pkg.Name))
+		return true, nil
+	}
+}
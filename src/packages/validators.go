@@ -0,0 +1,69 @@
+package packages
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+type PackageValidatorData struct {
+	Name           string  `json:"name" binding:"required,max=255"`
+	Description    string  `json:"description"`
+	DiskQuota      int64   `json:"diskQuota" binding:"gte=0"`      // MB, 0 means unlimited
+	MailboxesQuota int64   `json:"mailboxesQuota" binding:"gte=0"` // 0 means unlimited
+	MonthlyPrice   float64 `json:"monthlyPrice" binding:"gte=0"`
+	YearlyPrice    float64 `json:"yearlyPrice" binding:"gte=0"`
+	Active         *bool   `json:"active"` // true by default on create, unchanged when omitted on update
+}
+type PackageValidator struct {
+	PackageData PackageValidatorData `json:"package"`
+	pkg         Package              `json:"-"`
+}
+
+func (self *PackageValidator) fillModelData() {
+	self.pkg.Name = strings.TrimSpace(self.PackageData.Name)
+	self.pkg.Description = self.PackageData.Description
+	self.pkg.DiskQuota = self.PackageData.DiskQuota
+	self.pkg.MailboxesQuota = self.PackageData.MailboxesQuota
+	self.pkg.MonthlyPrice = self.PackageData.MonthlyPrice
+	self.pkg.YearlyPrice = self.PackageData.YearlyPrice
+	if self.PackageData.Active != nil {
+		self.pkg.Active = *self.PackageData.Active
+	}
+}
+
+func (self *PackageValidator) Bind(c *gin.Context) error {
+	err := c.ShouldBind(&self.PackageData)
+	if err != nil {
+		zap.S().Debug("Package Validation Error: ", err)
+		return err
+	}
+	self.pkg.Active = true
+	self.fillModelData()
+	self.pkg.Created = time.Now().Unix()
+	self.pkg.Updated = time.Now().Unix()
+
+	return nil
+}
+
+func (self *PackageValidator) BindUpdate(pkg *Package, c *gin.Context) error {
+	err := c.ShouldBind(&self.PackageData)
+	if err != nil {
+		zap.S().Debug("Package Validation Error: ", err)
+		return err
+	}
+	self.pkg.ID = pkg.ID
+	self.pkg.Active = pkg.Active
+	self.fillModelData()
+	self.pkg.Created = pkg.Created
+	self.pkg.Updated = time.Now().Unix()
+
+	return nil
+}
+
+// You can put the default value of a Validator here
+func NewPackageValidator() PackageValidator {
+	packageValidator := PackageValidator{}
+	return packageValidator
+}
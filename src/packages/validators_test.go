@@ -0,0 +1,42 @@
+package packages
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func packageContext(body string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	return c
+}
+
+func TestPackageActive(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	validator := NewPackageValidator()
+	if err := validator.Bind(packageContext(`{"name": "Basic"}`)); err != nil || !validator.pkg.Active {
+		t.Fatalf("expected a new package to be active by default, got %v", err)
+	}
+
+	inactive := Package{ID: primitive.NewObjectID(), Name: "Basic", Active: false}
+	validator = NewPackageValidator()
+	if err := validator.BindUpdate(&inactive, packageContext(`{"name": "Basic plan"}`)); err != nil || validator.pkg.Active {
+		t.Fatalf("expected the update omitting active to keep the package inactive, got %v", err)
+	}
+	validator = NewPackageValidator()
+	if err := validator.BindUpdate(&inactive, packageContext(`{"name": "Basic", "active": true}`)); err != nil || !validator.pkg.Active {
+		t.Fatalf("expected the update to reactivate the package, got %v", err)
+	}
+
+	active := Package{ID: primitive.NewObjectID(), Name: "Basic", Active: true}
+	validator = NewPackageValidator()
+	if err := validator.BindUpdate(&active, packageContext(`{"name": "Basic", "active": false}`)); err != nil || validator.pkg.Active {
+		t.Fatalf("expected the update to deactivate the package, got %v", err)
+	}
+}
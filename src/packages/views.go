@@ -0,0 +1,195 @@
+package packages
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"net/http"
+	"systems-management-api/auth"
+	"systems-management-api/core/utils"
+)
+
+// duplicateNameResponse error returned when saving a package whose name is already taken
+func duplicateNameResponse(pkg *Package) utils.ErrorResponse {
+	return utils.ErrorResponse{Message: fmt.Sprintf("A package named %s already exists", pkg.Name)}
+}
+
+// Returns all packages, admin or superadmin roles required
+// @Summary Packages list
+// @Description Retrieves all packages
+// @Security BearerAuth
+// @Tags packages
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} PackageData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /package/ [get]
+func packageListView(c *gin.Context) {
+	packageService := new(PackageService)
+	pkgs, err := packageService.All()
+
+	if err != nil {
+		zap.S().Error("Error while getting all packages, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{
+			Message: "Cannot fetch packages",
+		})
+	} else {
+		serializer := NewPackageSerializer()
+		c.JSON(http.StatusOK, serializer.SerializeMany(pkgs))
+	}
+}
+
+var PackageListView = auth.RoleRequired([]string{"admin", "superadmin"}, packageListView)
+
+// Returns package given its id
+// @Summary Package detail
+// @Description Retrieves one package given its id
+// @Security BearerAuth
+// @Tags packages
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Package ID"
+// @Success 200 {object} PackageData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /package/{id} [get]
+func packageDetailView(c *gin.Context) {
+	packageService := new(PackageService)
+	pkg, err := packageService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting package, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Package not found"})
+	} else {
+		serializer := NewPackageSerializer()
+		c.JSON(http.StatusOK, serializer.Serialize(pkg))
+	}
+}
+
+var PackageDetailView = auth.RoleRequired([]string{"admin", "superadmin"}, packageDetailView)
+
+// Creates a package
+// @Summary Create package
+// @Description Creates a package
+// @Security BearerAuth
+// @Tags packages
+// @Accept  json
+// @Produce  json
+// @Param package body PackageValidatorData true "Package data"
+// @Success 201 {object} PackageData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /package/ [post]
+func createPackageView(c *gin.Context) {
+	packageValidator := NewPackageValidator()
+	if err := packageValidator.Bind(c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if _, err := packageValidator.pkg.Save(); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, duplicateNameResponse(&packageValidator.pkg))
+			return
+		}
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Cannot insert package: %v", err)})
+		return
+	}
+	serializer := NewPackageSerializer()
+	c.JSON(http.StatusCreated, serializer.Serialize(&packageValidator.pkg))
+}
+
+var CreatePackageView = auth.RoleRequired([]string{"admin", "superadmin"}, createPackageView)
+
+// Updates a package
+// @Summary Update package
+// @Description Updates a package
+// @Security BearerAuth
+// @Tags packages
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Package ID"
+// @Param package body PackageValidatorData true "Package data"
+// @Success 200 {object} PackageData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /package/{id} [put]
+func updatePackageView(c *gin.Context) {
+	packageService := new(PackageService)
+	pkg, err := packageService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting package, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Package not found"})
+		return
+	}
+
+	packageValidator := NewPackageValidator()
+	if err := packageValidator.BindUpdate(pkg, c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if _, err := packageValidator.pkg.Save(); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, duplicateNameResponse(&packageValidator.pkg))
+			return
+		}
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Cannot update package: %v", err)})
+		return
+	}
+	serializer := NewPackageSerializer()
+	c.JSON(http.StatusOK, serializer.Serialize(&packageValidator.pkg))
+}
+
+var UpdatePackageView = auth.RoleRequired([]string{"admin", "superadmin"}, updatePackageView)
+
+// Deletes a package, packages still referenced (i.e. by domains) cannot be deleted
+// @Summary Delete package
+// @Description Deletes a package which is not referenced by other resources
+// @Security BearerAuth
+// @Tags packages
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Package ID"
+// @Success 204
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /package/{id} [delete]
+func deletePackageView(c *gin.Context) {
+	packageService := new(PackageService)
+	pkg, err := packageService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting package, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Package not found"})
+		return
+	}
+
+	refs, err := packageService.References(pkg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+	if len(refs) > 0 {
+		c.JSON(http.StatusConflict, utils.ErrorResponse{
+			Message: fmt.Sprintf("Package %s is still referenced: %v", pkg.Name, refs),
+		})
+		return
+	}
+
+	if _, err := pkg.Delete(); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, gin.H{})
+}
+
+var DeletePackageView = auth.RoleRequired([]string{"admin", "superadmin"}, deletePackageView)
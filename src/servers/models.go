@@ -0,0 +1,30 @@
+package servers
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive" // for BSON ObjectID
+)
+
+// Server the server model
+type Server struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty"`
+	Hostname string             `json:"hostname"`
+	Ips      []string           `json:"ips"`
+	Provider string             `json:"provider"`
+	Location string             `json:"location"`
+	Os       string             `json:"os"`
+	Notes    string             `json:"notes"`
+	Created  int64              `json:"created"`
+	Updated  int64              `json:"updated"`
+}
+
+func (self *Server) Save() (bool, error) {
+	serverService := new(ServerService) // @TODO factory method
+	result, err := serverService.Save(self)
+	return result, err
+}
+
+func (self *Server) Delete() (bool, error) {
+	serverService := new(ServerService) // @TODO factory method
+	result, err := serverService.Delete(self)
+	return result, err
+}
@@ -0,0 +1,14 @@
+package servers
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RoutesRegister attaches routes (path + view) to the given gin router group (paths namespace)
+func RoutesRegister(router *gin.RouterGroup) {
+	router.GET("", ServerListView)
+	router.GET("/:id", ServerDetailView)
+	router.POST("", CreateServerView)
+	router.PUT("/:id", UpdateServerView)
+	router.DELETE("/:id", DeleteServerView)
+}
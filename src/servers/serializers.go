@@ -0,0 +1,47 @@
+package servers
+
+type serverSerializer struct{}
+
+type ServerData struct {
+	ID       string   `json:"id"`
+	Hostname string   `json:"hostname"`
+	Ips      []string `json:"ips"`
+	Provider string   `json:"provider"`
+	Location string   `json:"location"`
+	Os       string   `json:"os"`
+	Notes    string   `json:"notes"`
+	Created  int64    `json:"created"`
+	Updated  int64    `json:"updated"`
+}
+
+func NewServerSerializer() *serverSerializer {
+	return &serverSerializer{}
+}
+
+func (self *serverSerializer) Serialize(server *Server) ServerData {
+	ips := server.Ips
+	if ips == nil {
+		ips = make([]string, 0)
+	}
+	serverData := ServerData{
+		ID:       server.ID.Hex(),
+		Hostname: server.Hostname,
+		Ips:      ips,
+		Provider: server.Provider,
+		Location: server.Location,
+		Os:       server.Os,
+		Notes:    server.Notes,
+		Created:  server.Created,
+		Updated:  server.Updated,
+	}
+	return serverData
+}
+
+func (self *serverSerializer) SerializeMany(servers *[]Server) []ServerData {
+	var res []ServerData
+	res = make([]ServerData, 0)
+	for _, server := range *servers {
+		res = append(res, self.Serialize(&server))
+	}
+	return res
+}
@@ -0,0 +1,127 @@
+package servers
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	database "systems-management-api/core/database"
+	"systems-management-api/core/references"
+)
+
+// EnsureIndexes creates the server collection indexes
+func EnsureIndexes() {
+	db := database.DB()
+	err := db.EnsureIndexes("server", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "hostname", Value: 1}}, Options: options.Index().SetUnique(true).SetName("unique_hostname")},
+	})
+	if err != nil {
+		zap.S().Error("Error creating server indexes: ", err)
+	}
+}
+
+// ServerService service which provides methods to access and modify database data
+type ServerService struct{}
+
+// Retrieves all servers instances
+func (service *ServerService) All() (*[]Server, error) {
+	db := database.DB()
+	collection := db.D.Collection("server")
+	cursor, err := collection.Find(context.TODO(), bson.D{{}}, options.Find().SetSort(bson.M{"hostname": 1}))
+
+	if err != nil {
+		return nil, err
+	} else {
+		servers := []Server{}
+		for cursor.Next(context.TODO()) {
+			var server Server
+			cursor.Decode(&server)
+			servers = append(servers, server)
+		}
+		return &servers, nil
+	}
+}
+
+// Retrieves a server instance given its ID
+func (service *ServerService) GetById(id string) (*Server, error) {
+	db := database.DB()
+	server := Server{}
+
+	if err := db.GetById("server", id, &server); err != nil {
+		return nil, err
+	} else {
+		return &server, nil
+	}
+}
+
+// Retrieves a server instance given its hostname
+func (service *ServerService) GetByHostname(hostname string) (*Server, error) {
+	db := database.DB()
+	collection := db.D.Collection("server")
+	server := Server{}
+
+	if err := collection.FindOne(context.TODO(), bson.M{"hostname": hostname}).Decode(&server); err != nil {
+		return nil, err
+	} else {
+		return &server, nil
+	}
+}
+
+// Returns the number of documents referencing the server, grouped by collection
+func (service *ServerService) References(server *Server) (map[string]int64, error) {
+	return references.Count("server", server.ID)
+}
+
+// Saves the server model to database
+// Returns boolean result and error
+func (service *ServerService) Save(server *Server) (bool, error) {
+	db := database.DB()
+	collection := db.D.Collection("server")
+
+	if server.ID.IsZero() {
+		// insert
+		res, err := collection.InsertOne(context.TODO(), server)
+
+		if err != nil {
+			zap.S().Error("Error inserting server: ", err)
+			return false, err
+		} else {
+			zap.S().Info(fmt.Sprintf("Server %s inserted succesfully", server.Hostname))
+			// update server ID
+			server.ID = res.InsertedID.(primitive.ObjectID)
+			return true, nil
+		}
+	} else {
+		// update
+		filter := bson.M{"_id": server.ID}
+		_, err := collection.ReplaceOne(context.TODO(), filter, server)
+
+		if err != nil {
+			zap.S().Error("Error updating server: ", err)
+			return false, err
+		} else {
+			zap.S().Info(fmt.Sprintf("Server %s updated succesfully", server.Hostname))
+			return true, nil
+		}
+	}
+}
+
+// Deletes the server model from databse
+// Returns boolean result and error
+func (service *ServerService) Delete(server *Server) (bool, error) {
+	db := database.DB()
+	collection := db.D.Collection("server")
+
+	_, err := collection.DeleteOne(context.TODO(), bson.M{"_id": server.ID})
+
+	if err != nil {
+		zap.S().Error("Error deleting server: ", err)
+		return false, err
+	} else {
+		zap.S().Info(fmt.Sprintf("Server %s deleted succesfully", server.Hostname))
+		return true, nil
+	}
+}
@@ -0,0 +1,67 @@
+package servers
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net"
+	"strings"
+	"time"
+)
+
+type ServerValidatorData struct {
+	Hostname string   `json:"hostname" binding:"required,hostname_rfc1123"`
+	Ips      []string `json:"ips" binding:"dive,ip"`
+	Provider string   `json:"provider"`
+	Location string   `json:"location"`
+	Os       string   `json:"os"`
+	Notes    string   `json:"notes"`
+}
+type ServerValidator struct {
+	ServerData ServerValidatorData `json:"server"`
+	server     Server              `json:"-"`
+}
+
+func (self *ServerValidator) fillModelData() {
+	self.server.Hostname = strings.ToLower(strings.TrimSuffix(self.ServerData.Hostname, "."))
+	self.server.Ips = make([]string, 0)
+	for _, ip := range self.ServerData.Ips {
+		self.server.Ips = append(self.server.Ips, net.ParseIP(ip).String())
+	}
+	self.server.Provider = self.ServerData.Provider
+	self.server.Location = self.ServerData.Location
+	self.server.Os = self.ServerData.Os
+	self.server.Notes = self.ServerData.Notes
+}
+
+func (self *ServerValidator) Bind(c *gin.Context) error {
+	err := c.ShouldBind(&self.ServerData)
+	if err != nil {
+		zap.S().Debug("Server Validation Error: ", err)
+		return err
+	}
+	self.fillModelData()
+	self.server.Created = time.Now().Unix()
+	self.server.Updated = time.Now().Unix()
+
+	return nil
+}
+
+func (self *ServerValidator) BindUpdate(server *Server, c *gin.Context) error {
+	err := c.ShouldBind(&self.ServerData)
+	if err != nil {
+		zap.S().Debug("Server Validation Error: ", err)
+		return err
+	}
+	self.server.ID = server.ID
+	self.fillModelData()
+	self.server.Created = server.Created
+	self.server.Updated = time.Now().Unix()
+
+	return nil
+}
+
+// You can put the default value of a Validator here
+func NewServerValidator() ServerValidator {
+	serverValidator := ServerValidator{}
+	return serverValidator
+}
@@ -0,0 +1,195 @@
+package servers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"net/http"
+	"systems-management-api/auth"
+	"systems-management-api/core/utils"
+)
+
+// duplicateHostnameResponse error returned when saving a server whose hostname is already taken
+func duplicateHostnameResponse(server *Server) utils.ErrorResponse {
+	return utils.ErrorResponse{Message: fmt.Sprintf("A server with hostname %s already exists", server.Hostname)}
+}
+
+// Returns all servers, admin or superadmin roles required
+// @Summary Servers list
+// @Description Retrieves all servers
+// @Security BearerAuth
+// @Tags servers
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} ServerData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /server/ [get]
+func serverListView(c *gin.Context) {
+	serverService := new(ServerService)
+	servers, err := serverService.All()
+
+	if err != nil {
+		zap.S().Error("Error while getting all servers, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{
+			Message: "Cannot fetch servers",
+		})
+	} else {
+		serializer := NewServerSerializer()
+		c.JSON(http.StatusOK, serializer.SerializeMany(servers))
+	}
+}
+
+var ServerListView = auth.RoleRequired([]string{"admin", "superadmin"}, serverListView)
+
+// Returns server given its id
+// @Summary Server detail
+// @Description Retrieves one server given its id
+// @Security BearerAuth
+// @Tags servers
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Server ID"
+// @Success 200 {object} ServerData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /server/{id} [get]
+func serverDetailView(c *gin.Context) {
+	serverService := new(ServerService)
+	server, err := serverService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting server, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Server not found"})
+	} else {
+		serializer := NewServerSerializer()
+		c.JSON(http.StatusOK, serializer.Serialize(server))
+	}
+}
+
+var ServerDetailView = auth.RoleRequired([]string{"admin", "superadmin"}, serverDetailView)
+
+// Creates a server
+// @Summary Create server
+// @Description Creates a server
+// @Security BearerAuth
+// @Tags servers
+// @Accept  json
+// @Produce  json
+// @Param server body ServerValidatorData true "Server data"
+// @Success 201 {object} ServerData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /server/ [post]
+func createServerView(c *gin.Context) {
+	serverValidator := NewServerValidator()
+	if err := serverValidator.Bind(c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if _, err := serverValidator.server.Save(); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, duplicateHostnameResponse(&serverValidator.server))
+			return
+		}
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Cannot insert server: %v", err)})
+		return
+	}
+	serializer := NewServerSerializer()
+	c.JSON(http.StatusCreated, serializer.Serialize(&serverValidator.server))
+}
+
+var CreateServerView = auth.RoleRequired([]string{"admin", "superadmin"}, createServerView)
+
+// Updates a server
+// @Summary Update server
+// @Description Updates a server
+// @Security BearerAuth
+// @Tags servers
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Server ID"
+// @Param server body ServerValidatorData true "Server data"
+// @Success 200 {object} ServerData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /server/{id} [put]
+func updateServerView(c *gin.Context) {
+	serverService := new(ServerService)
+	server, err := serverService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting server, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Server not found"})
+		return
+	}
+
+	serverValidator := NewServerValidator()
+	if err := serverValidator.BindUpdate(server, c); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if _, err := serverValidator.server.Save(); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, duplicateHostnameResponse(&serverValidator.server))
+			return
+		}
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse{Message: fmt.Sprintf("Cannot update server: %v", err)})
+		return
+	}
+	serializer := NewServerSerializer()
+	c.JSON(http.StatusOK, serializer.Serialize(&serverValidator.server))
+}
+
+var UpdateServerView = auth.RoleRequired([]string{"admin", "superadmin"}, updateServerView)
+
+// Deletes a server, servers still referenced (i.e. by domains) cannot be deleted
+// @Summary Delete server
+// @Description Deletes a server which is not referenced by other resources
+// @Security BearerAuth
+// @Tags servers
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Server ID"
+// @Success 204
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /server/{id} [delete]
+func deleteServerView(c *gin.Context) {
+	serverService := new(ServerService)
+	server, err := serverService.GetById(c.Param("id"))
+
+	if err != nil {
+		zap.S().Errorw("Error while getting server, Reason: ", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusNotFound, utils.ErrorResponse{Message: "Server not found"})
+		return
+	}
+
+	refs, err := serverService.References(server)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+	if len(refs) > 0 {
+		c.JSON(http.StatusConflict, utils.ErrorResponse{
+			Message: fmt.Sprintf("Server %s is still referenced: %v", server.Hostname, refs),
+		})
+		return
+	}
+
+	if _, err := server.Delete(); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, gin.H{})
+}
+
+var DeleteServerView = auth.RoleRequired([]string{"admin", "superadmin"}, deleteServerView)
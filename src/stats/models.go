@@ -0,0 +1,13 @@
+package stats
+
+import (
+	"systems-management-api/domains"
+	"time"
+)
+
+// Stats the dashboard statistics of domains and users
+type Stats struct {
+	Domains     domains.DomainStats
+	UsersByRole map[string]int64
+	GeneratedAt time.Time
+}
@@ -0,0 +1,84 @@
+package stats
+
+import (
+	"sort"
+	"systems-management-api/domains"
+)
+
+type statsSerializer struct{}
+
+type StatsData struct {
+	Domains     DomainStatsData `json:"domains"`
+	Users       UserStatsData   `json:"users"`
+	GeneratedAt int64           `json:"generatedAt"`
+}
+
+type DomainStatsData struct {
+	Total        int64            `json:"total"`
+	Mx           int64            `json:"mx"`
+	ExpiringSoon int64            `json:"expiringSoon"`
+	ExpiringDays int              `json:"expiringDays"`
+	ByPackage    []CountData      `json:"byPackage"`
+	ByServer     []CountData      `json:"byServer"`
+	ByOwner      []CountData      `json:"byOwner"`
+	PerMonth     []MonthCountData `json:"perMonth"`
+}
+
+type CountData struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+type MonthCountData struct {
+	Month string `json:"month"`
+	Count int64  `json:"count"`
+}
+
+type UserStatsData struct {
+	Total  int64           `json:"total"`
+	ByRole []RoleCountData `json:"byRole"`
+}
+
+type RoleCountData struct {
+	Role  string `json:"role"`
+	Count int64  `json:"count"`
+}
+
+func NewStatsSerializer() *statsSerializer {
+	return &statsSerializer{}
+}
+
+func (self *statsSerializer) Serialize(stats *Stats) StatsData {
+	data := StatsData{
+		Domains: DomainStatsData{
+			Total:        stats.Domains.Total,
+			Mx:           stats.Domains.Mx,
+			ExpiringSoon: stats.Domains.ExpiringSoon,
+			ExpiringDays: stats.Domains.ExpiringDays,
+			ByPackage:    self.serializeCounts(stats.Domains.ByPackage),
+			ByServer:     self.serializeCounts(stats.Domains.ByServer),
+			ByOwner:      self.serializeCounts(stats.Domains.ByOwner),
+			PerMonth:     []MonthCountData{},
+		},
+		Users:       UserStatsData{ByRole: []RoleCountData{}},
+		GeneratedAt: stats.GeneratedAt.Unix(),
+	}
+	for _, month := range stats.Domains.PerMonth {
+		data.Domains.PerMonth = append(data.Domains.PerMonth, MonthCountData{Month: month.Month, Count: month.Count})
+	}
+	for role, count := range stats.UsersByRole {
+		data.Users.Total += count
+		data.Users.ByRole = append(data.Users.ByRole, RoleCountData{Role: role, Count: count})
+	}
+	sort.Slice(data.Users.ByRole, func(i, j int) bool { return data.Users.ByRole[i].Role < data.Users.ByRole[j].Role })
+	return data
+}
+
+func (self *statsSerializer) serializeCounts(counts []domains.StatsCount) []CountData {
+	res := make([]CountData, 0)
+	for _, count := range counts {
+		res = append(res, CountData{ID: count.ID.Hex(), Name: count.Name, Count: count.Count})
+	}
+	return res
+}
@@ -0,0 +1,57 @@
+package stats
+
+import (
+	"github.com/spf13/viper"
+	"sync"
+	"systems-management-api/auth"
+	"systems-management-api/domains"
+	"time"
+)
+
+// cache the last computed statistics, shared by all the requests
+var cache struct {
+	sync.Mutex
+	stats *Stats
+}
+
+// cacheDuration returns how long the computed statistics are served before being computed again
+func cacheDuration() time.Duration {
+	seconds := viper.GetInt("stats.cacheSeconds")
+	if seconds <= 0 {
+		seconds = 60
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// StatsService service which computes the dashboard statistics
+type StatsService struct{}
+
+// Returns the cached statistics, computing them if they are older than the cache duration or if refresh is requested
+// Concurrent requests wait for the statistics being computed instead of running the aggregations again
+func (service *StatsService) Get(refresh bool) (*Stats, error) {
+	cache.Lock()
+	defer cache.Unlock()
+
+	if !refresh && cache.stats != nil && time.Since(cache.stats.GeneratedAt) < cacheDuration() {
+		return cache.stats, nil
+	}
+	stats, err := service.compute()
+	if err != nil {
+		return nil, err
+	}
+	cache.stats = stats
+	return stats, nil
+}
+
+// compute runs the statistics aggregations
+func (service *StatsService) compute() (*Stats, error) {
+	domainStats, err := new(domains.DomainService).Stats()
+	if err != nil {
+		return nil, err
+	}
+	users, err := new(auth.UserService).CountByRole()
+	if err != nil {
+		return nil, err
+	}
+	return &Stats{Domains: *domainStats, UsersByRole: users, GeneratedAt: time.Now()}, nil
+}
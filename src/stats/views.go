@@ -0,0 +1,38 @@
+package stats
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"systems-management-api/auth"
+	"systems-management-api/core/utils"
+)
+
+// Returns the dashboard statistics of domains and users, admin or superadmin roles required
+// @Summary Dashboard statistics
+// @Description Retrieves the domain counts by package, server and owner (the owners having most domains), with mx enabled, expiring soon and created per month, and the user counts by role, trashed domains excluded. Statistics are cached for a short time (stats.cacheSeconds), refresh computes them again
+// @Security BearerAuth
+// @Tags stats
+// @Accept  json
+// @Produce  json
+// @Param refresh query bool false "Compute the statistics again instead of returning the cached ones"
+// @Success 200 {object} StatsData
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /stats [get]
+func statsView(c *gin.Context) {
+	refresh, _ := strconv.ParseBool(c.Query("refresh"))
+	statsService := new(StatsService)
+	stats, err := statsService.Get(refresh)
+
+	if err != nil {
+		zap.S().Error("Error while computing stats, Reason: ", err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse{Message: "Cannot compute statistics"})
+		return
+	}
+	serializer := NewStatsSerializer()
+	c.JSON(http.StatusOK, serializer.Serialize(stats))
+}
+
+var StatsView = auth.RoleRequired([]string{"admin", "superadmin"}, statsView)